@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+// DeletionPolicy defines what happens in Github when a resource is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyOrphan leaves Github unchanged. It is the default.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyDelete removes what the resource manages from Github.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRemoveMembersOnly removes the members of a team from Github and
+	// keeps the team itself.
+	DeletionPolicyRemoveMembersOnly DeletionPolicy = "RemoveMembersOnly"
+)
+
+// REPO_GUARD_FINALIZER blocks the deletion of a resource until its Github state is
+// cleaned up according to its deletion policy.
+const REPO_GUARD_FINALIZER = "repo-guard.cloudoperators.dev/cleanup"
+
+// NeedsCleanup reports whether p changes Github when the resource is deleted.
+func (p DeletionPolicy) NeedsCleanup() bool {
+	return p == DeletionPolicyDelete || p == DeletionPolicyRemoveMembersOnly
+}
@@ -15,12 +15,113 @@ type GithubSpec struct {
 	IntegrationID   int64  `json:"integrationID,omitempty"`
 	ClientUserAgent string `json:"clientUserAgent,omitempty"`
 	Secret          string `json:"secret,omitempty"`
+
+	// AuthMode selects the credentials read from the secret: "app" uses the
+	// GitHub App (integrationID and privateKey), "token" a personal access or
+	// fine-grained token stored under the "token" key. Defaults to "app".
+	// +kubebuilder:validation:Enum=app;token
+	// +optional
+	AuthMode GithubAuthMode `json:"authMode,omitempty"`
+
+	// Proxy routes all GitHub API calls of this Github through an HTTP proxy.
+	// +optional
+	Proxy *GithubProxy `json:"proxy,omitempty"`
+
+	// CABundle references PEM certificates trusted in addition to the system
+	// roots, for instances with a private CA.
+	// +optional
+	CABundle *CABundleReference `json:"caBundle,omitempty"`
+
+	// EnterpriseManagedUsers marks all organizations of this Github as part of
+	// an enterprise with managed users. GithubOrganizations can override it.
+	// +optional
+	EnterpriseManagedUsers *EnterpriseManagedUsers `json:"enterpriseManagedUsers,omitempty"`
+
+	// OrganizationDiscovery creates a GithubOrganization for every organization
+	// the GitHub App is installed on.
+	// +optional
+	OrganizationDiscovery *OrganizationDiscovery `json:"organizationDiscovery,omitempty"`
+}
+
+// OrganizationDiscovery configures the creation of GithubOrganizations from the
+// installations of the GitHub App. Discovered objects are named
+// "<github>--<organization>". Nothing is ever deleted: organizations whose
+// installation was removed are only labelled.
+type OrganizationDiscovery struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Namespace the GithubOrganizations are created in.
+	Namespace string `json:"namespace"`
+
+	// Interval between two discoveries as a Go duration. Defaults to 1h.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// Template the GithubOrganizations are created from.
+	// +optional
+	Template GithubOrganizationTemplate `json:"template,omitempty"`
+}
+
+// GithubOrganizationTemplate holds the defaults of discovered GithubOrganizations.
+// spec.github and spec.organization are always set by the discovery, and
+// spec.installationID is left to installation discovery.
+type GithubOrganizationTemplate struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// +optional
+	Spec GithubOrganizationSpec `json:"spec,omitempty"`
+}
+
+// EnterpriseManagedUsers configures GitHub Enterprise Managed Users (EMU).
+// Managed user logins are "<normalized IdP handle>_<shortcode>" and accounts
+// can only be provisioned through SCIM, so they are never invited.
+type EnterpriseManagedUsers struct {
+	// Shortcode of the enterprise, appended to every managed user login.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]+$`
+	Shortcode string `json:"shortcode"`
+}
+
+// GithubProxy configures the HTTP proxy of a Github.
+type GithubProxy struct {
+	// URL of the proxy, e.g. "http://proxy.example.com:3128".
+	URL string `json:"url"`
+
+	// NoProxy lists hosts, domains (".example.com") and CIDRs reached without
+	// the proxy, like the NO_PROXY environment variable.
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// CABundleReference selects the key of a Secret or ConfigMap holding PEM
+// certificates. Like the secret of the Github, it is looked up in the operator's
+// namespace for cluster-scoped Githubs.
+type CABundleReference struct {
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	// Key holding the PEM bundle. Defaults to "ca.crt".
+	// +optional
+	Key string `json:"key,omitempty"`
 }
 
+const CA_BUNDLE_DEFAULT_KEY = "ca.crt"
+
+type GithubAuthMode string
+
+const (
+	GithubAuthModeApp   GithubAuthMode = "app"
+	GithubAuthModeToken GithubAuthMode = "token"
+)
+
 const (
 	GITHUB_SECRET_CLIENT_ID_KEY     = "clientID"
 	GITHUB_SECRET_CLIENT_SECRET_KEY = "clientSecret"
 	SECRET_PRIVATE_KEY_KEY          = "privateKey"
+	GITHUB_SECRET_TOKEN_KEY         = "token"
+	// GITHUB_SECRET_WEBHOOK_SECRET_KEY holds the webhook secret of the GitHub App,
+	// used to validate the signature of webhook deliveries.
+	GITHUB_SECRET_WEBHOOK_SECRET_KEY = "webhookSecret"
 )
 
 // GithubStatus defines the observed state of Github
@@ -28,6 +129,41 @@ type GithubStatus struct {
 	State     GithubState `json:"state,omitempty"`
 	Error     string      `json:"error,omitempty"`
 	Timestamp metav1.Time `json:"timestamp,omitempty"`
+
+	// Token reports the validation of the token used with authMode "token".
+	// +optional
+	Token *GithubTokenStatus `json:"token,omitempty"`
+
+	// OrganizationDiscovery reports the result of the last organization discovery.
+	// +optional
+	OrganizationDiscovery *OrganizationDiscoveryStatus `json:"organizationDiscovery,omitempty"`
+}
+
+// GithubTokenStatus describes the token of a Github with authMode "token".
+type GithubTokenStatus struct {
+	// Login of the user the token belongs to.
+	Login string `json:"login,omitempty"`
+	// Scopes granted to a classic personal access token.
+	Scopes []string `json:"scopes,omitempty"`
+	// MissingScopes lists the required scopes not granted to a classic personal access token.
+	MissingScopes []string `json:"missingScopes,omitempty"`
+	// FineGrained is true for tokens that do not report OAuth scopes, such as
+	// fine-grained tokens. Their permissions are not validated.
+	FineGrained bool `json:"fineGrained,omitempty"`
+}
+
+// OrganizationDiscoveryStatus is the result of an organization discovery.
+type OrganizationDiscoveryStatus struct {
+	LastDiscovery metav1.Time `json:"lastDiscovery,omitempty"`
+	Error         string      `json:"error,omitempty"`
+
+	// Installations is the number of organizations the GitHub App is installed on.
+	Installations int `json:"installations,omitempty"`
+	// Created lists the GithubOrganizations created by the last discovery.
+	Created []string `json:"created,omitempty"`
+	// InstallationRemoved lists the GithubOrganizations of this Github whose
+	// organization has no active installation anymore.
+	InstallationRemoved []string `json:"installationRemoved,omitempty"`
 }
 
 type GithubState string
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY is set by an approver to their
+// Kubernetes user name to approve a GithubAccessRequest.
+const GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY = "repo-guard.cloudoperators.dev/approved-by"
+
+// GITHUB_ACCESS_REQUEST_ANNOTATION_DENIED_BY is set by an approver to their
+// Kubernetes user name to deny a GithubAccessRequest.
+const GITHUB_ACCESS_REQUEST_ANNOTATION_DENIED_BY = "repo-guard.cloudoperators.dev/denied-by"
+
+// GithubAccessRequestSpec requests time-bound access to a GithubTeam or to repositories
+type GithubAccessRequestSpec struct {
+	// Requester is the Greenhouse ID of the user who requests access. The admission
+	// policy of the chart requires it to be the user who creates the request.
+	Requester string `json:"requester"`
+	// GithubTeam is the name of the GithubTeam in the namespace of the request. Without
+	// repository the requester asks for the membership of the team; with repository the
+	// team is granted the permission on the repositories.
+	GithubTeam string `json:"githubTeam"`
+	// Repository requests a permission of the team on repositories.
+	// +optional
+	Repository *RepositoryAccess `json:"repository,omitempty"`
+	// Justification explains why the access is needed.
+	Justification string `json:"justification"`
+	// Duration of the access after the approval, e.g. 8h.
+	Duration metav1.Duration `json:"duration"`
+}
+
+// RepositoryAccess is a permission on repositories of the organization of the team.
+type RepositoryAccess struct {
+	Repositories []string             `json:"repositories"`
+	Permission   GithubTeamPermission `json:"permission"`
+}
+
+// AccessRequestApprovers are the members of a GithubTeam and Kubernetes users or
+// service accounts.
+type AccessRequestApprovers struct {
+	// GithubTeam is the name of a GithubTeam in the same namespace whose members are
+	// approvers. They approve as the Kubernetes user of their Greenhouse ID.
+	// +optional
+	GithubTeam string `json:"githubTeam,omitempty"`
+	// Subjects are approvers of kind User or ServiceAccount. Groups are not supported.
+	// +optional
+	Subjects []rbacv1.Subject `json:"subjects,omitempty"`
+}
+
+// GithubAccessRequestStatus defines the observed state of GithubAccessRequest
+type GithubAccessRequestStatus struct {
+	// State is pending until the request is approved or denied. An approved request is
+	// active while its grant is in place, and expired afterwards. It is revoked when the
+	// spec changes after the approval, and failed while the spec is invalid.
+	State GithubAccessRequestState `json:"state,omitempty"`
+	// ApprovedBy is the approver who approved the request.
+	ApprovedBy string `json:"approvedBy,omitempty"`
+	// DeniedBy is the approver who denied the request.
+	DeniedBy string `json:"deniedBy,omitempty"`
+	// ApprovedAt is the time the approval was accepted.
+	ApprovedAt *metav1.Time `json:"approvedAt,omitempty"`
+	// ApprovedGeneration is the generation of the approved spec. A change of the spec
+	// after the approval revokes the access.
+	ApprovedGeneration int64 `json:"approvedGeneration,omitempty"`
+	// ExpiresAt is the end of the access.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// Grant is the name of the GithubTeamMembershipGrant or GithubTeamRepository that
+	// provides the access.
+	Grant string `json:"grant,omitempty"`
+	// Error is why the request cannot be approved or granted.
+	Error string `json:"error,omitempty"`
+}
+
+type GithubAccessRequestState string
+
+const (
+	GithubAccessRequestStatePending = "pending"
+	GithubAccessRequestStateActive  = "active"
+	GithubAccessRequestStateExpired = "expired"
+	GithubAccessRequestStateDenied  = "denied"
+	GithubAccessRequestStateRevoked = "revoked"
+	GithubAccessRequestStateFailed  = "failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Requester",type="string",JSONPath=".spec.requester"
+// +kubebuilder:printcolumn:name="Team",type="string",JSONPath=".spec.githubTeam"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Expires At",type="date",JSONPath=".status.expiresAt"
+type GithubAccessRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GithubAccessRequestSpec   `json:"spec,omitempty"`
+	Status GithubAccessRequestStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GithubAccessRequestList contains a list of GithubAccessRequest
+type GithubAccessRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GithubAccessRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(func(scheme *runtime.Scheme) error {
+		scheme.AddKnownTypes(GroupVersion, &GithubAccessRequest{}, &GithubAccessRequestList{})
+		return nil
+	})
+}
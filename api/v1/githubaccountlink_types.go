@@ -12,9 +12,62 @@ type GithubAccountLinkSpec struct {
 	GreenhouseUserID string `json:"userID,omitempty"`
 	GithubUserID     string `json:"githubUserID,omitempty"`
 	Github           string `json:"github,omitempty"`
+	// EmailVerification lists the organizations in which the GitHub user is checked for a
+	// verified email address under a domain. Results are reported in status.emailVerification.
+	// +listType=map
+	// +listMapKey=organization
+	EmailVerification []EmailVerificationSpec `json:"emailVerification,omitempty"`
+}
+
+// EmailVerificationSpec requests a verified-domain email check in one organization.
+type EmailVerificationSpec struct {
+	// Organization is the GitHub organization whose verified domains are checked.
+	// +kubebuilder:validation:MinLength=1
+	Organization string `json:"organization"`
+	// Domain the verified email address must belong to.
+	// +kubebuilder:validation:MinLength=1
+	Domain string `json:"domain"`
+	// TTL after which the check is repeated, as a Go duration. When empty the check runs once.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// EmailVerificationStatus is the result of a verified-domain email check in one organization.
+type EmailVerificationStatus struct {
+	Organization string `json:"organization"`
+	Domain       string `json:"domain"`
+	// +kubebuilder:validation:Enum=verified;not-part-of-org;no;skipped
+	Status      string      `json:"status"`
+	LastChecked metav1.Time `json:"lastChecked,omitempty"`
 }
 
 type GithubAccountLinkStatus struct {
+	// Login is the GitHub login resolved for githubUserID at the last verification.
+	Login string `json:"login,omitempty"`
+	// Exists reports whether githubUserID resolved to a GitHub user at the last verification.
+	Exists bool `json:"exists,omitempty"`
+	// Suspended reports whether the GitHub user is suspended. Only detectable on GitHub Enterprise Server.
+	Suspended bool `json:"suspended,omitempty"`
+	// LastVerified is the time githubUserID was last resolved against GitHub.
+	LastVerified metav1.Time `json:"lastVerified,omitempty"`
+	// ObservedGeneration is the generation of the spec that was last verified.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conflicts lists other links of the same Github that map the same githubUserID or userID.
+	Conflicts []GithubAccountLinkConflict `json:"conflicts,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// EmailVerification holds the results of the checks requested in spec.emailVerification.
+	// +listType=map
+	// +listMapKey=organization
+	EmailVerification []EmailVerificationStatus `json:"emailVerification,omitempty"`
+}
+
+// GithubAccountLinkConflict names another GithubAccountLink that maps the same identity.
+type GithubAccountLinkConflict struct {
+	// Name of the conflicting GithubAccountLink.
+	Name string `json:"name"`
+	// Field shared with the conflicting link, either githubUserID or userID.
+	Field string `json:"field"`
 }
 
 // +kubebuilder:object:root=true
@@ -23,6 +76,8 @@ type GithubAccountLinkStatus struct {
 // +kubebuilder:printcolumn:name="Github",type="string",JSONPath=".spec.github"
 // +kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.userID"
 // +kubebuilder:printcolumn:name="Github ID",type="string",JSONPath=".spec.githubUserID"
+// +kubebuilder:printcolumn:name="Login",type="string",JSONPath=".status.login"
+// +kubebuilder:printcolumn:name="Verified",type="string",JSONPath=".status.conditions[?(@.type==\"Verified\")].status"
 type GithubAccountLink struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -47,7 +102,9 @@ func init() {
 	})
 }
 
-// Email verification nnotations. When set on GithubAccountLink, controller checks whether the linked GitHub user  has a verified email address under the specified domain and stores the result.
+// Deprecated email verification annotations, superseded by spec.emailVerification and
+// status.emailVerification. Links carrying the config annotation are migrated to the typed
+// fields by the controller; a results annotation without config is still honored by GithubTeam.
 // Example:
 //
 //	{
@@ -68,3 +125,24 @@ const GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED = "verified"
 const GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NOT_PART_OF_ORG = "not-part-of-org"
 const GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO = "no"
 const GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_SKIPPED = "skipped"
+
+// GithubAccountLink condition types and reasons.
+const GITHUB_ACCOUNT_LINK_CONDITION_VERIFIED = "Verified"
+const GITHUB_ACCOUNT_LINK_CONDITION_CONFLICT = "Conflict"
+
+const GITHUB_ACCOUNT_LINK_REASON_USER_FOUND = "UserFound"
+const GITHUB_ACCOUNT_LINK_REASON_USER_NOT_FOUND = "UserNotFound"
+const GITHUB_ACCOUNT_LINK_REASON_USER_SUSPENDED = "UserSuspended"
+const GITHUB_ACCOUNT_LINK_REASON_INVALID_GITHUB_USER_ID = "InvalidGithubUserID"
+const GITHUB_ACCOUNT_LINK_REASON_INSTALLATION_NOT_RESOLVED = "InstallationNotResolved"
+const GITHUB_ACCOUNT_LINK_REASON_GITHUB_ERROR = "GithubError"
+const GITHUB_ACCOUNT_LINK_REASON_NO_CONFLICT = "NoConflict"
+const GITHUB_ACCOUNT_LINK_REASON_DUPLICATE_IDENTITY = "DuplicateIdentity"
+
+// Fields reported in GithubAccountLinkConflict.Field.
+const GITHUB_ACCOUNT_LINK_CONFLICT_FIELD_GITHUB_USER_ID = "githubUserID"
+const GITHUB_ACCOUNT_LINK_CONFLICT_FIELD_USER_ID = "userID"
+
+// GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE marks GithubAccountLinks created from SAML external
+// identities. The value is the name of the GithubOrganization whose sync owns the link.
+const GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE = "repo-guard.cloudoperators.dev/saml-source"
@@ -30,6 +30,67 @@ type GithubOrganizationSpec struct {
 	ProtectedMembers []string `json:"protectedMembers,omitempty"`
 
 	InstallationID int64 `json:"installationID,omitempty"`
+
+	// SAMLAccountLinks enables creating GithubAccountLinks from the external
+	// identities of the organization's SAML identity provider.
+	// +optional
+	SAMLAccountLinks *SAMLAccountLinkSync `json:"samlAccountLinks,omitempty"`
+
+	// EnterpriseManagedUsers overrides the EMU configuration of the Github for
+	// this organization.
+	// +optional
+	EnterpriseManagedUsers *EnterpriseManagedUsers `json:"enterpriseManagedUsers,omitempty"`
+
+	// AuditLog enables polling the organization's audit log for access changes
+	// made outside of repo-guard.
+	// +optional
+	AuditLog *AuditLogPolling `json:"auditLog,omitempty"`
+
+	// DeletionPolicy defines what happens when the GithubOrganization is deleted:
+	// Orphan keeps the GithubTeams and GithubTeamRepositories of the organization,
+	// Delete deletes them, so that they clean up Github according to their own
+	// deletion policy. Defaults to Orphan.
+	// +kubebuilder:validation:Enum=Orphan;Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// SAMLAccountLinkSync configures the creation of GithubAccountLinks from the
+// organization's SAML external identities. Links created this way carry the
+// GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE label; links without it are never
+// modified or deleted.
+type SAMLAccountLinkSync struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Interval between two syncs as a Go duration. Defaults to 1h.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// StripNameIDDomain removes everything from the first "@" of the SAML
+	// NameID before it is used as the user ID of the link.
+	// +optional
+	StripNameIDDomain bool `json:"stripNameIDDomain,omitempty"`
+}
+
+// AuditLogPolling configures polling the audit log of the organization. Entries of
+// the watched actions whose actor is not repo-guard itself are reported as
+// out-of-band changes. The audit log API needs GitHub Enterprise Cloud or Server.
+type AuditLogPolling struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Interval between two polls as a Go duration. Defaults to 15m.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// Actions of the audit log to watch. Defaults to team.add_member,
+	// team.add_repository, org.add_member and repo.add_member.
+	// +optional
+	Actions []string `json:"actions,omitempty"`
+
+	// IgnoredActors are logins whose changes are not reported, e.g. other
+	// automation. The GitHub App or token owner of the Github is always ignored.
+	// +optional
+	IgnoredActors []string `json:"ignoredActors,omitempty"`
 }
 
 func GithubRepositoryListEquals(github, kubernetes []GithubRepository) bool {
@@ -123,6 +184,26 @@ func PermissionsMatch(actual, configured GithubTeamPermission) bool {
 	return false
 }
 
+// permissionRanks orders the permissions of a team on a repository by the access they
+// grant. admin-ondemand inherits Read.
+var permissionRanks = map[GithubTeamPermission]int{
+	GithubTeamPermissionPull:          1,
+	GithubTeamPermissionAdminOndemand: 2,
+	"triage":                          3,
+	GithubTeamPermissionPush:          4,
+	"maintain":                        5,
+	GithubTeamPermissionAdmin:         6,
+}
+
+// HigherPermission returns the permission of a and b that grants more access. An
+// empty a yields b.
+func HigherPermission(a, b GithubTeamPermission) GithubTeamPermission {
+	if a == "" || permissionRanks[b] > permissionRanks[a] {
+		return b
+	}
+	return a
+}
+
 // GithubOrganizationStatus defines the observed state of GithubOrganization
 type GithubOrganizationStatus struct {
 	Teams                []string           `json:"teams,omitempty"`
@@ -143,6 +224,101 @@ type GithubOrganizationStatus struct {
 	OrganizationStatusTimestamp metav1.Time             `json:"timestamp,omitempty"`
 
 	Operations GithubOrganizationStatusOperations `json:"operations,omitempty"`
+
+	// SAMLAccountLinks reports the last sync of GithubAccountLinks from SAML
+	// external identities. It is written by a separate reconciler and preserved
+	// by the organization reconciler's status updates.
+	SAMLAccountLinks *SAMLAccountLinkSyncStatus `json:"samlAccountLinks,omitempty"`
+
+	// AuditLog reports the last poll of the audit log and the changes made by
+	// other actors than repo-guard.
+	// +optional
+	AuditLog *AuditLogStatus `json:"auditLog,omitempty"`
+
+	// InstallationID is the installation of the GitHub App on the organization,
+	// discovered by the controller when spec.installationID is empty.
+	InstallationID int64 `json:"installationID,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// GithubOrganization condition types and reasons.
+const GITHUB_ORG_CONDITION_APP_INSTALLED = "AppInstalled"
+
+const GITHUB_ORG_REASON_INSTALLATION_DISCOVERED = "InstallationDiscovered"
+const GITHUB_ORG_REASON_APP_NOT_INSTALLED = "AppNotInstalled"
+const GITHUB_ORG_REASON_DISCOVERY_FAILED = "DiscoveryFailed"
+
+// ResolvedInstallationID returns spec.installationID, or the installation
+// discovered by the controller if it is not set. It is 0 while unknown.
+func (g *GithubOrganization) ResolvedInstallationID() int64 {
+	if g.Spec.InstallationID != 0 {
+		return g.Spec.InstallationID
+	}
+	return g.Status.InstallationID
+}
+
+// GITHUB_ORG_AUDIT_LOG_MAX_CHANGES bounds status.auditLog.outOfBandChanges.
+const GITHUB_ORG_AUDIT_LOG_MAX_CHANGES = 50
+
+// AuditLogStatus summarizes the audit log polls of an organization.
+type AuditLogStatus struct {
+	LastPoll metav1.Time `json:"lastPoll,omitempty"`
+	// LastEntry is the creation time of the newest entry processed. The next poll
+	// reads the entries created after it, with an overlap for entries created in the
+	// same second or indexed late by GitHub.
+	LastEntry metav1.Time `json:"lastEntry,omitempty"`
+	// RecentEntries are the entries reported within the overlap before LastEntry, so
+	// that they are not reported again.
+	// +optional
+	RecentEntries []AuditLogEntryRef `json:"recentEntries,omitempty"`
+	// Truncated is true if the last poll reached the page limit. LastEntry is not
+	// advanced past the last entry read and the next polls read the remaining entries.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+	// Actor is the login repo-guard's own changes are attributed to.
+	Actor string `json:"actor,omitempty"`
+	// OutOfBandChanges are the most recent changes made by other actors, newest
+	// first. At most GITHUB_ORG_AUDIT_LOG_MAX_CHANGES are kept.
+	OutOfBandChanges []OutOfBandChange `json:"outOfBandChanges,omitempty"`
+	Error            string            `json:"error,omitempty"`
+}
+
+// AuditLogEntryRef identifies an audit log entry by its _document_id.
+type AuditLogEntryRef struct {
+	ID        string      `json:"id"`
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// OutOfBandChange is an audit log entry of a change made by another actor than repo-guard.
+type OutOfBandChange struct {
+	Action string `json:"action"`
+	Actor  string `json:"actor"`
+	// +optional
+	User string `json:"user,omitempty"`
+	// +optional
+	Team string `json:"team,omitempty"`
+	// +optional
+	Repository string      `json:"repository,omitempty"`
+	Timestamp  metav1.Time `json:"timestamp"`
+}
+
+// SAMLAccountLinkSyncStatus summarizes the outcome of the last SAML account link sync.
+type SAMLAccountLinkSyncStatus struct {
+	LastSync metav1.Time `json:"lastSync,omitempty"`
+	// Identities is the number of external identities linked to a GitHub user.
+	Identities int `json:"identities,omitempty"`
+	// Links is the number of GithubAccountLinks owned by this organization after the sync.
+	Links   int `json:"links,omitempty"`
+	Created int `json:"created,omitempty"`
+	Updated int `json:"updated,omitempty"`
+	Deleted int `json:"deleted,omitempty"`
+	// Conflicts is the number of identities skipped because a link not owned by
+	// this organization already exists for the user.
+	Conflicts int    `json:"conflicts,omitempty"`
+	Error     string `json:"error,omitempty"`
 }
 
 type GithubOrganizationStatusOperations struct {
@@ -185,6 +361,7 @@ const (
 	GithubOrganizationStateComplete          = "complete"
 	GithubOrganizationStateDryRun            = "dry-run"
 	GithubOrganizationStateRateLimited       = "ratelimited"
+	GithubOrganizationStateDeleting          = "deleting"
 )
 
 type GithubRepoTeamOperation struct {
@@ -277,8 +454,8 @@ func (g GithubOrganization) OwnerChangeCalculator(ownersFromKubernetes []Member)
 
 		githubOwnerFound := false
 		for _, githubOwner := range g.Status.OrganizationOwners {
-			// Compare by GithubUsername to avoid mismatches between different GreenhouseID mappings
-			if strings.EqualFold(githubOwner.GithubUsername, kubernetesOwner.GithubUsername) {
+			// Compare by GitHub account to avoid mismatches between different GreenhouseID mappings
+			if githubOwner.SameGithubUser(kubernetesOwner) {
 				githubOwnerFound = true
 				break
 			}
@@ -316,8 +493,8 @@ func (g GithubOrganization) OwnerChangeCalculator(ownersFromKubernetes []Member)
 
 		kubernetesOwnerFound := false
 		for _, kubernetesOwner := range ownersFromKubernetes {
-			// Compare by GithubUsername to avoid mismatches between different GreenhouseID mappings
-			if strings.EqualFold(kubernetesOwner.GithubUsername, githubOwner.GithubUsername) {
+			// Compare by GitHub account to avoid mismatches between different GreenhouseID mappings
+			if kubernetesOwner.SameGithubUser(githubOwner) {
 				kubernetesOwnerFound = true
 				break
 			}
@@ -361,7 +538,11 @@ func (g GithubOrganization) OwnerChangeCalculator(ownersFromKubernetes []Member)
 
 }
 
-func (g GithubOrganization) TeamChangeCalculator(teamsFromKubernetes []string) (bool, *GithubOrganizationStatus) {
+// TeamChangeCalculator queues the addition of teamsFromKubernetes missing in Github and
+// the removal of teams in Github missing in teamsFromKubernetes. renames maps the
+// lower-cased slugs of teams in Github to the names they are renamed to by their
+// GithubTeam; neither name of a renamed team is added or removed.
+func (g GithubOrganization) TeamChangeCalculator(teamsFromKubernetes []string, renames map[string]string) (bool, *GithubOrganizationStatus) {
 
 	newStatus := g.Status.DeepCopy()
 	changed := false
@@ -370,7 +551,13 @@ func (g GithubOrganization) TeamChangeCalculator(teamsFromKubernetes []string) (
 
 		kubernetesTeamFound := false
 		for _, k := range g.Status.Teams {
-			if strings.EqualFold(k, kubernetesTeam) {
+			if SameTeam(k, kubernetesTeam) {
+				kubernetesTeamFound = true
+				break
+			}
+		}
+		for _, renamed := range renames {
+			if SameTeam(renamed, kubernetesTeam) {
 				kubernetesTeamFound = true
 				break
 			}
@@ -406,9 +593,16 @@ func (g GithubOrganization) TeamChangeCalculator(teamsFromKubernetes []string) (
 
 	for _, githubTeam := range g.Status.Teams {
 
+		// Status.Teams holds the names of the teams in Github, renames their slugs
 		kubernetesTeamFound := false
+		for renamedSlug := range renames {
+			if SameTeam(renamedSlug, githubTeam) {
+				kubernetesTeamFound = true
+				break
+			}
+		}
 		for _, kubernetesTeam := range teamsFromKubernetes {
-			if strings.EqualFold(kubernetesTeam, githubTeam) {
+			if SameTeam(kubernetesTeam, githubTeam) {
 				kubernetesTeamFound = true
 				break
 			}
@@ -4,6 +4,7 @@
 package v1
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -651,3 +652,54 @@ func TestRepositoryDirectCollaboratorChangeCalculator(t *testing.T) {
 		})
 	}
 }
+
+func TestOwnerChangeCalculator_Rename(t *testing.T) {
+	org := GithubOrganization{}
+	org.Status.OrganizationOwners = []Member{{GithubUsername: "alice-new", GithubUID: 42}, {GithubUsername: "bob", GithubUID: 43}}
+
+	changed, newStatus := org.OwnerChangeCalculator([]Member{{GithubUsername: "alice", GithubUID: 42}})
+	if !changed {
+		t.Fatal("expected bob to be removed")
+	}
+	ops := newStatus.Operations.OrganizationOwnerOperations
+	if len(ops) != 1 || ops[0].User != "bob" || ops[0].Operation != GithubUserOperationTypeRemove {
+		t.Errorf("ops = %+v, want only a remove op for bob", ops)
+	}
+}
+
+func TestTeamChangeCalculator_Rename(t *testing.T) {
+	org := GithubOrganization{}
+	// Github lists the names of teams, renames are keyed by their slugs
+	org.Status.Teams = []string{"old-squad", "Platform Admins", "stale"}
+
+	changed, newStatus := org.TeamChangeCalculator(
+		[]string{"new-squad", "platform", "Platform Operators"},
+		map[string]string{"old-squad": "new-squad", "platform-admins": "Platform Operators"},
+	)
+	if !changed {
+		t.Fatal("expected platform to be added and stale to be removed")
+	}
+	var got []string
+	for _, op := range newStatus.Operations.GithubTeamOperations {
+		got = append(got, string(op.Operation)+"/"+op.Team)
+	}
+	if want := "add/platform,remove/stale"; strings.Join(got, ",") != want {
+		t.Errorf("ops = %v, want %s", got, want)
+	}
+}
+
+func TestHigherPermission(t *testing.T) {
+	for _, tc := range []struct {
+		a, b, want GithubTeamPermission
+	}{
+		{a: "", b: GithubTeamPermissionPull, want: GithubTeamPermissionPull},
+		{a: GithubTeamPermissionPull, b: GithubTeamPermissionAdminOndemand, want: GithubTeamPermissionAdminOndemand},
+		{a: "maintain", b: GithubTeamPermissionPush, want: "maintain"},
+		{a: GithubTeamPermissionPush, b: GithubTeamPermissionAdmin, want: GithubTeamPermissionAdmin},
+		{a: "triage", b: GithubTeamPermissionPull, want: "triage"},
+	} {
+		if got := HigherPermission(tc.a, tc.b); got != tc.want {
+			t.Errorf("HigherPermission(%q, %q) = %q, want %q", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package v1
 import (
 	"strings"
 
+	"github.com/gosimple/slug"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -17,6 +18,100 @@ type GithubTeamSpec struct {
 	Team                   string                        `json:"team,omitempty"`
 	GreenhouseTeam         string                        `json:"greenhouseTeam,omitempty"`
 	ExternalMemberProvider *ExternalMemberProviderConfig `json:"externalMemberProvider,omitempty"`
+
+	// ParentTeam is the name of the team in the same organization this team is nested
+	// in. Child teams inherit the repository access of their parent. Empty keeps the
+	// team at the top level.
+	// +optional
+	ParentTeam string `json:"parentTeam,omitempty"`
+
+	// DisplayName is the name of the team shown in Github. Team stays the identifier
+	// of the team, so the slug of DisplayName must be the slug of Team, e.g.
+	// "Platform Squad" for "platform-squad". Defaults to Team.
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Description of the team. Unset descriptions are not managed; new teams get a
+	// default description.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Privacy of the team. Unset privacy is not managed; new teams are closed.
+	// Secret teams cannot have a parent team.
+	// +kubebuilder:validation:Enum=closed;secret
+	// +optional
+	Privacy string `json:"privacy,omitempty"`
+
+	// NotificationSetting of the team. Unset settings are not managed.
+	// +kubebuilder:validation:Enum=notifications_enabled;notifications_disabled
+	// +optional
+	NotificationSetting string `json:"notificationSetting,omitempty"`
+
+	// Maintainers selects the members with the maintainer role, who can manage the
+	// team in Github. Maintainers are members of the team as well. Roles are not
+	// managed when unset.
+	// +optional
+	Maintainers *TeamMaintainers `json:"maintainers,omitempty"`
+
+	// IdPGroup links the team with a group of the identity provider, which then owns
+	// the members of the team. Members are not managed while it is set, so it cannot
+	// be combined with greenhouseTeam, externalMemberProvider or maintainers.
+	// +optional
+	IdPGroup *TeamIdPGroup `json:"idpGroup,omitempty"`
+
+	// AccessRequestApprovers approve or deny the GithubAccessRequests for the team.
+	// They are kept out of the requests so that a requester cannot choose them.
+	// Access to the team cannot be requested without approvers.
+	// +optional
+	AccessRequestApprovers *AccessRequestApprovers `json:"accessRequestApprovers,omitempty"`
+
+	// DeletionPolicy defines what happens to the team in Github when the GithubTeam
+	// is deleted: Orphan keeps it, Delete deletes it and RemoveMembersOnly removes
+	// its members. Defaults to Orphan.
+	// +kubebuilder:validation:Enum=Orphan;Delete;RemoveMembersOnly
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// TeamMaintainers are the members of Group and Users.
+type TeamMaintainers struct {
+	// Group is a group of the member provider of the team, or the name of a
+	// Greenhouse Team with greenhouseTeam.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Users are Greenhouse IDs or Github logins.
+	// +optional
+	Users []string `json:"users,omitempty"`
+}
+
+// TeamIdPGroup is a group of the identity provider linked with a team.
+type TeamIdPGroup struct {
+	// Type is externalGroup for the external groups of Enterprise Managed Users, or
+	// teamSync for the groups of Team Synchronization.
+	// +kubebuilder:validation:Enum=externalGroup;teamSync
+	Type string `json:"type"`
+	// Name of the group as shown in Github.
+	Name string `json:"name"`
+}
+
+// SameTeam reports whether a and b name the same Github team. Teams are addressed by
+// their slug, so names that only differ in case or punctuation match.
+func SameTeam(a, b string) bool {
+	return strings.EqualFold(a, b) || slug.Make(a) == slug.Make(b)
+}
+
+// PendingRename returns the slug of the team in Github that is renamed to spec.team,
+// or "" if the team is not renamed.
+func (g GithubTeam) PendingRename() string {
+	if g.Status.TeamID == 0 || g.Status.Slug == "" || SameTeam(g.Status.Slug, g.Spec.Team) {
+		return ""
+	}
+	return g.Status.Slug
+}
+
+// ManagesSettings reports whether any setting of the team besides its parent is set.
+func (s GithubTeamSpec) ManagesSettings() bool {
+	return s.DisplayName != "" || s.Description != "" || s.Privacy != "" || s.NotificationSetting != ""
 }
 
 type ExternalMemberProviderConfig struct {
@@ -40,6 +135,29 @@ type LDAPGroup struct {
 type Member struct {
 	GreenhouseID   string `json:"id,omitempty"`
 	GithubUsername string `json:"githubUsername,omitempty"`
+	// GithubUID is the numeric GitHub user ID. Unlike the login it survives
+	// account renames.
+	GithubUID int64 `json:"githubUID,omitempty"`
+	// Role of the member in the team, maintainer or member. It is only recorded for
+	// teams with spec.maintainers.
+	Role string `json:"role,omitempty"`
+}
+
+// TeamRole returns the role of the member, defaulting to member.
+func (a Member) TeamRole() string {
+	if a.Role == "" {
+		return GithubTeamRoleMember
+	}
+	return a.Role
+}
+
+// SameGithubUser reports whether a and b are the same GitHub account: either
+// both carry the same GitHub UID or their logins match case-insensitively.
+func (a Member) SameGithubUser(b Member) bool {
+	if a.GithubUID != 0 && a.GithubUID == b.GithubUID {
+		return true
+	}
+	return strings.EqualFold(a.GithubUsername, b.GithubUsername)
 }
 
 // GithubTeamStatus defines the observed state of GithubTeam
@@ -50,6 +168,64 @@ type GithubTeamStatus struct {
 	Operations          []GithubUserOperation `json:"operations,omitempty"`
 
 	Members []Member `json:"members,omitempty"`
+
+	// NotFoundRecheck tracks the backoff schedule used to re-resolve users
+	// whose add operation ended in the notfound state.
+	NotFoundRecheck *NotFoundRecheckStatus `json:"notFoundRecheck,omitempty"`
+
+	// ParentTeam is the parent of the team observed in Github.
+	ParentTeam string `json:"parentTeam,omitempty"`
+
+	// TeamID is the ID of the team in Github. It identifies the team across renames.
+	TeamID int64 `json:"teamID,omitempty"`
+	// Slug is the slug of the team observed in Github. It differs from the slug of
+	// spec.team while the team is renamed.
+	Slug string `json:"slug,omitempty"`
+	// PreviousSlug is the slug of the team before it was last renamed.
+	PreviousSlug string `json:"previousSlug,omitempty"`
+
+	// SettingsDrift lists the settings of the team in Github that differ from the
+	// spec and were not corrected because updating team settings is disabled.
+	SettingsDrift []string `json:"settingsDrift,omitempty"`
+
+	// IdPGroup reports the link of the team with the group of spec.idpGroup.
+	IdPGroup *IdPGroupStatus `json:"idpGroup,omitempty"`
+
+	// MembershipGrants lists the active GithubTeamMembershipGrants merged into the
+	// members of the team.
+	MembershipGrants []MembershipGrantStatus `json:"membershipGrants,omitempty"`
+}
+
+// MembershipGrantStatus is an active GithubTeamMembershipGrant of a team.
+type MembershipGrantStatus struct {
+	// Name of the GithubTeamMembershipGrant.
+	Name      string      `json:"name"`
+	User      string      `json:"user"`
+	ExpiresAt metav1.Time `json:"expiresAt"`
+}
+
+// IdPGroupStatus is the link of a team with a group of the identity provider
+// observed in Github.
+type IdPGroupStatus struct {
+	Type string `json:"type,omitempty"`
+	Name string `json:"name,omitempty"`
+	// ID of the group in Github.
+	ID string `json:"id,omitempty"`
+	// Linked reports whether the team is linked with the group.
+	Linked bool `json:"linked,omitempty"`
+	// LastSync is the last sync of the members of an external group.
+	LastSync *metav1.Time `json:"lastSync,omitempty"`
+	// Error is why the team is not linked with the group.
+	Error string `json:"error,omitempty"`
+}
+
+// NotFoundRecheckStatus records when users in the notfound state were last
+// re-resolved against GitHub and when the next attempt is due. The schedule is
+// cleared once no users remain in the notfound state.
+type NotFoundRecheckStatus struct {
+	Attempts  int         `json:"attempts,omitempty"`
+	LastCheck metav1.Time `json:"lastCheck,omitempty"`
+	NextCheck metav1.Time `json:"nextCheck,omitempty"`
 }
 
 type GithubTeamState string
@@ -60,6 +236,7 @@ const (
 	GithubTeamStateComplete          = "complete"
 	GithubTeamStateDryRun            = "dry-run"
 	GithubTeamStateRateLimited       = "ratelimited"
+	GithubTeamStateDeleting          = "deleting"
 )
 
 type GithubUserOperation struct {
@@ -68,6 +245,8 @@ type GithubUserOperation struct {
 	State     GithubUserOperationState `json:"state,omitempty"`
 	Error     string                   `json:"error,omitempty"`
 	Timestamp metav1.Time              `json:"timestamp,omitempty"`
+	// Role the user is added with or changed to.
+	Role string `json:"role,omitempty"`
 }
 
 type GithubUserOperationType string
@@ -75,6 +254,13 @@ type GithubUserOperationType string
 const (
 	GithubUserOperationTypeAdd    GithubUserOperationType = "add"
 	GithubUserOperationTypeRemove GithubUserOperationType = "remove"
+	// GithubUserOperationTypeRole changes the role of a member of the team.
+	GithubUserOperationTypeRole GithubUserOperationType = "role"
+)
+
+const (
+	GithubTeamRoleMaintainer = "maintainer"
+	GithubTeamRoleMember     = "member"
 )
 
 type GithubUserOperationState string
@@ -133,10 +319,15 @@ func (github GithubTeam) ChangeCalculator(desiredMembers []Member) (bool, *Githu
 	newStatus := github.Status.DeepCopy()
 	changed := false
 
-	// Build a map of current members for quick lookup
+	// Build a map of current members for quick lookup. Members are also matched by
+	// GitHub UID so that a renamed login is not treated as a remove and an add.
 	currentMembersMap := make(map[string]Member)
+	currentMembersByUID := make(map[int64]Member)
 	for _, m := range github.Status.Members {
 		currentMembersMap[strings.ToLower(m.GithubUsername)] = m
+		if m.GithubUID != 0 {
+			currentMembersByUID[m.GithubUID] = m
+		}
 	}
 
 	// Build a map of users who are in NotFound state
@@ -150,7 +341,23 @@ func (github GithubTeam) ChangeCalculator(desiredMembers []Member) (bool, *Githu
 			continue
 		}
 
-		if _, exists := currentMembersMap[lowerGithubUsername]; !exists {
+		currentMember, exists := currentMembersMap[lowerGithubUsername]
+		if !exists && desiredMember.GithubUID != 0 {
+			currentMember, exists = currentMembersByUID[desiredMember.GithubUID]
+		}
+		// Members without a desired role keep their role
+		if exists && desiredMember.Role != "" && currentMember.TeamRole() != desiredMember.Role &&
+			!roleOperationExists(newStatus.Operations, currentMember.GithubUsername, desiredMember.Role) {
+			newStatus.Operations = append(newStatus.Operations, GithubUserOperation{
+				Operation: GithubUserOperationTypeRole,
+				User:      currentMember.GithubUsername,
+				Role:      desiredMember.Role,
+				State:     GithubUserOperationStatePending,
+				Timestamp: metav1.Now(),
+			})
+			changed = true
+		}
+		if !exists {
 			// Check if there's already a pending or completed add operation
 			operationExists := false
 			for _, op := range newStatus.Operations {
@@ -168,6 +375,7 @@ func (github GithubTeam) ChangeCalculator(desiredMembers []Member) (bool, *Githu
 				op := GithubUserOperation{
 					Operation: GithubUserOperationTypeAdd,
 					User:      desiredMember.GithubUsername,
+					Role:      desiredMember.Role,
 					State:     GithubUserOperationStatePending,
 					Timestamp: metav1.Now(),
 				}
@@ -179,13 +387,21 @@ func (github GithubTeam) ChangeCalculator(desiredMembers []Member) (bool, *Githu
 
 	// Process current members to identify removals
 	desiredMembersMap := make(map[string]Member)
+	desiredMembersByUID := make(map[int64]Member)
 	for _, m := range desiredMembers {
 		desiredMembersMap[strings.ToLower(m.GithubUsername)] = m
+		if m.GithubUID != 0 {
+			desiredMembersByUID[m.GithubUID] = m
+		}
 	}
 
 	for _, currentMember := range github.Status.Members {
 		lowerGithubUsername := strings.ToLower(currentMember.GithubUsername)
-		if _, exists := desiredMembersMap[lowerGithubUsername]; !exists {
+		_, exists := desiredMembersMap[lowerGithubUsername]
+		if !exists && currentMember.GithubUID != 0 {
+			_, exists = desiredMembersByUID[currentMember.GithubUID]
+		}
+		if !exists {
 			// Check if there's already a pending or completed remove operation
 			operationExists := false
 			for _, op := range newStatus.Operations {
@@ -220,6 +436,21 @@ func (github GithubTeam) ChangeCalculator(desiredMembers []Member) (bool, *Githu
 	return changed, newStatus
 }
 
+// roleOperationExists reports whether the latest role operation of user changes its
+// role to role and did not fail.
+func roleOperationExists(operations []GithubUserOperation, user, role string) bool {
+	var latest *GithubUserOperation
+	for i, op := range operations {
+		if op.Operation == GithubUserOperationTypeRole && strings.EqualFold(op.User, user) {
+			latest = &operations[i]
+		}
+	}
+	return latest != nil && latest.Role == role &&
+		(latest.State == GithubUserOperationStatePending ||
+			latest.State == GithubUserOperationStateComplete ||
+			latest.State == GithubUserOperationStateSkipped)
+}
+
 func (g GithubTeam) PendingOperationsFound() bool {
 
 	if g.Status.Operations != nil {
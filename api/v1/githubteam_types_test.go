@@ -4,6 +4,7 @@
 package v1
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -88,6 +89,20 @@ func TestGithubTeamChangeCalculator(t *testing.T) {
 			wantOpsLen:   2, // grace's notfound op + henry's new pending op
 			wantAddUsers: []string{"henry"},
 		},
+		{
+			name:            "renamed login with same UID — no add or remove op",
+			existingMembers: []Member{{GithubUsername: "ivan-new", GithubUID: 42}},
+			desiredMembers:  []Member{{GithubUsername: "ivan", GithubUID: 42}},
+			wantChanged:     false,
+			wantOpsLen:      0,
+		},
+		{
+			name:            "same login with different UIDs — still matched by login",
+			existingMembers: []Member{{GithubUsername: "judy", GithubUID: 1}},
+			desiredMembers:  []Member{{GithubUsername: "judy"}},
+			wantChanged:     false,
+			wantOpsLen:      0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -119,3 +134,117 @@ func TestGithubTeamChangeCalculator(t *testing.T) {
 		})
 	}
 }
+
+func TestGithubTeamChangeCalculator_Roles(t *testing.T) {
+	tests := []struct {
+		name            string
+		existingMembers []Member
+		existingOps     []GithubUserOperation
+		desiredMembers  []Member
+		wantRoleOps     []string
+	}{
+		{
+			name:            "member becomes maintainer — role op queued",
+			existingMembers: []Member{{GithubUsername: "alice", Role: GithubTeamRoleMember}},
+			desiredMembers:  []Member{{GithubUsername: "alice", Role: GithubTeamRoleMaintainer}},
+			wantRoleOps:     []string{"alice/maintainer"},
+		},
+		{
+			name:            "maintainer becomes member — role op queued",
+			existingMembers: []Member{{GithubUsername: "Alice", Role: GithubTeamRoleMaintainer}},
+			desiredMembers:  []Member{{GithubUsername: "alice", Role: GithubTeamRoleMember}},
+			wantRoleOps:     []string{"Alice/member"},
+		},
+		{
+			name:            "unrecorded role is member",
+			existingMembers: []Member{{GithubUsername: "alice"}},
+			desiredMembers:  []Member{{GithubUsername: "alice", Role: GithubTeamRoleMember}},
+		},
+		{
+			name:            "roles not managed — no role op",
+			existingMembers: []Member{{GithubUsername: "alice", Role: GithubTeamRoleMaintainer}},
+			desiredMembers:  []Member{{GithubUsername: "alice"}},
+		},
+		{
+			name:            "completed role op — no duplicate",
+			existingMembers: []Member{{GithubUsername: "alice", Role: GithubTeamRoleMember}},
+			existingOps: []GithubUserOperation{
+				{User: "alice", Operation: GithubUserOperationTypeRole, Role: GithubTeamRoleMaintainer, State: GithubUserOperationStateComplete},
+			},
+			desiredMembers: []Member{{GithubUsername: "alice", Role: GithubTeamRoleMaintainer}},
+			wantRoleOps:    []string{"alice/maintainer"},
+		},
+		{
+			name:            "later role op to another role — role op queued again",
+			existingMembers: []Member{{GithubUsername: "alice", Role: GithubTeamRoleMember}},
+			existingOps: []GithubUserOperation{
+				{User: "alice", Operation: GithubUserOperationTypeRole, Role: GithubTeamRoleMaintainer, State: GithubUserOperationStateComplete},
+				{User: "alice", Operation: GithubUserOperationTypeRole, Role: GithubTeamRoleMember, State: GithubUserOperationStateComplete},
+			},
+			desiredMembers: []Member{{GithubUsername: "alice", Role: GithubTeamRoleMaintainer}},
+			wantRoleOps:    []string{"alice/maintainer", "alice/member", "alice/maintainer"},
+		},
+		{
+			name:            "failed role op — retried",
+			existingMembers: []Member{{GithubUsername: "alice", Role: GithubTeamRoleMember}},
+			existingOps: []GithubUserOperation{
+				{User: "alice", Operation: GithubUserOperationTypeRole, Role: GithubTeamRoleMaintainer, State: GithubUserOperationStateFailed},
+			},
+			desiredMembers: []Member{{GithubUsername: "alice", Role: GithubTeamRoleMaintainer}},
+			wantRoleOps:    []string{"alice/maintainer", "alice/maintainer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := GithubTeam{}
+			team.Status.Members = tt.existingMembers
+			team.Status.Operations = tt.existingOps
+
+			_, newStatus := team.ChangeCalculator(tt.desiredMembers)
+
+			var got []string
+			for _, op := range newStatus.Operations {
+				if op.Operation == GithubUserOperationTypeRole {
+					got = append(got, op.User+"/"+op.Role)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantRoleOps, ",") {
+				t.Errorf("role ops = %v, want %v", got, tt.wantRoleOps)
+			}
+		})
+	}
+}
+
+func TestGithubTeamChangeCalculator_AddWithRole(t *testing.T) {
+	team := GithubTeam{}
+	_, newStatus := team.ChangeCalculator([]Member{{GithubUsername: "alice", Role: GithubTeamRoleMaintainer}})
+	if len(newStatus.Operations) != 1 {
+		t.Fatalf("ops = %v, want one add op", newStatus.Operations)
+	}
+	if op := newStatus.Operations[0]; op.Operation != GithubUserOperationTypeAdd || op.Role != GithubTeamRoleMaintainer {
+		t.Errorf("op = %+v, want add with role maintainer", op)
+	}
+}
+
+func TestGithubTeamPendingRename(t *testing.T) {
+	tests := []struct {
+		name   string
+		team   string
+		status GithubTeamStatus
+		want   string
+	}{
+		{name: "not observed yet", team: "new-squad", status: GithubTeamStatus{}, want: ""},
+		{name: "same team", team: "Platform Squad", status: GithubTeamStatus{TeamID: 1, Slug: "platform-squad"}, want: ""},
+		{name: "renamed", team: "new-squad", status: GithubTeamStatus{TeamID: 1, Slug: "old-squad"}, want: "old-squad"},
+		{name: "slug without ID", team: "new-squad", status: GithubTeamStatus{Slug: "old-squad"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := GithubTeam{Spec: GithubTeamSpec{Team: tt.team}, Status: tt.status}
+			if got := team.PendingRename(); got != tt.want {
+				t.Errorf("PendingRename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
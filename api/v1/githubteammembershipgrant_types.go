@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// GithubTeamMembershipGrantSpec grants a user the membership of a GithubTeam until it expires
+type GithubTeamMembershipGrantSpec struct {
+	// GithubTeam is the name of the GithubTeam in the namespace of the grant.
+	GithubTeam string `json:"githubTeam"`
+	// User is the Greenhouse ID or the Github login of the member. It is resolved like
+	// the members of the member provider of the team.
+	User string `json:"user"`
+	// ExpiresAt is the time the user is removed from the team again.
+	ExpiresAt metav1.Time `json:"expiresAt"`
+	// Reason records why the membership is granted.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// GithubTeamMembershipGrantStatus defines the observed state of GithubTeamMembershipGrant
+type GithubTeamMembershipGrantStatus struct {
+	// State is active while the grant is valid and expired afterwards.
+	State string `json:"state,omitempty"`
+	// Error is why the grant cannot be applied to the team.
+	Error string `json:"error,omitempty"`
+}
+
+const (
+	GithubTeamMembershipGrantStateActive  = "active"
+	GithubTeamMembershipGrantStateExpired = "expired"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Team",type="string",JSONPath=".spec.githubTeam"
+// +kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.user"
+// +kubebuilder:printcolumn:name="Expires At",type="date",JSONPath=".spec.expiresAt"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+type GithubTeamMembershipGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GithubTeamMembershipGrantSpec   `json:"spec,omitempty"`
+	Status GithubTeamMembershipGrantStatus `json:"status,omitempty"`
+}
+
+// Active reports whether the grant is valid at now.
+func (g *GithubTeamMembershipGrant) Active(now metav1.Time) bool {
+	return now.Before(&g.Spec.ExpiresAt)
+}
+
+//+kubebuilder:object:root=true
+
+// GithubTeamMembershipGrantList contains a list of GithubTeamMembershipGrant
+type GithubTeamMembershipGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GithubTeamMembershipGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(func(scheme *runtime.Scheme) error {
+		scheme.AddKnownTypes(GroupVersion, &GithubTeamMembershipGrant{}, &GithubTeamMembershipGrantList{})
+		return nil
+	})
+}
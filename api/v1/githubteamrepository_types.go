@@ -15,12 +15,25 @@ type GithubTeamRepositorySpec struct {
 	Team         string               `json:"team,omitempty"`
 	Repository   []string             `json:"repository,omitempty"`
 	Permission   GithubTeamPermission `json:"permission,omitempty"`
+
+	// DeletionPolicy defines what happens when the GithubTeamRepository is deleted:
+	// Orphan keeps the access of the team to the repositories, Delete removes it.
+	// Defaults to Orphan.
+	// +kubebuilder:validation:Enum=Orphan;Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
 // GithubTeamRepositoryStatus defines the observed state of GithubTeamRepository
 type GithubTeamRepositoryStatus struct {
+	// State is deleting while the access of the team is removed from Github.
+	State string `json:"state,omitempty"`
+	// Error is the last error of the cleanup in Github.
+	Error string `json:"error,omitempty"`
 }
 
+const GithubTeamRepositoryStateDeleting = "deleting"
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Github",type="string",JSONPath=".spec.github"
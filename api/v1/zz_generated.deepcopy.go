@@ -5,9 +5,118 @@
 package v1
 
 import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequestApprovers) DeepCopyInto(out *AccessRequestApprovers) {
+	*out = *in
+	if in.Subjects != nil {
+		in, out := &in.Subjects, &out.Subjects
+		*out = make([]rbacv1.Subject, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequestApprovers.
+func (in *AccessRequestApprovers) DeepCopy() *AccessRequestApprovers {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequestApprovers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogEntryRef) DeepCopyInto(out *AuditLogEntryRef) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogEntryRef.
+func (in *AuditLogEntryRef) DeepCopy() *AuditLogEntryRef {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogEntryRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogPolling) DeepCopyInto(out *AuditLogPolling) {
+	*out = *in
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IgnoredActors != nil {
+		in, out := &in.IgnoredActors, &out.IgnoredActors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogPolling.
+func (in *AuditLogPolling) DeepCopy() *AuditLogPolling {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogPolling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogStatus) DeepCopyInto(out *AuditLogStatus) {
+	*out = *in
+	in.LastPoll.DeepCopyInto(&out.LastPoll)
+	in.LastEntry.DeepCopyInto(&out.LastEntry)
+	if in.RecentEntries != nil {
+		in, out := &in.RecentEntries, &out.RecentEntries
+		*out = make([]AuditLogEntryRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OutOfBandChanges != nil {
+		in, out := &in.OutOfBandChanges, &out.OutOfBandChanges
+		*out = make([]OutOfBandChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogStatus.
+func (in *AuditLogStatus) DeepCopy() *AuditLogStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundleReference) DeepCopyInto(out *CABundleReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundleReference.
+func (in *CABundleReference) DeepCopy() *CABundleReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundleReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterGenericExternalMemberProvider) DeepCopyInto(out *ClusterGenericExternalMemberProvider) {
 	*out = *in
@@ -185,6 +294,52 @@ func (in *ClusterStaticMemberProviderList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmailVerificationSpec) DeepCopyInto(out *EmailVerificationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmailVerificationSpec.
+func (in *EmailVerificationSpec) DeepCopy() *EmailVerificationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EmailVerificationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmailVerificationStatus) DeepCopyInto(out *EmailVerificationStatus) {
+	*out = *in
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmailVerificationStatus.
+func (in *EmailVerificationStatus) DeepCopy() *EmailVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EmailVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnterpriseManagedUsers) DeepCopyInto(out *EnterpriseManagedUsers) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnterpriseManagedUsers.
+func (in *EnterpriseManagedUsers) DeepCopy() *EnterpriseManagedUsers {
+	if in == nil {
+		return nil
+	}
+	out := new(EnterpriseManagedUsers)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExternalMemberProviderConfig) DeepCopyInto(out *ExternalMemberProviderConfig) {
 	*out = *in
@@ -330,7 +485,7 @@ func (in *Github) DeepCopyInto(out *Github) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -352,13 +507,116 @@ func (in *Github) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubAccessRequest) DeepCopyInto(out *GithubAccessRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubAccessRequest.
+func (in *GithubAccessRequest) DeepCopy() *GithubAccessRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubAccessRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GithubAccessRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubAccessRequestList) DeepCopyInto(out *GithubAccessRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GithubAccessRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubAccessRequestList.
+func (in *GithubAccessRequestList) DeepCopy() *GithubAccessRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubAccessRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GithubAccessRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubAccessRequestSpec) DeepCopyInto(out *GithubAccessRequestSpec) {
+	*out = *in
+	if in.Repository != nil {
+		in, out := &in.Repository, &out.Repository
+		*out = new(RepositoryAccess)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubAccessRequestSpec.
+func (in *GithubAccessRequestSpec) DeepCopy() *GithubAccessRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubAccessRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubAccessRequestStatus) DeepCopyInto(out *GithubAccessRequestStatus) {
+	*out = *in
+	if in.ApprovedAt != nil {
+		in, out := &in.ApprovedAt, &out.ApprovedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubAccessRequestStatus.
+func (in *GithubAccessRequestStatus) DeepCopy() *GithubAccessRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubAccessRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubAccountLink) DeepCopyInto(out *GithubAccountLink) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubAccountLink.
@@ -379,6 +637,21 @@ func (in *GithubAccountLink) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubAccountLinkConflict) DeepCopyInto(out *GithubAccountLinkConflict) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubAccountLinkConflict.
+func (in *GithubAccountLinkConflict) DeepCopy() *GithubAccountLinkConflict {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubAccountLinkConflict)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubAccountLinkList) DeepCopyInto(out *GithubAccountLinkList) {
 	*out = *in
@@ -414,6 +687,11 @@ func (in *GithubAccountLinkList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubAccountLinkSpec) DeepCopyInto(out *GithubAccountLinkSpec) {
 	*out = *in
+	if in.EmailVerification != nil {
+		in, out := &in.EmailVerification, &out.EmailVerification
+		*out = make([]EmailVerificationSpec, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubAccountLinkSpec.
@@ -429,6 +707,26 @@ func (in *GithubAccountLinkSpec) DeepCopy() *GithubAccountLinkSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubAccountLinkStatus) DeepCopyInto(out *GithubAccountLinkStatus) {
 	*out = *in
+	in.LastVerified.DeepCopyInto(&out.LastVerified)
+	if in.Conflicts != nil {
+		in, out := &in.Conflicts, &out.Conflicts
+		*out = make([]GithubAccountLinkConflict, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EmailVerification != nil {
+		in, out := &in.EmailVerification, &out.EmailVerification
+		*out = make([]EmailVerificationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubAccountLinkStatus.
@@ -560,6 +858,21 @@ func (in *GithubOrganizationSpec) DeepCopyInto(out *GithubOrganizationSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SAMLAccountLinks != nil {
+		in, out := &in.SAMLAccountLinks, &out.SAMLAccountLinks
+		*out = new(SAMLAccountLinkSync)
+		**out = **in
+	}
+	if in.EnterpriseManagedUsers != nil {
+		in, out := &in.EnterpriseManagedUsers, &out.EnterpriseManagedUsers
+		*out = new(EnterpriseManagedUsers)
+		**out = **in
+	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(AuditLogPolling)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubOrganizationSpec.
@@ -613,6 +926,23 @@ func (in *GithubOrganizationStatus) DeepCopyInto(out *GithubOrganizationStatus)
 	}
 	in.OrganizationStatusTimestamp.DeepCopyInto(&out.OrganizationStatusTimestamp)
 	in.Operations.DeepCopyInto(&out.Operations)
+	if in.SAMLAccountLinks != nil {
+		in, out := &in.SAMLAccountLinks, &out.SAMLAccountLinks
+		*out = new(SAMLAccountLinkSyncStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(AuditLogStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubOrganizationStatus.
@@ -675,6 +1005,49 @@ func (in *GithubOrganizationStatusOperations) DeepCopy() *GithubOrganizationStat
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubOrganizationTemplate) DeepCopyInto(out *GithubOrganizationTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubOrganizationTemplate.
+func (in *GithubOrganizationTemplate) DeepCopy() *GithubOrganizationTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubOrganizationTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubProxy) DeepCopyInto(out *GithubProxy) {
+	*out = *in
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubProxy.
+func (in *GithubProxy) DeepCopy() *GithubProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubRepoTeamOperation) DeepCopyInto(out *GithubRepoTeamOperation) {
 	*out = *in
@@ -730,6 +1103,26 @@ func (in *GithubRepository) DeepCopy() *GithubRepository {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubSpec) DeepCopyInto(out *GithubSpec) {
 	*out = *in
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(GithubProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(CABundleReference)
+		**out = **in
+	}
+	if in.EnterpriseManagedUsers != nil {
+		in, out := &in.EnterpriseManagedUsers, &out.EnterpriseManagedUsers
+		*out = new(EnterpriseManagedUsers)
+		**out = **in
+	}
+	if in.OrganizationDiscovery != nil {
+		in, out := &in.OrganizationDiscovery, &out.OrganizationDiscovery
+		*out = new(OrganizationDiscovery)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubSpec.
@@ -746,6 +1139,16 @@ func (in *GithubSpec) DeepCopy() *GithubSpec {
 func (in *GithubStatus) DeepCopyInto(out *GithubStatus) {
 	*out = *in
 	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.Token != nil {
+		in, out := &in.Token, &out.Token
+		*out = new(GithubTokenStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OrganizationDiscovery != nil {
+		in, out := &in.OrganizationDiscovery, &out.OrganizationDiscovery
+		*out = new(OrganizationDiscoveryStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubStatus.
@@ -817,6 +1220,96 @@ func (in *GithubTeamList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubTeamMembershipGrant) DeepCopyInto(out *GithubTeamMembershipGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubTeamMembershipGrant.
+func (in *GithubTeamMembershipGrant) DeepCopy() *GithubTeamMembershipGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubTeamMembershipGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GithubTeamMembershipGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubTeamMembershipGrantList) DeepCopyInto(out *GithubTeamMembershipGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GithubTeamMembershipGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubTeamMembershipGrantList.
+func (in *GithubTeamMembershipGrantList) DeepCopy() *GithubTeamMembershipGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubTeamMembershipGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GithubTeamMembershipGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubTeamMembershipGrantSpec) DeepCopyInto(out *GithubTeamMembershipGrantSpec) {
+	*out = *in
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubTeamMembershipGrantSpec.
+func (in *GithubTeamMembershipGrantSpec) DeepCopy() *GithubTeamMembershipGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubTeamMembershipGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubTeamMembershipGrantStatus) DeepCopyInto(out *GithubTeamMembershipGrantStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubTeamMembershipGrantStatus.
+func (in *GithubTeamMembershipGrantStatus) DeepCopy() *GithubTeamMembershipGrantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubTeamMembershipGrantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubTeamOperation) DeepCopyInto(out *GithubTeamOperation) {
 	*out = *in
@@ -935,6 +1428,21 @@ func (in *GithubTeamSpec) DeepCopyInto(out *GithubTeamSpec) {
 		*out = new(ExternalMemberProviderConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Maintainers != nil {
+		in, out := &in.Maintainers, &out.Maintainers
+		*out = new(TeamMaintainers)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdPGroup != nil {
+		in, out := &in.IdPGroup, &out.IdPGroup
+		*out = new(TeamIdPGroup)
+		**out = **in
+	}
+	if in.AccessRequestApprovers != nil {
+		in, out := &in.AccessRequestApprovers, &out.AccessRequestApprovers
+		*out = new(AccessRequestApprovers)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubTeamSpec.
@@ -963,6 +1471,28 @@ func (in *GithubTeamStatus) DeepCopyInto(out *GithubTeamStatus) {
 		*out = make([]Member, len(*in))
 		copy(*out, *in)
 	}
+	if in.NotFoundRecheck != nil {
+		in, out := &in.NotFoundRecheck, &out.NotFoundRecheck
+		*out = new(NotFoundRecheckStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SettingsDrift != nil {
+		in, out := &in.SettingsDrift, &out.SettingsDrift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IdPGroup != nil {
+		in, out := &in.IdPGroup, &out.IdPGroup
+		*out = new(IdPGroupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MembershipGrants != nil {
+		in, out := &in.MembershipGrants, &out.MembershipGrants
+		*out = make([]MembershipGrantStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubTeamStatus.
@@ -990,6 +1520,31 @@ func (in *GithubTeamWithPermission) DeepCopy() *GithubTeamWithPermission {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubTokenStatus) DeepCopyInto(out *GithubTokenStatus) {
+	*out = *in
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MissingScopes != nil {
+		in, out := &in.MissingScopes, &out.MissingScopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubTokenStatus.
+func (in *GithubTokenStatus) DeepCopy() *GithubTokenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubTokenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubUserOperation) DeepCopyInto(out *GithubUserOperation) {
 	*out = *in
@@ -1006,6 +1561,25 @@ func (in *GithubUserOperation) DeepCopy() *GithubUserOperation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdPGroupStatus) DeepCopyInto(out *IdPGroupStatus) {
+	*out = *in
+	if in.LastSync != nil {
+		in, out := &in.LastSync, &out.LastSync
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdPGroupStatus.
+func (in *IdPGroupStatus) DeepCopy() *IdPGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IdPGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LDAPGroup) DeepCopyInto(out *LDAPGroup) {
 	*out = *in
@@ -1126,6 +1700,148 @@ func (in *Member) DeepCopy() *Member {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MembershipGrantStatus) DeepCopyInto(out *MembershipGrantStatus) {
+	*out = *in
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MembershipGrantStatus.
+func (in *MembershipGrantStatus) DeepCopy() *MembershipGrantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MembershipGrantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotFoundRecheckStatus) DeepCopyInto(out *NotFoundRecheckStatus) {
+	*out = *in
+	in.LastCheck.DeepCopyInto(&out.LastCheck)
+	in.NextCheck.DeepCopyInto(&out.NextCheck)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotFoundRecheckStatus.
+func (in *NotFoundRecheckStatus) DeepCopy() *NotFoundRecheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NotFoundRecheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationDiscovery) DeepCopyInto(out *OrganizationDiscovery) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationDiscovery.
+func (in *OrganizationDiscovery) DeepCopy() *OrganizationDiscovery {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationDiscovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationDiscoveryStatus) DeepCopyInto(out *OrganizationDiscoveryStatus) {
+	*out = *in
+	in.LastDiscovery.DeepCopyInto(&out.LastDiscovery)
+	if in.Created != nil {
+		in, out := &in.Created, &out.Created
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstallationRemoved != nil {
+		in, out := &in.InstallationRemoved, &out.InstallationRemoved
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationDiscoveryStatus.
+func (in *OrganizationDiscoveryStatus) DeepCopy() *OrganizationDiscoveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationDiscoveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutOfBandChange) DeepCopyInto(out *OutOfBandChange) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutOfBandChange.
+func (in *OutOfBandChange) DeepCopy() *OutOfBandChange {
+	if in == nil {
+		return nil
+	}
+	out := new(OutOfBandChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryAccess) DeepCopyInto(out *RepositoryAccess) {
+	*out = *in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryAccess.
+func (in *RepositoryAccess) DeepCopy() *RepositoryAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SAMLAccountLinkSync) DeepCopyInto(out *SAMLAccountLinkSync) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SAMLAccountLinkSync.
+func (in *SAMLAccountLinkSync) DeepCopy() *SAMLAccountLinkSync {
+	if in == nil {
+		return nil
+	}
+	out := new(SAMLAccountLinkSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SAMLAccountLinkSyncStatus) DeepCopyInto(out *SAMLAccountLinkSyncStatus) {
+	*out = *in
+	in.LastSync.DeepCopyInto(&out.LastSync)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SAMLAccountLinkSyncStatus.
+func (in *SAMLAccountLinkSyncStatus) DeepCopy() *SAMLAccountLinkSyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SAMLAccountLinkSyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StaticGroup) DeepCopyInto(out *StaticGroup) {
 	*out = *in
@@ -1242,3 +1958,38 @@ func (in *StaticMemberProviderStatus) DeepCopy() *StaticMemberProviderStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamIdPGroup) DeepCopyInto(out *TeamIdPGroup) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamIdPGroup.
+func (in *TeamIdPGroup) DeepCopy() *TeamIdPGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamIdPGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamMaintainers) DeepCopyInto(out *TeamMaintainers) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamMaintainers.
+func (in *TeamMaintainers) DeepCopy() *TeamMaintainers {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamMaintainers)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -4,7 +4,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"net"
+	"net/http"
 	"os"
 	"time"
 
@@ -18,14 +22,18 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	greenhousesapv1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
 
 	repoguardsapv1 "github.com/cloudoperators/repo-guard/api/v1"
 	"github.com/cloudoperators/repo-guard/internal/controller"
+	"github.com/cloudoperators/repo-guard/internal/github"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -50,6 +58,12 @@ func main() {
 	var maxConcurrentReconciles int
 	var leaderElect bool
 	var leaderElectionID string
+	var accountLinkRefreshInterval time.Duration
+	var etagCacheMaxEntries int
+	var etagCacheDir string
+	var etagCacheFlushInterval time.Duration
+	var githubWebhookAddr string
+	var accessRequestUsernamePrefix string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8082", "The address the probe endpoint binds to.")
@@ -57,6 +71,12 @@ func main() {
 	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 10, "The maximum number of concurrent Reconciles which can be run.")
 	flag.BoolVar(&leaderElect, "leader-elect", false, "Enable leader election for controller manager.")
 	flag.StringVar(&leaderElectionID, "leader-election-id", "repo-guard.cloudoperators.dev", "Name of the leader election Lease resource.")
+	flag.DurationVar(&accountLinkRefreshInterval, "account-link-refresh-interval", 24*time.Hour, "How often the GitHub user of every GithubAccountLink is re-verified.")
+	flag.IntVar(&etagCacheMaxEntries, "etag-cache-max-entries", 5000, "The maximum number of ETag cache entries per organization; least recently used entries are evicted beyond it. 0 means unbounded.")
+	flag.StringVar(&etagCacheDir, "etag-cache-dir", "", "Directory to persist the ETag caches to, so that they survive restarts. Empty disables persistence.")
+	flag.DurationVar(&etagCacheFlushInterval, "etag-cache-flush-interval", 5*time.Minute, "How often changed ETag caches are written to --etag-cache-dir.")
+	flag.StringVar(&githubWebhookAddr, "github-webhook-bind-address", "", "The address the GitHub App webhook endpoint binds to. Empty disables it.")
+	flag.StringVar(&accessRequestUsernamePrefix, "access-request-username-prefix", "", "Prefix of the Kubernetes user names of Greenhouse users, e.g. the OIDC username prefix. Members of the approvers team of a GithubAccessRequest approve as <prefix><Greenhouse ID>.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -94,6 +114,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	github.ConfigureEtagCache(github.EtagCacheOptions{MaxEntriesPerOrganization: etagCacheMaxEntries, Dir: etagCacheDir})
+	if etagCacheDir != "" {
+		if err := github.LoadEtagCaches(); err != nil {
+			setupLog.Error(err, "unable to load persisted etag caches")
+		}
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			ticker := time.NewTicker(etagCacheFlushInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if err := github.FlushEtagCaches(); err != nil {
+						setupLog.Error(err, "unable to persist etag caches")
+					}
+				case <-ctx.Done():
+					return github.FlushEtagCaches()
+				}
+			}
+		})); err != nil {
+			setupLog.Error(err, "unable to set up etag cache persistence")
+			os.Exit(1)
+		}
+	}
+
 	if err := controller.SetupFieldIndexes(mgr); err != nil {
 		setupLog.Error(err, "unable to create index for GithubAccountLink")
 		os.Exit(1)
@@ -106,11 +150,61 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Github")
 		os.Exit(1)
 	}
+	if err = (&controller.GithubOrganizationDiscoveryReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubOrganizationDiscovery")
+		os.Exit(1)
+	}
+
+	var teamWebhookEvents, organizationWebhookEvents <-chan event.GenericEvent
+	if githubWebhookAddr != "" {
+		receiver := controller.NewGithubWebhookReceiver(mgr.GetClient())
+		teamWebhookEvents = receiver.TeamEvents()
+		organizationWebhookEvents = receiver.OrganizationEvents()
+		mux := http.NewServeMux()
+		mux.Handle(controller.GITHUB_WEBHOOK_PATH_PREFIX, receiver)
+		webhookServer := &http.Server{Addr: githubWebhookAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		// pods are read without the cache, which would watch all pods of the cluster
+		podClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+		if err != nil {
+			setupLog.Error(err, "unable to create client for the github webhook endpoint")
+			os.Exit(1)
+		}
+		// the endpoint runs on the leader only
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", githubWebhookAddr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				<-ctx.Done()
+				_ = webhookServer.Shutdown(context.Background())
+			}()
+			// the webhook Service selects the labelled pod once it listens
+			if podName := os.Getenv("POD_NAME"); podName != "" {
+				if err := controller.LabelGithubWebhookLeader(ctx, podClient, controller.OperatorNamespace, podName); err != nil {
+					_ = listener.Close()
+					return err
+				}
+			}
+			if err := webhookServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		})); err != nil {
+			setupLog.Error(err, "unable to set up github webhook endpoint")
+			os.Exit(1)
+		}
+	}
 
 	if err = (&controller.GithubTeamReconciler{
 		Client:                  mgr.GetClient(),
 		Scheme:                  mgr.GetScheme(),
+		Recorder:                mgr.GetEventRecorder("githubteam-controller"),
 		MaxConcurrentReconciles: maxConcurrentReconciles,
+		WebhookEvents:           teamWebhookEvents,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GithubTeam")
 		os.Exit(1)
@@ -119,11 +213,38 @@ func main() {
 	if err = (&controller.GithubOrganizationReconciler{
 		Client:                  mgr.GetClient(),
 		Scheme:                  mgr.GetScheme(),
+		Recorder:                mgr.GetEventRecorder("githuborganization-controller"),
 		MaxConcurrentReconciles: maxConcurrentReconciles,
+		WebhookEvents:           organizationWebhookEvents,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GithubOrganization")
 		os.Exit(1)
 	}
+	if err = (&controller.GithubTeamRepositoryReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("githubteamrepository-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubTeamRepository")
+		os.Exit(1)
+	}
+	if err = (&controller.GithubTeamMembershipGrantReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorder("githubteammembershipgrant-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubTeamMembershipGrant")
+		os.Exit(1)
+	}
+	if err = (&controller.GithubAccessRequestReconciler{
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorder("githubaccessrequest-controller"),
+		UsernamePrefix: accessRequestUsernamePrefix,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubAccessRequest")
+		os.Exit(1)
+	}
 	if err = (&controller.LDAPGroupProviderReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -145,6 +266,14 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "GithubAccountLink")
 		os.Exit(1)
 	}
+	if err = (&controller.GithubAccountLinkStatusReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		RefreshInterval: accountLinkRefreshInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubAccountLinkStatus")
+		os.Exit(1)
+	}
 	if err = (&controller.GenericExternalMemberProviderReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -173,6 +302,27 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterStaticMemberProvider")
 		os.Exit(1)
 	}
+	if err = (&controller.GithubOrganizationEmailVerificationReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubOrganizationEmailVerification")
+		os.Exit(1)
+	}
+	if err = (&controller.GithubOrganizationSAMLReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubOrganizationSAML")
+		os.Exit(1)
+	}
+	if err = (&controller.GithubOrganizationAuditLogReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubOrganizationAuditLog")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
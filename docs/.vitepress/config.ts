@@ -33,6 +33,8 @@ export default withMermaid(defineConfig({
           { text: 'GithubOrganization', link: '/crds/github-organization' },
           { text: 'GithubTeam', link: '/crds/github-team' },
           { text: 'GithubTeamRepository', link: '/crds/github-team-repository' },
+          { text: 'GithubTeamMembershipGrant', link: '/crds/github-team-membership-grant' },
+          { text: 'GithubAccessRequest', link: '/crds/github-access-request' },
           { text: 'GithubAccountLink', link: '/crds/github-account-link' },
           { text: 'Member Providers', link: '/crds/member-providers' },
         ],
@@ -835,7 +835,7 @@ cmd_test() {
 
   # 1) Ensure CRDs are present
   log_step "Checking required CRDs exist"
-  for crd in githubs.repo-guard.cloudoperators.dev githuborganizations.repo-guard.cloudoperators.dev githubteams.repo-guard.cloudoperators.dev githubteamrepositories.repo-guard.cloudoperators.dev githubaccountlinks.repo-guard.cloudoperators.dev staticmemberproviders.repo-guard.cloudoperators.dev ldapgroupproviders.repo-guard.cloudoperators.dev genericexternalmemberproviders.repo-guard.cloudoperators.dev; do
+  for crd in githubs.repo-guard.cloudoperators.dev githuborganizations.repo-guard.cloudoperators.dev githubteams.repo-guard.cloudoperators.dev githubteamrepositories.repo-guard.cloudoperators.dev githubteammembershipgrants.repo-guard.cloudoperators.dev githubaccessrequests.repo-guard.cloudoperators.dev githubaccountlinks.repo-guard.cloudoperators.dev staticmemberproviders.repo-guard.cloudoperators.dev ldapgroupproviders.repo-guard.cloudoperators.dev genericexternalmemberproviders.repo-guard.cloudoperators.dev; do
     echo -n "Checking CRD $crd ... "
     kubectl get crd "$crd" >/dev/null
     echo OK
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+// deletionRecheckInterval is the requeue of a resource whose deletion waits for Github
+// or for other resources.
+const deletionRecheckInterval = 10 * time.Second
+
+// EVENT_REASON_CLEANUP_ORPHANED is the reason of the event emitted when a resource is
+// deleted without cleaning up Github because its Github or GithubOrganization is gone.
+const EVENT_REASON_CLEANUP_ORPHANED = "CleanupOrphaned"
+
+// setCleanupFinalizer adds the cleanup finalizer to obj, or removes it when want is
+// false. obj is refreshed with the latest version before the update.
+func setCleanupFinalizer(ctx context.Context, c client.Client, obj client.Object, want bool) error {
+	if controllerutil.ContainsFinalizer(obj, v1.REPO_GUARD_FINALIZER) == want {
+		return nil
+	}
+	key := client.ObjectKeyFromObject(obj)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.Get(ctx, key, obj); err != nil {
+			return err
+		}
+		if want {
+			controllerutil.AddFinalizer(obj, v1.REPO_GUARD_FINALIZER)
+		} else {
+			controllerutil.RemoveFinalizer(obj, v1.REPO_GUARD_FINALIZER)
+		}
+		return c.Update(ctx, obj)
+	})
+}
+
+// cleansUpGithub reports whether obj is being deleted with policy, which cleans up
+// Github. What it manages is no longer desired by its organization, so that the
+// organization does not add back what the cleanup removes.
+func cleansUpGithub(obj client.Object, policy v1.DeletionPolicy) bool {
+	return !obj.GetDeletionTimestamp().IsZero() && policy.NeedsCleanup()
+}
+
+// deletionRetry returns the requeue of a cleanup that failed with err: rate limits are
+// retried at their reset, stale ETag cache entries right away. ok is false for other
+// errors, which are returned to the work queue.
+func deletionRetry(err error) (res reconcile.Result, ok bool) {
+	if isEtagCacheInconsistency(err) {
+		return reconcile.Result{RequeueAfter: time.Second}, true
+	}
+	if t, limited := parseGitHubRateLimitReset(err.Error()); limited {
+		if now := time.Now().UTC(); t.After(now) {
+			return reconcile.Result{RequeueAfter: t.Sub(now)}, true
+		}
+		return reconcile.Result{RequeueAfter: time.Second}, true
+	}
+	return reconcile.Result{}, false
+}
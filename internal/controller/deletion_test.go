@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func newDeletionTestClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithStatusSubresource(&v1.GithubOrganization{}, &v1.GithubTeam{}, &v1.GithubTeamRepository{}).Build()
+}
+
+func deletedMeta(namespace, name string) metav1.ObjectMeta {
+	now := metav1.Now()
+	return metav1.ObjectMeta{Namespace: namespace, Name: name, DeletionTimestamp: &now, Finalizers: []string{v1.REPO_GUARD_FINALIZER}}
+}
+
+func TestDeletionChildGithubTeams(t *testing.T) {
+	parent := &v1.GithubTeam{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme--platform"}, Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "platform"}}
+	teams := []v1.GithubTeam{
+		*parent,
+		{ObjectMeta: metav1.ObjectMeta{Name: "com--acme--sre"}, Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "sre", ParentTeam: "Platform"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "com--acme--dev"}, Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "dev", ParentTeam: "platform"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "com--other--sre"}, Spec: v1.GithubTeamSpec{Github: "com", Organization: "other", Team: "sre", ParentTeam: "platform"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "com--acme--top"}, Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "top"}},
+	}
+	got := childGithubTeams(teams, parent)
+	if want := []string{"com--acme--dev", "com--acme--sre"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected child teams %v, got %v", want, got)
+	}
+}
+
+func TestPendingOrganizationCleanups(t *testing.T) {
+	teams := []v1.GithubTeam{
+		{ObjectMeta: deletedMeta("ns", "deleting")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "kept", Finalizers: []string{v1.REPO_GUARD_FINALIZER}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "orphan"}},
+	}
+	teamRepositories := []v1.GithubTeamRepository{
+		{ObjectMeta: deletedMeta("ns", "access")},
+	}
+
+	got := pendingOrganizationCleanups(v1.DeletionPolicyOrphan, teams, teamRepositories)
+	if want := []string{"GithubTeam deleting", "GithubTeamRepository access"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Orphan: expected %v, got %v", want, got)
+	}
+	// with Delete, the dependents were just deleted and all of them clean up
+	got = pendingOrganizationCleanups(v1.DeletionPolicyDelete, teams, teamRepositories)
+	if want := []string{"GithubTeam deleting", "GithubTeam kept", "GithubTeamRepository access"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Delete: expected %v, got %v", want, got)
+	}
+}
+
+func TestReconcileOrganizationDeletion(t *testing.T) {
+	org := &v1.GithubOrganization{
+		ObjectMeta: deletedMeta("ns", "com--acme"),
+		Spec:       v1.GithubOrganizationSpec{Github: "com", Organization: "acme", DeletionPolicy: v1.DeletionPolicyDelete},
+	}
+	team := &v1.GithubTeam{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme--admins", Finalizers: []string{v1.REPO_GUARD_FINALIZER}},
+		Spec:       v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "admins", DeletionPolicy: v1.DeletionPolicyDelete},
+	}
+	orphan := &v1.GithubTeam{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme--orphan"},
+		Spec:       v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "orphan"},
+	}
+	other := &v1.GithubTeam{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--other--admins"},
+		Spec:       v1.GithubTeamSpec{Github: "com", Organization: "other", Team: "admins"},
+	}
+	c := newDeletionTestClient(t, org, team, orphan, other)
+	r := &GithubOrganizationReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "com--acme"}}
+
+	res, err := r.reconcileOrganizationDeletion(t.Context(), req, org)
+	if err != nil {
+		t.Fatalf("reconcileOrganizationDeletion: %v", err)
+	}
+	if res.RequeueAfter != deletionRecheckInterval {
+		t.Errorf("expected a requeue while the team cleans up, got %+v", res)
+	}
+	latest := &v1.GithubOrganization{}
+	if err := c.Get(t.Context(), req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	if latest.Status.OrganizationStatus != v1.GithubOrganizationStateDeleting || latest.Status.OrganizationStatusError != "waiting for the cleanup of GithubTeam com--acme--admins" {
+		t.Errorf("unexpected status %q: %q", latest.Status.OrganizationStatus, latest.Status.OrganizationStatusError)
+	}
+	deleting := &v1.GithubTeam{}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(team), deleting); err != nil || deleting.DeletionTimestamp.IsZero() {
+		t.Errorf("expected the team to be deleted, got %v", err)
+	}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(orphan), &v1.GithubTeam{}); err == nil {
+		t.Error("expected the team without finalizer to be gone")
+	}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(other), &v1.GithubTeam{}); err != nil {
+		t.Errorf("expected the team of another organization to be kept, got %v", err)
+	}
+
+	// the team has cleaned up Github
+	controllerutil.RemoveFinalizer(deleting, v1.REPO_GUARD_FINALIZER)
+	if err := c.Update(t.Context(), deleting); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.reconcileOrganizationDeletion(t.Context(), req, latest); err != nil {
+		t.Fatalf("reconcileOrganizationDeletion: %v", err)
+	}
+	if err := c.Get(t.Context(), req.NamespacedName, &v1.GithubOrganization{}); err == nil {
+		t.Error("expected the organization to be gone once its teams cleaned up")
+	}
+}
+
+func TestGithubTeamRepositoryFinalizer(t *testing.T) {
+	teamRepository := &v1.GithubTeamRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "admins-app"},
+		Spec:       v1.GithubTeamRepositorySpec{Github: "com", Organization: "acme", Team: "admins", Repository: []string{"app"}, DeletionPolicy: v1.DeletionPolicyDelete},
+	}
+	c := newDeletionTestClient(t, teamRepository)
+	r := &GithubTeamRepositoryReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(teamRepository)}
+
+	if _, err := r.Reconcile(t.Context(), req); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	latest := &v1.GithubTeamRepository{}
+	if err := c.Get(t.Context(), req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	if !controllerutil.ContainsFinalizer(latest, v1.REPO_GUARD_FINALIZER) {
+		t.Fatal("expected the finalizer for the Delete policy")
+	}
+
+	// the Github is gone: the access is orphaned and the deletion is not blocked
+	if err := c.Delete(t.Context(), latest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(t.Context(), req); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if err := c.Get(t.Context(), req.NamespacedName, &v1.GithubTeamRepository{}); err == nil {
+		t.Error("expected the orphaned GithubTeamRepository to be gone")
+	}
+}
+
+func TestOrganizationSkipsGithubCleanups(t *testing.T) {
+	org := &v1.GithubOrganization{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme"},
+		Spec:       v1.GithubOrganizationSpec{Github: "com", Organization: "acme"},
+	}
+	deletingTeam := &v1.GithubTeam{
+		ObjectMeta: deletedMeta("ns", "com--acme--deleting"),
+		Spec:       v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "deleting", DeletionPolicy: v1.DeletionPolicyDelete},
+	}
+	orphanedTeam := &v1.GithubTeam{
+		ObjectMeta: deletedMeta("ns", "com--acme--orphaned"),
+		Spec:       v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "orphaned", DeletionPolicy: v1.DeletionPolicyOrphan},
+	}
+	team := &v1.GithubTeam{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme--admins"},
+		Spec:       v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "admins", DeletionPolicy: v1.DeletionPolicyDelete},
+	}
+	deletingAccess := &v1.GithubTeamRepository{
+		ObjectMeta: deletedMeta("ns", "deleting-app"),
+		Spec:       v1.GithubTeamRepositorySpec{Github: "com", Organization: "acme", Team: "deleting", Repository: []string{"app"}, DeletionPolicy: v1.DeletionPolicyDelete},
+	}
+	access := &v1.GithubTeamRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "admins-app"},
+		Spec:       v1.GithubTeamRepositorySpec{Github: "com", Organization: "acme", Team: "admins", Repository: []string{"app"}},
+	}
+	c := newDeletionTestClient(t, org, deletingTeam, orphanedTeam, team, deletingAccess, access)
+	r := &GithubOrganizationReconciler{Client: c}
+
+	teams, _, err := r.teamsFromGithubTeams(t.Context(), org)
+	if err != nil {
+		t.Fatalf("teamsFromGithubTeams: %v", err)
+	}
+	if want := []string{"admins", "orphaned"}; fmt.Sprint(teams) != fmt.Sprint(want) {
+		t.Errorf("expected teams %v, got %v", want, teams)
+	}
+	teamRepositories, err := r.GithubTeamRepositoryListByOrganization(t.Context(), "com", "acme")
+	if err != nil {
+		t.Fatalf("GithubTeamRepositoryListByOrganization: %v", err)
+	}
+	if len(teamRepositories) != 1 || teamRepositories[0].Name != "admins-app" {
+		t.Errorf("expected only the GithubTeamRepository that is not cleaned up, got %+v", teamRepositories)
+	}
+}
@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+// GithubClients holds the client creators of the running Githubs. The GithubReconciler
+// registers them; all other reconcilers read them concurrently.
+var GithubClients = NewGithubClientRegistry()
+
+// githubClientEntry is the client creator of a Github and the generation of the Github
+// it was created for.
+type githubClientEntry struct {
+	creator    githubapp.ClientCreator
+	generation int64
+}
+
+// githubClientEventBuffer is the number of events a subscriber buffers before its
+// controller reads them.
+const githubClientEventBuffer = 64
+
+// GithubClientRegistry is a synchronised registry of the client creators of Githubs,
+// keyed by the name of the Github. Subscribers are notified when the client of a
+// Github becomes available or is rebuilt for a new generation.
+type GithubClientRegistry struct {
+	mu          sync.RWMutex
+	clients     map[string]githubClientEntry
+	subscribers []chan event.GenericEvent
+}
+
+// NewGithubClientRegistry returns an empty registry.
+func NewGithubClientRegistry() *GithubClientRegistry {
+	return &GithubClientRegistry{clients: make(map[string]githubClientEntry)}
+}
+
+// Get returns the client creator of the Github githubName.
+func (r *GithubClientRegistry) Get(githubName string) (githubapp.ClientCreator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.clients[githubName]
+	return entry.creator, ok
+}
+
+// Generation returns the generation of the Github the registered client was created for.
+func (r *GithubClientRegistry) Generation(githubName string) (int64, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.clients[githubName]
+	return entry.generation, ok
+}
+
+// Set registers the client creator built for generation of the Github githubName.
+// Subscribers are notified if the Github had no client or one of another generation;
+// periodic rebuilds of the same generation replace the client silently.
+func (r *GithubClientRegistry) Set(githubName string, generation int64, creator githubapp.ClientCreator) {
+	r.mu.Lock()
+	previous, existed := r.clients[githubName]
+	r.clients[githubName] = githubClientEntry{creator: creator, generation: generation}
+	subscribers := r.subscribers
+	r.mu.Unlock()
+
+	if existed && previous.generation == generation {
+		return
+	}
+	for _, ch := range subscribers {
+		ev := event.GenericEvent{Object: &v1.Github{ObjectMeta: metav1.ObjectMeta{Name: githubName}}}
+		// sources only read once their controller started; do not block the GithubReconciler.
+		// A full buffer means the controller has not started yet, and it reconciles all
+		// its objects when it does.
+		select {
+		case ch <- ev:
+		default:
+			log.Log.Info("subscriber is not reading: event dropped", "github", githubName)
+		}
+	}
+}
+
+// Delete removes the client creator of the Github githubName, e.g. when the Github
+// was deleted or its configuration failed.
+func (r *GithubClientRegistry) Delete(githubName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, githubName)
+}
+
+// subscribe returns a channel receiving an event with the Github whenever its
+// client becomes available.
+func (r *GithubClientRegistry) subscribe() <-chan event.GenericEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ch := make(chan event.GenericEvent, githubClientEventBuffer)
+	r.subscribers = append(r.subscribers, ch)
+	return ch
+}
+
+// githubClientSource returns a source enqueueing the requests of mapFn for a Github
+// whose client became available. Reconcilers waiting for the client of a Github use
+// it instead of polling.
+func githubClientSource(mapFn handler.MapFunc) source.Source {
+	return source.Channel(GithubClients.subscribe(), handler.EnqueueRequestsFromMapFunc(mapFn))
+}
+
+// githubOrganizationsOfGithub returns a MapFunc enqueueing the GithubOrganizations of a
+// Github that match filter; a nil filter matches all.
+func githubOrganizationsOfGithub(c client.Reader, filter func(*v1.GithubOrganization) bool) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		orgList := &v1.GithubOrganizationList{}
+		if err := c.List(ctx, orgList); err != nil {
+			log.FromContext(ctx).Error(err, "failed to list GithubOrganizations", "github", o.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range orgList.Items {
+			org := &orgList.Items[i]
+			if org.Spec.Github != o.GetName() || (filter != nil && !filter(org)) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: org.Namespace, Name: org.Name}})
+		}
+		return requests
+	}
+}
+
+// githubTeamsOfGithub returns a MapFunc enqueueing the GithubTeams of a Github.
+func githubTeamsOfGithub(c client.Reader) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		teamList := &v1.GithubTeamList{}
+		if err := c.List(ctx, teamList); err != nil {
+			log.FromContext(ctx).Error(err, "failed to list GithubTeams", "github", o.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, team := range teamList.Items {
+			if team.Spec.Github == o.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: team.Namespace, Name: team.Name}})
+			}
+		}
+		return requests
+	}
+}
+
+// githubAccountLinksOfGithub returns a MapFunc enqueueing the GithubAccountLinks of a
+// Github that match filter; a nil filter matches all.
+func githubAccountLinksOfGithub(c client.Reader, filter func(*v1.GithubAccountLink) bool) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		linkList := &v1.GithubAccountLinkList{}
+		if err := c.List(ctx, linkList, client.MatchingFields{"spec.github": o.GetName()}); err != nil {
+			log.FromContext(ctx).Error(err, "failed to list GithubAccountLinks", "github", o.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range linkList.Items {
+			link := &linkList.Items[i]
+			if filter != nil && !filter(link) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: link.Namespace, Name: link.Name}})
+		}
+		return requests
+	}
+}
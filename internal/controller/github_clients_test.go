@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGithubClientRegistry(t *testing.T) {
+	registry := NewGithubClientRegistry()
+	events := registry.subscribe()
+	expectEvent := func(want bool) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if !want {
+				t.Fatalf("unexpected event for %s", ev.Object.GetName())
+			}
+			if ev.Object.GetName() != "gh" {
+				t.Errorf("event for %q, want gh", ev.Object.GetName())
+			}
+		case <-time.After(100 * time.Millisecond):
+			if want {
+				t.Fatal("expected an event")
+			}
+		}
+	}
+
+	if _, ok := registry.Get("gh"); ok {
+		t.Fatal("expected no client before Set")
+	}
+
+	first := NewFakeClientCreator("http://first")
+	registry.Set("gh", 1, first)
+	expectEvent(true)
+	if cc, ok := registry.Get("gh"); !ok || cc != first {
+		t.Fatalf("Get: got %v, %v", cc, ok)
+	}
+
+	// rebuilding the client of the same generation does not wake reconcilers
+	second := NewFakeClientCreator("http://second")
+	registry.Set("gh", 1, second)
+	expectEvent(false)
+	if cc, _ := registry.Get("gh"); cc != second {
+		t.Error("expected the rebuilt client")
+	}
+
+	registry.Set("gh", 2, first)
+	expectEvent(true)
+	if gen, _ := registry.Generation("gh"); gen != 2 {
+		t.Errorf("generation: got %d, want 2", gen)
+	}
+
+	registry.Delete("gh")
+	if _, ok := registry.Get("gh"); ok {
+		t.Error("expected no client after Delete")
+	}
+	registry.Set("gh", 2, first)
+	expectEvent(true)
+}
+
+func TestGithubClientRegistrySubscriberNotReading(t *testing.T) {
+	registry := NewGithubClientRegistry()
+	events := registry.subscribe()
+
+	// a subscriber whose controller has not started does not block Set
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for generation := int64(1); generation <= 2*githubClientEventBuffer; generation++ {
+			registry.Set("gh", generation, NewFakeClientCreator("http://gh"))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Set blocked on a subscriber that is not reading")
+	}
+
+	// the buffered events are delivered once the subscriber reads, the rest is dropped
+	if got := len(events); got != githubClientEventBuffer {
+		t.Errorf("buffered events: got %d, want %d", got, githubClientEventBuffer)
+	}
+	for range githubClientEventBuffer {
+		if ev := <-events; ev.Object.GetName() != "gh" {
+			t.Errorf("event for %q, want gh", ev.Object.GetName())
+		}
+	}
+	registry.Set("gh", 0, NewFakeClientCreator("http://gh"))
+	select {
+	case <-events:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expected an event after the subscriber caught up")
+	}
+}
@@ -6,6 +6,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/palantir/go-githubapp/githubapp"
@@ -23,15 +24,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	repoguardsapv1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
 	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
 )
 
-var GithubClients map[string]githubapp.ClientCreator
-
-func init() {
-	GithubClients = make(map[string]githubapp.ClientCreator)
-}
-
 // GithubReconciler reconciles a Github object
 type GithubReconciler struct {
 	client.Client
@@ -39,6 +35,7 @@ type GithubReconciler struct {
 }
 
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 func (r *GithubReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
 	l := log.FromContext(ctx)
 	done := ghmetrics.StartReconcileTimer("Github")
@@ -52,11 +49,12 @@ func (r *GithubReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 		done(result)
 	}()
 
-	github := &repoguardsapv1.Github{}
-	err = r.Get(ctx, req.NamespacedName, github)
+	githubInstance := &repoguardsapv1.Github{}
+	err = r.Get(ctx, req.NamespacedName, githubInstance)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			l.Info("resource not found in kubernetes: reconcile is skipped")
+			l.Info("resource not found in kubernetes: github client is removed")
+			GithubClients.Delete(req.Name)
 			return ctrl.Result{}, nil
 		}
 		l.Error(err, "error during getting the resource")
@@ -65,82 +63,61 @@ func (r *GithubReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 
 	// get secret for credentials
 	githubSecret := &corev1.Secret{}
-	err = r.Get(ctx, types.NamespacedName{Namespace: github.Namespace, Name: github.Spec.Secret}, githubSecret)
-	if err != nil && github.Namespace == "" {
+	err = r.Get(ctx, types.NamespacedName{Namespace: githubInstance.Namespace, Name: githubInstance.Spec.Secret}, githubSecret)
+	if err != nil && githubInstance.Namespace == "" {
 		// for cluster-scoped, look in operator namespace
-		err = r.Get(ctx, types.NamespacedName{Namespace: OperatorNamespace, Name: github.Spec.Secret}, githubSecret)
+		err = r.Get(ctx, types.NamespacedName{Namespace: OperatorNamespace, Name: githubInstance.Spec.Secret}, githubSecret)
 	}
 	if err != nil {
 		l.Error(err, "error during getting the secret for github")
-		if updateErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			latest := &repoguardsapv1.Github{}
-			if getErr := r.Get(ctx, req.NamespacedName, latest); getErr != nil {
-				return getErr
-			}
-			latest.Status.State = repoguardsapv1.GithubStateFailed
-			latest.Status.Error = fmt.Sprintf("error in getting secret: %v", err)
-			latest.Status.Timestamp = metav1.Now()
-			return r.Status().Update(ctx, latest)
-		}); updateErr != nil {
-			l.Error(updateErr, "error during status update")
-			return reconcile.Result{}, updateErr
-		}
-		return reconcile.Result{}, nil
+		return r.updateFailedStatus(ctx, req, fmt.Sprintf("error in getting secret: %v", err))
 	}
 
 	cfg := githubapp.Config{
-		WebURL:   github.Spec.WebURL,
-		V3APIURL: github.Spec.V3APIURL,
-		V4APIURL: deriveV4APIURL(github.Spec.V3APIURL),
+		WebURL:   githubInstance.Spec.WebURL,
+		V3APIURL: githubInstance.Spec.V3APIURL,
+		V4APIURL: deriveV4APIURL(githubInstance.Spec.V3APIURL),
 	}
-	cfg.App.IntegrationID = github.Spec.IntegrationID
+	cfg.App.IntegrationID = githubInstance.Spec.IntegrationID
 
 	cfg.OAuth.ClientID = string(githubSecret.Data[repoguardsapv1.GITHUB_SECRET_CLIENT_ID_KEY])
 	cfg.OAuth.ClientSecret = string(githubSecret.Data[repoguardsapv1.GITHUB_SECRET_CLIENT_SECRET_KEY])
 	cfg.App.PrivateKey = string(githubSecret.Data[repoguardsapv1.SECRET_PRIVATE_KEY_KEY])
-	cc, err := githubapp.NewDefaultCachingClientCreator(
-		cfg,
-		githubapp.WithClientUserAgent(github.Spec.ClientUserAgent),
-	)
+	clientOptions := []githubapp.ClientOption{githubapp.WithClientUserAgent(githubInstance.Spec.ClientUserAgent)}
+	if githubInstance.Spec.Proxy != nil || githubInstance.Spec.CABundle != nil {
+		transport, err := r.transport(ctx, githubInstance)
+		if err != nil {
+			l.Error(err, "error during github transport configuration")
+			return r.updateFailedStatus(ctx, req, fmt.Sprintf("error in github transport configuration: %v", err))
+		}
+		clientOptions = append(clientOptions, githubapp.WithTransport(transport))
+	}
+	cc, err := githubapp.NewDefaultCachingClientCreator(cfg, clientOptions...)
 	if err != nil {
 		l.Error(err, "error during github client creation")
-		if updateErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			latest := &repoguardsapv1.Github{}
-			if getErr := r.Get(ctx, req.NamespacedName, latest); getErr != nil {
-				return getErr
-			}
-			latest.Status.State = repoguardsapv1.GithubStateFailed
-			latest.Status.Error = fmt.Sprintf("error in github client creation: %v", err)
-			latest.Status.Timestamp = metav1.Now()
-			return r.Status().Update(ctx, latest)
-		}); updateErr != nil {
-			l.Error(updateErr, "error during status update")
-			return reconcile.Result{}, updateErr
-		}
-		return reconcile.Result{}, nil
+		return r.updateFailedStatus(ctx, req, fmt.Sprintf("error in github client creation: %v", err))
 	}
 
-	// create a test client
-	_, err = cc.NewAppClient()
-	if err != nil {
-		l.Error(err, "error during github app client creation")
-		if updateErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			latest := &repoguardsapv1.Github{}
-			if getErr := r.Get(ctx, req.NamespacedName, latest); getErr != nil {
-				return getErr
-			}
-			latest.Status.State = repoguardsapv1.GithubStateFailed
-			latest.Status.Error = fmt.Sprintf("error in github app client creation: %v", err)
-			latest.Status.Timestamp = metav1.Now()
-			return r.Status().Update(ctx, latest)
-		}); updateErr != nil {
-			l.Error(updateErr, "error during status update")
-			return reconcile.Result{}, updateErr
+	var tokenStatus *repoguardsapv1.GithubTokenStatus
+	if githubInstance.Spec.AuthMode == repoguardsapv1.GithubAuthModeToken {
+		cc, tokenStatus, err = tokenClientCreator(ctx, cc, githubSecret)
+		if err != nil {
+			l.Error(err, "error during github token validation")
+			return r.updateFailedStatus(ctx, req, fmt.Sprintf("error in github token validation: %v", err))
+		}
+		if len(tokenStatus.MissingScopes) > 0 {
+			l.Info("github token is missing scopes", "login", tokenStatus.Login, "missingScopes", tokenStatus.MissingScopes)
+		}
+	} else {
+		// create a test client
+		_, err = cc.NewAppClient()
+		if err != nil {
+			l.Error(err, "error during github app client creation")
+			return r.updateFailedStatus(ctx, req, fmt.Sprintf("error in github app client creation: %v", err))
 		}
-		return reconcile.Result{}, nil
 	}
 
-	GithubClients[github.Name] = cc
+	GithubClients.Set(githubInstance.Name, githubInstance.Generation, github.NewRateLimitBudgetClientCreator(cc, githubInstance.Name, cfg.V4APIURL))
 
 	// update status to running
 	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -149,6 +126,8 @@ func (r *GithubReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 			return err
 		}
 		latest.Status.State = repoguardsapv1.GithubStateRunning
+		latest.Status.Error = ""
+		latest.Status.Token = tokenStatus
 		latest.Status.Timestamp = metav1.Now()
 		return r.Status().Update(ctx, latest)
 	})
@@ -163,6 +142,104 @@ func (r *GithubReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 	l.Info("github is configured and running as part of controller")
 	return ctrl.Result{}, nil
 }
+
+// updateFailedStatus removes the client of the Github and sets it to failed with
+// message. The reconcile is not retried: a fix of the Github or its secret triggers
+// the next one.
+func (r *GithubReconciler) updateFailedStatus(ctx context.Context, req ctrl.Request, message string) (ctrl.Result, error) {
+	GithubClients.Delete(req.Name)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &repoguardsapv1.Github{}
+		if getErr := r.Get(ctx, req.NamespacedName, latest); getErr != nil {
+			return getErr
+		}
+		latest.Status.State = repoguardsapv1.GithubStateFailed
+		latest.Status.Error = message
+		latest.Status.Timestamp = metav1.Now()
+		return r.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// transport builds the HTTP transport for the proxy and CA bundle of githubInstance.
+func (r *GithubReconciler) transport(ctx context.Context, githubInstance *repoguardsapv1.Github) (http.RoundTripper, error) {
+	var cfg github.TransportConfig
+	if proxy := githubInstance.Spec.Proxy; proxy != nil {
+		cfg.ProxyURL = proxy.URL
+		cfg.NoProxy = proxy.NoProxy
+	}
+	if ref := githubInstance.Spec.CABundle; ref != nil {
+		bundle, err := r.caBundle(ctx, githubInstance.Namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+		cfg.CABundle = bundle
+	}
+	return github.NewTransport(cfg)
+}
+
+// caBundle reads the PEM bundle referenced by ref. Like the secret of the Github, it is
+// looked up in the operator's namespace for cluster-scoped Githubs.
+func (r *GithubReconciler) caBundle(ctx context.Context, namespace string, ref *repoguardsapv1.CABundleReference) ([]byte, error) {
+	key := ref.Key
+	if key == "" {
+		key = repoguardsapv1.CA_BUNDLE_DEFAULT_KEY
+	}
+	get := func(obj client.Object) error {
+		err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, obj)
+		if err != nil && namespace == "" {
+			err = r.Get(ctx, types.NamespacedName{Namespace: OperatorNamespace, Name: ref.Name}, obj)
+		}
+		return err
+	}
+
+	var bundle []byte
+	switch ref.Kind {
+	case "Secret":
+		secret := &corev1.Secret{}
+		if err := get(secret); err != nil {
+			return nil, fmt.Errorf("getting CA bundle secret: %w", err)
+		}
+		bundle = secret.Data[key]
+	case "ConfigMap":
+		configMap := &corev1.ConfigMap{}
+		if err := get(configMap); err != nil {
+			return nil, fmt.Errorf("getting CA bundle config map: %w", err)
+		}
+		bundle = []byte(configMap.Data[key])
+	default:
+		return nil, fmt.Errorf("unsupported CA bundle kind %q", ref.Kind)
+	}
+	if len(bundle) == 0 {
+		return nil, fmt.Errorf("CA bundle %s %q has no key %q", ref.Kind, ref.Name, key)
+	}
+	return bundle, nil
+}
+
+// tokenClientCreator wraps base so that all clients authenticate with the token of the
+// secret, and validates the token's scopes.
+func tokenClientCreator(ctx context.Context, base githubapp.ClientCreator, secret *corev1.Secret) (githubapp.ClientCreator, *repoguardsapv1.GithubTokenStatus, error) {
+	token := strings.TrimSpace(string(secret.Data[repoguardsapv1.GITHUB_SECRET_TOKEN_KEY]))
+	if token == "" {
+		return nil, nil, fmt.Errorf("secret has no %q key", repoguardsapv1.GITHUB_SECRET_TOKEN_KEY)
+	}
+	cc := github.NewTokenClientCreator(base, token)
+	info, err := github.ValidateToken(ctx, cc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cc, &repoguardsapv1.GithubTokenStatus{
+		Login:         info.Login,
+		Scopes:        info.Scopes,
+		MissingScopes: info.MissingScopes,
+		FineGrained:   info.FineGrained,
+	}, nil
+}
+
 func (r *GithubReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&repoguardsapv1.Github{}).
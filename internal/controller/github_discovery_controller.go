@@ -0,0 +1,286 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	goerrors "errors"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// defaultOrganizationDiscoveryInterval is used when spec.organizationDiscovery.interval is unset or invalid.
+const defaultOrganizationDiscoveryInterval = time.Hour
+
+// Label set on GithubOrganizations created by organization discovery. The value is the name of the Github.
+const GITHUB_ORG_LABEL_DISCOVERED_BY = "repo-guard.cloudoperators.dev/discoveredBy"
+
+// Label set by organization discovery on GithubOrganizations whose organization has no
+// active installation of the GitHub App anymore. It is removed when the app is installed again.
+const GITHUB_ORG_LABEL_INSTALLATION_REMOVED = "repo-guard.cloudoperators.dev/installationRemoved"
+const GITHUB_ORG_LABEL_INSTALLATION_REMOVED_VALUE = "true"
+
+// GithubOrganizationDiscoveryReconciler creates a GithubOrganization for every organization
+// the GitHub App of a Github is installed on, if the Github opts in via
+// spec.organizationDiscovery. GithubOrganizations are never deleted: organizations whose
+// installation was removed are labelled with GITHUB_ORG_LABEL_INSTALLATION_REMOVED.
+type GithubOrganizationDiscoveryReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (r *GithubOrganizationDiscoveryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	l := log.FromContext(ctx)
+	done := ghmetrics.StartReconcileTimer("GithubOrganizationDiscovery")
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
+		} else if res.RequeueAfter > 0 {
+			result = "requeue"
+		}
+		done(result)
+	}()
+
+	githubInstance := &v1.Github{}
+	if err = r.Get(ctx, req.NamespacedName, githubInstance); err != nil {
+		if errors.IsNotFound(err) {
+			l.Info("resource not found in kubernetes: reconcile is skipped")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	cfg := githubInstance.Spec.OrganizationDiscovery
+	if cfg == nil || !cfg.Enabled {
+		return reconcile.Result{}, nil
+	}
+
+	interval := defaultOrganizationDiscoveryInterval
+	if cfg.Interval != "" {
+		if d, perr := time.ParseDuration(cfg.Interval); perr == nil && d > 0 {
+			interval = d
+		} else {
+			l.Info("invalid organization discovery interval; using default", "value", cfg.Interval, "default", interval)
+		}
+	}
+
+	now := time.Now().UTC()
+	if st := githubInstance.Status.OrganizationDiscovery; st != nil && !st.LastDiscovery.IsZero() {
+		if next := st.LastDiscovery.Add(interval); now.Before(next) {
+			return reconcile.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+
+	githubClient, ok := GithubClients.Get(githubInstance.Name)
+	if !ok {
+		l.Info("waiting for github to be initialized", "github", githubInstance.Name)
+		return reconcile.Result{}, nil
+	}
+
+	newStatus := &v1.OrganizationDiscoveryStatus{LastDiscovery: metav1.NewTime(now)}
+	if github.UsesTokenAuth(githubClient) {
+		newStatus.Error = "organization discovery requires authMode app"
+		return reconcile.Result{RequeueAfter: interval}, r.updateDiscoveryStatus(ctx, req, newStatus)
+	}
+	installations, err := github.AppInstallations(ctx, githubClient)
+	if err != nil {
+		l.Error(err, "error during listing app installations")
+		newStatus.Error = err.Error()
+		requeueAfter := interval
+		if t, ok := parseGitHubRateLimitReset(err.Error()); ok {
+			recordOrgRateLimitHit(err.Error(), t)
+			if t.After(now) {
+				requeueAfter = t.Sub(now)
+			}
+		}
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.updateDiscoveryStatus(ctx, req, newStatus)
+	}
+
+	var orgList v1.GithubOrganizationList
+	if err := r.List(ctx, &orgList); err != nil {
+		l.Error(err, "error during listing GithubOrganizations")
+		return reconcile.Result{}, err
+	}
+
+	plan := planOrganizationDiscovery(githubInstance.Name, cfg, installations, orgList.Items)
+	newStatus.Installations = plan.installations
+	newStatus.InstallationRemoved = plan.installationRemoved
+
+	var errs []error
+	for i := range plan.update {
+		org := &plan.update[i]
+		if err := r.Update(ctx, org); err != nil {
+			l.Error(err, "error during updating GithubOrganization", "namespace", org.Namespace, "name", org.Name)
+			errs = append(errs, err)
+			continue
+		}
+		l.Info("GithubOrganization installation label updated", "namespace", org.Namespace, "name", org.Name,
+			"installationRemoved", org.Labels[GITHUB_ORG_LABEL_INSTALLATION_REMOVED] == GITHUB_ORG_LABEL_INSTALLATION_REMOVED_VALUE)
+	}
+	for i := range plan.create {
+		org := &plan.create[i]
+		if err := r.Create(ctx, org); err != nil {
+			if errors.IsAlreadyExists(err) {
+				l.Info("GithubOrganization with the same name already exists: organization skipped", "namespace", org.Namespace, "name", org.Name)
+				continue
+			}
+			l.Error(err, "error during creating GithubOrganization", "namespace", org.Namespace, "name", org.Name)
+			errs = append(errs, err)
+			continue
+		}
+		l.Info("GithubOrganization created from app installation", "namespace", org.Namespace, "name", org.Name)
+		newStatus.Created = append(newStatus.Created, org.Name)
+	}
+	if err := goerrors.Join(errs...); err != nil {
+		newStatus.Error = err.Error()
+	}
+
+	if err := r.updateDiscoveryStatus(ctx, req, newStatus); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// updateDiscoveryStatus writes only status.organizationDiscovery so that the Github
+// reconciler's status fields are left untouched.
+func (r *GithubOrganizationDiscoveryReconciler) updateDiscoveryStatus(ctx context.Context, req ctrl.Request, status *v1.OrganizationDiscoveryStatus) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.Github{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status.OrganizationDiscovery = status
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+	}
+	return err
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GithubOrganizationDiscoveryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Reconcile only Githubs that opted in; status-only updates are ignored
+	// because the discovery schedules itself via RequeueAfter.
+	pred := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		githubInstance, ok := o.(*v1.Github)
+		return ok && githubInstance.Spec.OrganizationDiscovery != nil && githubInstance.Spec.OrganizationDiscovery.Enabled
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.Github{}, builder.WithPredicates(pred, predicate.GenerationChangedPredicate{})).
+		Named("github-organizationdiscovery").
+		WatchesRawSource(githubClientSource(func(ctx context.Context, o client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: o.GetName()}}}
+		})).
+		Complete(r)
+}
+
+// organizationDiscoveryPlan lists the changes needed to bring the GithubOrganizations
+// of a Github in line with the installations of its GitHub App.
+type organizationDiscoveryPlan struct {
+	create []v1.GithubOrganization
+	// update holds GithubOrganizations whose GITHUB_ORG_LABEL_INSTALLATION_REMOVED label changes.
+	update []v1.GithubOrganization
+	// installations is the number of organizations with an active installation.
+	installations int
+	// installationRemoved lists "<namespace>/<name>" of the GithubOrganizations without an active installation.
+	installationRemoved []string
+}
+
+// planOrganizationDiscovery compares the installations of the GitHub App with the
+// GithubOrganizations of githubName in all namespaces. An organization that already has
+// a GithubOrganization is never created again, wherever it lives. Suspended installations
+// count as removed.
+func planOrganizationDiscovery(githubName string, cfg *v1.OrganizationDiscovery, installations []github.AppInstallation, orgs []v1.GithubOrganization) organizationDiscoveryPlan {
+	var plan organizationDiscoveryPlan
+
+	active := make(map[string]bool)
+	var order []string
+	for _, installation := range installations {
+		organization := strings.ToLower(installation.Organization)
+		if installation.Suspended {
+			continue
+		}
+		if !active[organization] {
+			active[organization] = true
+			order = append(order, installation.Organization)
+		}
+	}
+	plan.installations = len(order)
+	sort.Strings(order)
+
+	existing := make(map[string]bool)
+	for i := range orgs {
+		org := &orgs[i]
+		if org.Spec.Github != githubName {
+			continue
+		}
+		existing[strings.ToLower(org.Spec.Organization)] = true
+
+		removed := !active[strings.ToLower(org.Spec.Organization)]
+		if removed {
+			plan.installationRemoved = append(plan.installationRemoved, org.Namespace+"/"+org.Name)
+		}
+		labelled := org.Labels[GITHUB_ORG_LABEL_INSTALLATION_REMOVED] == GITHUB_ORG_LABEL_INSTALLATION_REMOVED_VALUE
+		if removed == labelled {
+			continue
+		}
+		updated := org.DeepCopy()
+		if removed {
+			if updated.Labels == nil {
+				updated.Labels = make(map[string]string)
+			}
+			updated.Labels[GITHUB_ORG_LABEL_INSTALLATION_REMOVED] = GITHUB_ORG_LABEL_INSTALLATION_REMOVED_VALUE
+		} else {
+			delete(updated.Labels, GITHUB_ORG_LABEL_INSTALLATION_REMOVED)
+		}
+		plan.update = append(plan.update, *updated)
+	}
+	sort.Strings(plan.installationRemoved)
+
+	for _, organization := range order {
+		if existing[strings.ToLower(organization)] {
+			continue
+		}
+		labels := make(map[string]string, len(cfg.Template.Labels)+1)
+		for k, v := range cfg.Template.Labels {
+			labels[k] = v
+		}
+		labels[GITHUB_ORG_LABEL_DISCOVERED_BY] = githubName
+
+		spec := cfg.Template.Spec.DeepCopy()
+		spec.Github = githubName
+		spec.Organization = organization
+		spec.InstallationID = 0
+
+		plan.create = append(plan.create, v1.GithubOrganization{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      strings.ToLower(githubName + "--" + organization),
+				Namespace: cfg.Namespace,
+				Labels:    labels,
+			},
+			Spec: *spec,
+		})
+	}
+	return plan
+}
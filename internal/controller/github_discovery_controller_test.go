@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+func discoveredOrg(namespace, name, githubName, organization string, labels map[string]string) v1.GithubOrganization {
+	return v1.GithubOrganization{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       v1.GithubOrganizationSpec{Github: githubName, Organization: organization},
+	}
+}
+
+func TestPlanOrganizationDiscovery(t *testing.T) {
+	cfg := &v1.OrganizationDiscovery{
+		Enabled:   true,
+		Namespace: "repo-guard",
+		Template: v1.GithubOrganizationTemplate{
+			Labels: map[string]string{GITHUB_ORG_LABEL_DRY_RUN: GITHUB_ORG_LABEL_DRY_RUN_ENABLED_VALUE},
+			Spec: v1.GithubOrganizationSpec{
+				Github:                 "ignored",
+				OrganizationOwnerTeams: []string{"org-admins"},
+				InstallationID:         99,
+			},
+		},
+	}
+	installations := []github.AppInstallation{
+		{ID: 1, Organization: "Existing-Org"},
+		{ID: 2, Organization: "New-Org"},
+		{ID: 3, Organization: "suspended-org", Suspended: true},
+		{ID: 4, Organization: "reinstalled-org"},
+		{ID: 5, Organization: "taken"},
+	}
+	removed := map[string]string{GITHUB_ORG_LABEL_INSTALLATION_REMOVED: GITHUB_ORG_LABEL_INSTALLATION_REMOVED_VALUE}
+	orgs := []v1.GithubOrganization{
+		// existing in another namespace, matched case-insensitively
+		discoveredOrg("team-a", "by-hand", "com", "existing-org", nil),
+		// installation suspended
+		discoveredOrg("repo-guard", "com--suspended-org", "com", "suspended-org", nil),
+		// installation gone, already labelled
+		discoveredOrg("repo-guard", "com--gone-org", "com", "gone-org", removed),
+		// installed again
+		discoveredOrg("repo-guard", "com--reinstalled-org", "com", "reinstalled-org", removed),
+		// another Github is ignored, even for the same organization
+		discoveredOrg("repo-guard", "ghe--new-org", "ghe", "new-org", nil),
+		// name taken by an organization of another Github
+		discoveredOrg("repo-guard", "com--taken", "ghe", "other", nil),
+	}
+
+	plan := planOrganizationDiscovery("com", cfg, installations, orgs)
+
+	if plan.installations != 4 {
+		t.Errorf("installations: got %d, want 4", plan.installations)
+	}
+	wantRemoved := []string{"repo-guard/com--gone-org", "repo-guard/com--suspended-org"}
+	if !slices.Equal(plan.installationRemoved, wantRemoved) {
+		t.Errorf("installationRemoved: got %v, want %v", plan.installationRemoved, wantRemoved)
+	}
+
+	if len(plan.update) != 2 {
+		t.Fatalf("update: got %d objects, want 2", len(plan.update))
+	}
+	for _, org := range plan.update {
+		got := org.Labels[GITHUB_ORG_LABEL_INSTALLATION_REMOVED]
+		switch org.Name {
+		case "com--suspended-org":
+			if got != GITHUB_ORG_LABEL_INSTALLATION_REMOVED_VALUE {
+				t.Errorf("%s: expected installationRemoved label, got %q", org.Name, got)
+			}
+		case "com--reinstalled-org":
+			if _, ok := org.Labels[GITHUB_ORG_LABEL_INSTALLATION_REMOVED]; ok {
+				t.Errorf("%s: expected installationRemoved label to be removed", org.Name)
+			}
+		default:
+			t.Errorf("unexpected update of %s", org.Name)
+		}
+	}
+	if _, ok := orgs[3].Labels[GITHUB_ORG_LABEL_INSTALLATION_REMOVED]; !ok {
+		t.Error("input GithubOrganization was modified")
+	}
+
+	var created []string
+	for _, org := range plan.create {
+		created = append(created, org.Namespace+"/"+org.Name)
+	}
+	wantCreated := []string{"repo-guard/com--new-org", "repo-guard/com--taken"}
+	if !slices.Equal(created, wantCreated) {
+		t.Fatalf("create: got %v, want %v", created, wantCreated)
+	}
+	newOrg := plan.create[0]
+	if newOrg.Spec.Github != "com" || newOrg.Spec.Organization != "New-Org" || newOrg.Spec.InstallationID != 0 {
+		t.Errorf("unexpected spec: %+v", newOrg.Spec)
+	}
+	if !slices.Equal(newOrg.Spec.OrganizationOwnerTeams, []string{"org-admins"}) {
+		t.Errorf("template spec not applied: %+v", newOrg.Spec)
+	}
+	if newOrg.Labels[GITHUB_ORG_LABEL_DRY_RUN] != GITHUB_ORG_LABEL_DRY_RUN_ENABLED_VALUE || newOrg.Labels[GITHUB_ORG_LABEL_DISCOVERED_BY] != "com" {
+		t.Errorf("unexpected labels: %v", newOrg.Labels)
+	}
+	newOrg.Spec.OrganizationOwnerTeams[0] = "changed"
+	if cfg.Template.Spec.OrganizationOwnerTeams[0] != "org-admins" {
+		t.Error("template spec shared with created GithubOrganization")
+	}
+}
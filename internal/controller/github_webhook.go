@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	gogithub "github.com/google/go-github/v90/github"
+	"github.com/gosimple/slug"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// GITHUB_WEBHOOK_PATH_PREFIX is followed by the name of the Github in the URL the
+// webhook of its GitHub App is configured with.
+const GITHUB_WEBHOOK_PATH_PREFIX = "/webhooks/github/"
+
+// webhookUnvalidatedLabel replaces the github and event labels of deliveries whose
+// signature was not validated, so that unauthenticated requests cannot create series.
+const webhookUnvalidatedLabel = "unknown"
+
+// webhookEventBuffer is the number of reconciles of each kind the receiver queues for
+// its controller; deliveries are refused while the queue is full.
+const webhookEventBuffer = 256
+
+// GITHUB_WEBHOOK_LEADER_LABEL marks the manager pod serving the webhook endpoint. Only
+// the leader serves it, so the webhook Service selects the pod with this label.
+const GITHUB_WEBHOOK_LEADER_LABEL = "repo-guard.cloudoperators.dev/github-webhook-leader"
+
+// GithubWebhookReceiver receives the webhook events of the GitHub Apps of all Githubs
+// and enqueues reconciles of the affected GithubTeams and GithubOrganizations, so that
+// changes made in the GitHub UI are reverted without waiting for the next resync.
+// Deliveries are validated with the webhook secret in the secret of the Github.
+type GithubWebhookReceiver struct {
+	client.Reader
+	organizations chan event.GenericEvent
+	teams         chan event.GenericEvent
+}
+
+// NewGithubWebhookReceiver returns a receiver reading Githubs, their secrets and the
+// GithubTeams and GithubOrganizations with c.
+func NewGithubWebhookReceiver(c client.Reader) *GithubWebhookReceiver {
+	return &GithubWebhookReceiver{
+		Reader:        c,
+		organizations: make(chan event.GenericEvent, webhookEventBuffer),
+		teams:         make(chan event.GenericEvent, webhookEventBuffer),
+	}
+}
+
+// OrganizationEvents returns the GithubOrganizations to reconcile; it is watched by
+// the GithubOrganizationReconciler.
+func (w *GithubWebhookReceiver) OrganizationEvents() <-chan event.GenericEvent {
+	return w.organizations
+}
+
+// TeamEvents returns the GithubTeams to reconcile; it is watched by the GithubTeamReconciler.
+func (w *GithubWebhookReceiver) TeamEvents() <-chan event.GenericEvent {
+	return w.teams
+}
+
+// webhookTarget is the organization and, for team events, the team a webhook event is about.
+type webhookTarget struct {
+	organization string
+	team         *gogithub.Team
+	// organizationLevel is set for events that need a reconcile of the GithubOrganization,
+	// such as repository permission or organization membership changes.
+	organizationLevel bool
+}
+
+// webhookTargetOf maps the parsed payload of a webhook event. Events of other types
+// are ignored.
+func webhookTargetOf(payload any) (webhookTarget, bool) {
+	switch e := payload.(type) {
+	case *gogithub.MembershipEvent:
+		return webhookTarget{organization: e.GetOrg().GetLogin(), team: e.GetTeam()}, true
+	case *gogithub.TeamEvent:
+		return webhookTarget{organization: e.GetOrg().GetLogin(), team: e.GetTeam(), organizationLevel: true}, true
+	case *gogithub.TeamAddEvent:
+		return webhookTarget{organization: e.GetOrg().GetLogin(), team: e.GetTeam(), organizationLevel: true}, true
+	case *gogithub.RepositoryEvent:
+		return webhookTarget{organization: e.GetOrg().GetLogin(), organizationLevel: true}, true
+	case *gogithub.MemberEvent:
+		org := e.GetOrg().GetLogin()
+		if org == "" {
+			org = e.GetRepo().GetOwner().GetLogin()
+		}
+		return webhookTarget{organization: org, organizationLevel: true}, true
+	case *gogithub.OrganizationEvent:
+		return webhookTarget{organization: e.GetOrganization().GetLogin(), organizationLevel: true}, true
+	}
+	return webhookTarget{}, false
+}
+
+// ServeHTTP handles POST GITHUB_WEBHOOK_PATH_PREFIX<github>.
+func (w *GithubWebhookReceiver) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	githubName := strings.TrimPrefix(req.URL.Path, GITHUB_WEBHOOK_PATH_PREFIX)
+	eventType := gogithub.WebHookType(req)
+	l := log.FromContext(ctx).WithValues("github", githubName, "event", eventType, "delivery", gogithub.DeliveryID(req))
+
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if githubName == "" || strings.Contains(githubName, "/") {
+		http.Error(rw, "not found", http.StatusNotFound)
+		return
+	}
+
+	secret, err := w.webhookSecret(ctx, githubName)
+	if err != nil {
+		l.Info("rejecting webhook delivery", "reason", err.Error())
+		ghmetrics.WebhookEventsTotal.WithLabelValues(webhookUnvalidatedLabel, webhookUnvalidatedLabel, "rejected").Inc()
+		http.Error(rw, "not found", http.StatusNotFound)
+		return
+	}
+	payload, err := gogithub.ValidatePayload(req, secret)
+	if err != nil {
+		l.Info("rejecting webhook delivery with invalid signature", "reason", err.Error())
+		ghmetrics.WebhookEventsTotal.WithLabelValues(webhookUnvalidatedLabel, webhookUnvalidatedLabel, "rejected").Inc()
+		http.Error(rw, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	parsed, err := gogithub.ParseWebHook(eventType, payload)
+	if err != nil {
+		// event types unknown to go-github are acknowledged and ignored
+		ghmetrics.WebhookEventsTotal.WithLabelValues(githubName, eventType, "ignored").Inc()
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+	target, ok := webhookTargetOf(parsed)
+	if !ok || target.organization == "" {
+		ghmetrics.WebhookEventsTotal.WithLabelValues(githubName, eventType, "ignored").Inc()
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	organizations, teams, err := w.affected(ctx, githubName, target)
+	if err != nil {
+		l.Error(err, "error during mapping webhook event")
+		ghmetrics.WebhookEventsTotal.WithLabelValues(githubName, eventType, "error").Inc()
+		http.Error(rw, "internal error", http.StatusInternalServerError)
+		return
+	}
+	l.Info("webhook event triggers reconciles", "org", target.organization, "organizations", len(organizations), "teams", len(teams))
+	queued := true
+	for _, obj := range organizations {
+		queued = enqueueWebhookEvent(w.organizations, obj) && queued
+	}
+	for _, obj := range teams {
+		queued = enqueueWebhookEvent(w.teams, obj) && queued
+	}
+	if !queued {
+		// the resources are reconciled by the next resync; the delivery can be redelivered
+		l.Info("webhook event queue is full: delivery refused")
+		ghmetrics.WebhookEventsTotal.WithLabelValues(githubName, eventType, "dropped").Inc()
+		http.Error(rw, "event queue full", http.StatusServiceUnavailable)
+		return
+	}
+	ghmetrics.WebhookEventsTotal.WithLabelValues(githubName, eventType, "accepted").Inc()
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// enqueueWebhookEvent queues obj without blocking the delivery and reports whether
+// there was room; the channel is only read once its controller started.
+func enqueueWebhookEvent(ch chan event.GenericEvent, obj client.Object) bool {
+	select {
+	case ch <- event.GenericEvent{Object: obj}:
+		return true
+	default:
+		return false
+	}
+}
+
+// LabelGithubWebhookLeader labels the pod podName in namespace as the one serving the
+// webhook endpoint and removes the label from the other pods, such as a former leader
+// whose container was restarted.
+func LabelGithubWebhookLeader(ctx context.Context, c client.Client, namespace, podName string) error {
+	pod := &corev1.Pod{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: podName}, pod); err != nil {
+		return fmt.Errorf("getting the manager pod: %w", err)
+	}
+	if pod.Labels[GITHUB_WEBHOOK_LEADER_LABEL] != "true" {
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[GITHUB_WEBHOOK_LEADER_LABEL] = "true"
+		if err := c.Patch(ctx, pod, patch); err != nil {
+			return fmt.Errorf("labelling the manager pod: %w", err)
+		}
+	}
+
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(namespace), client.HasLabels{GITHUB_WEBHOOK_LEADER_LABEL}); err != nil {
+		return fmt.Errorf("listing the labelled pods: %w", err)
+	}
+	for i := range pods.Items {
+		other := &pods.Items[i]
+		if other.Name == podName {
+			continue
+		}
+		patch := client.MergeFrom(other.DeepCopy())
+		delete(other.Labels, GITHUB_WEBHOOK_LEADER_LABEL)
+		if err := c.Patch(ctx, other, patch); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("removing the label of pod %s: %w", other.Name, err)
+		}
+	}
+	return nil
+}
+
+// webhookSecret returns the webhook secret of the Github githubName. Like its
+// credentials, the secret of a cluster-scoped Github is read from the operator's namespace.
+func (w *GithubWebhookReceiver) webhookSecret(ctx context.Context, githubName string) ([]byte, error) {
+	githubInstance := &v1.Github{}
+	if err := w.Get(ctx, types.NamespacedName{Name: githubName}, githubInstance); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("github %q not found", githubName)
+		}
+		return nil, err
+	}
+	secret := &corev1.Secret{}
+	err := w.Get(ctx, types.NamespacedName{Namespace: githubInstance.Namespace, Name: githubInstance.Spec.Secret}, secret)
+	if err != nil && githubInstance.Namespace == "" {
+		err = w.Get(ctx, types.NamespacedName{Namespace: OperatorNamespace, Name: githubInstance.Spec.Secret}, secret)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting the secret of github %q: %w", githubName, err)
+	}
+	webhookSecret := secret.Data[v1.GITHUB_SECRET_WEBHOOK_SECRET_KEY]
+	if len(webhookSecret) == 0 {
+		return nil, fmt.Errorf("secret of github %q has no %q key", githubName, v1.GITHUB_SECRET_WEBHOOK_SECRET_KEY)
+	}
+	return webhookSecret, nil
+}
+
+// affected returns the GithubOrganizations and GithubTeams of githubName the event
+// target is about.
+func (w *GithubWebhookReceiver) affected(ctx context.Context, githubName string, target webhookTarget) ([]client.Object, []client.Object, error) {
+	var organizations, teams []client.Object
+	if target.organizationLevel {
+		orgList := &v1.GithubOrganizationList{}
+		if err := w.List(ctx, orgList); err != nil {
+			return nil, nil, err
+		}
+		for i := range orgList.Items {
+			org := &orgList.Items[i]
+			if org.Spec.Github == githubName && strings.EqualFold(org.Spec.Organization, target.organization) {
+				organizations = append(organizations, org)
+			}
+		}
+	}
+	if target.team != nil {
+		teamList := &v1.GithubTeamList{}
+		if err := w.List(ctx, teamList); err != nil {
+			return nil, nil, err
+		}
+		for i := range teamList.Items {
+			team := &teamList.Items[i]
+			if team.Spec.Github != githubName || !strings.EqualFold(team.Spec.Organization, target.organization) {
+				continue
+			}
+			if team.Spec.Team == target.team.GetName() || slug.Make(team.Spec.Team) == target.team.GetSlug() {
+				teams = append(teams, team)
+			}
+		}
+	}
+	return organizations, teams, nil
+}
@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+func TestGithubWebhookReceiver(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	previousNamespace := OperatorNamespace
+	OperatorNamespace = "repo-guard"
+	t.Cleanup(func() { OperatorNamespace = previousNamespace })
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1.Github{ObjectMeta: metav1.ObjectMeta{Name: "com"}, Spec: v1.GithubSpec{Secret: "com-secret"}},
+		&v1.Github{ObjectMeta: metav1.ObjectMeta{Name: "nosecret"}, Spec: v1.GithubSpec{Secret: "nosecret-secret"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "repo-guard", Name: "com-secret"}, Data: map[string][]byte{v1.GITHUB_SECRET_WEBHOOK_SECRET_KEY: []byte("s3cret")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "repo-guard", Name: "nosecret-secret"}},
+		&v1.GithubOrganization{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme"}, Spec: v1.GithubOrganizationSpec{Github: "com", Organization: "acme"}},
+		&v1.GithubOrganization{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "ghe--acme"}, Spec: v1.GithubOrganizationSpec{Github: "ghe", Organization: "acme"}},
+		&v1.GithubTeam{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme--platform-admins"}, Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "Platform Admins"}},
+		&v1.GithubTeam{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme--other"}, Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "other"}},
+	).Build()
+	receiver := NewGithubWebhookReceiver(c)
+
+	sign := func(secret, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	received := func(ch <-chan event.GenericEvent) []string {
+		var names []string
+		for {
+			select {
+			case ev := <-ch:
+				names = append(names, ev.Object.GetName())
+			case <-time.After(100 * time.Millisecond):
+				sort.Strings(names)
+				return names
+			}
+		}
+	}
+
+	membership := `{"action":"added","team":{"name":"Platform Admins","slug":"platform-admins"},"organization":{"login":"ACME"},"member":{"login":"mallory"}}`
+	teamAdd := `{"team":{"name":"other","slug":"other"},"repository":{"name":"repo"},"organization":{"login":"acme"}}`
+	member := `{"action":"added","member":{"login":"mallory"},"repository":{"name":"repo","owner":{"login":"acme"}}}`
+	tests := []struct {
+		name      string
+		github    string
+		event     string
+		body      string
+		signature string
+		wantCode  int
+		wantOrgs  []string
+		wantTeams []string
+	}{
+		{name: "membership", github: "com", event: "membership", body: membership, signature: sign("s3cret", membership),
+			wantCode: http.StatusAccepted, wantTeams: []string{"com--acme--platform-admins"}},
+		{name: "team_add", github: "com", event: "team_add", body: teamAdd, signature: sign("s3cret", teamAdd),
+			wantCode: http.StatusAccepted, wantOrgs: []string{"com--acme"}, wantTeams: []string{"com--acme--other"}},
+		{name: "member without organization", github: "com", event: "member", body: member, signature: sign("s3cret", member),
+			wantCode: http.StatusAccepted, wantOrgs: []string{"com--acme"}},
+		{name: "ignored event", github: "com", event: "star", body: `{"action":"created"}`, signature: sign("s3cret", `{"action":"created"}`),
+			wantCode: http.StatusOK},
+		{name: "invalid signature", github: "com", event: "membership", body: membership, signature: sign("wrong", membership),
+			wantCode: http.StatusUnauthorized},
+		{name: "missing signature", github: "com", event: "membership", body: membership,
+			wantCode: http.StatusUnauthorized},
+		{name: "unknown github", github: "unknown", event: "membership", body: membership, signature: sign("s3cret", membership),
+			wantCode: http.StatusNotFound},
+		{name: "github without webhook secret", github: "nosecret", event: "membership", body: membership, signature: sign("", membership),
+			wantCode: http.StatusNotFound},
+	}
+	rejected := ghmetrics.WebhookEventsTotal.WithLabelValues(webhookUnvalidatedLabel, webhookUnvalidatedLabel, "rejected")
+	rejectedBefore := testutil.ToFloat64(rejected)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, GITHUB_WEBHOOK_PATH_PREFIX+tt.github, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-GitHub-Event", tt.event)
+			req.Header.Set("X-GitHub-Delivery", "1")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			receiver.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status: got %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if got := received(receiver.OrganizationEvents()); !slices.Equal(got, tt.wantOrgs) {
+				t.Errorf("organizations: got %v, want %v", got, tt.wantOrgs)
+			}
+			if got := received(receiver.TeamEvents()); !slices.Equal(got, tt.wantTeams) {
+				t.Errorf("teams: got %v, want %v", got, tt.wantTeams)
+			}
+		})
+	}
+
+	// rejected deliveries are not labelled with the unauthenticated path and event header
+	if got := testutil.ToFloat64(rejected) - rejectedBefore; got != 4 {
+		t.Errorf("rejected deliveries: got %v, want 4", got)
+	}
+	if got := testutil.ToFloat64(ghmetrics.WebhookEventsTotal.WithLabelValues("com", "membership", "rejected")); got != 0 {
+		t.Errorf("expected no rejected deliveries labelled with the github, got %v", got)
+	}
+}
+
+func TestGithubWebhookReceiverQueueFull(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	previousNamespace := OperatorNamespace
+	OperatorNamespace = "repo-guard"
+	t.Cleanup(func() { OperatorNamespace = previousNamespace })
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1.Github{ObjectMeta: metav1.ObjectMeta{Name: "com"}, Spec: v1.GithubSpec{Secret: "com-secret"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "repo-guard", Name: "com-secret"}, Data: map[string][]byte{v1.GITHUB_SECRET_WEBHOOK_SECRET_KEY: []byte("s3cret")}},
+		&v1.GithubTeam{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme--sre"}, Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "sre"}},
+	).Build()
+	receiver := NewGithubWebhookReceiver(c)
+
+	body := `{"action":"added","team":{"name":"sre","slug":"sre"},"organization":{"login":"acme"},"member":{"login":"mallory"}}`
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(body))
+	deliver := func() int {
+		req := httptest.NewRequest(http.MethodPost, GITHUB_WEBHOOK_PATH_PREFIX+"com", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "membership")
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		rec := httptest.NewRecorder()
+		receiver.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// the controller has not started reading; deliveries do not block and are refused
+	// once the queue is full
+	for i := range webhookEventBuffer {
+		if code := deliver(); code != http.StatusAccepted {
+			t.Fatalf("delivery %d: got status %d, want %d", i, code, http.StatusAccepted)
+		}
+	}
+	if code := deliver(); code != http.StatusServiceUnavailable {
+		t.Fatalf("delivery to a full queue: got status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if got := len(receiver.TeamEvents()); got != webhookEventBuffer {
+		t.Errorf("queued events: got %d, want %d", got, webhookEventBuffer)
+	}
+
+	<-receiver.TeamEvents()
+	if code := deliver(); code != http.StatusAccepted {
+		t.Errorf("delivery after the controller read an event: got status %d, want %d", code, http.StatusAccepted)
+	}
+}
+
+func TestLabelGithubWebhookLeader(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	pod := func(namespace, name string, leader bool) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{"control-plane": "controller-manager"}}}
+		if leader {
+			p.Labels[GITHUB_WEBHOOK_LEADER_LABEL] = "true"
+		}
+		return p
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pod("repo-guard", "manager-a", false),
+		// a former leader whose container was restarted
+		pod("repo-guard", "manager-b", true),
+		pod("other", "manager-c", true),
+	).Build()
+
+	if err := LabelGithubWebhookLeader(t.Context(), c, "repo-guard", "manager-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{"repo-guard/manager-a": true, "repo-guard/manager-b": false, "other/manager-c": true} {
+		namespace, podName, _ := strings.Cut(name, "/")
+		got := &corev1.Pod{}
+		if err := c.Get(t.Context(), types.NamespacedName{Namespace: namespace, Name: podName}, got); err != nil {
+			t.Fatal(err)
+		}
+		if _, labelled := got.Labels[GITHUB_WEBHOOK_LEADER_LABEL]; labelled != want {
+			t.Errorf("%s: labelled %v, want %v", name, labelled, want)
+		}
+		if got.Labels["control-plane"] != "controller-manager" {
+			t.Errorf("%s: other labels were changed: %v", name, got.Labels)
+		}
+	}
+
+	if err := LabelGithubWebhookLeader(t.Context(), c, "repo-guard", "missing"); err == nil {
+		t.Error("expected an error for a missing pod")
+	}
+}
@@ -0,0 +1,501 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// EVENT_REASON_ACCESS_REQUEST_APPROVED is the reason of the event emitted when a
+// GithubAccessRequest is approved and its grant is created.
+const EVENT_REASON_ACCESS_REQUEST_APPROVED = "GithubAccessRequestApproved"
+
+// EVENT_REASON_ACCESS_REQUEST_DENIED is the reason of the event emitted when a
+// GithubAccessRequest is denied.
+const EVENT_REASON_ACCESS_REQUEST_DENIED = "GithubAccessRequestDenied"
+
+// EVENT_REASON_ACCESS_REQUEST_EXPIRED is the reason of the event emitted when the
+// access of a GithubAccessRequest ends.
+const EVENT_REASON_ACCESS_REQUEST_EXPIRED = "GithubAccessRequestExpired"
+
+// EVENT_REASON_ACCESS_REQUEST_REVOKED is the reason of the event emitted when the spec
+// of an approved GithubAccessRequest changes and its access is removed.
+const EVENT_REASON_ACCESS_REQUEST_REVOKED = "GithubAccessRequestRevoked"
+
+// GithubAccessRequestReconciler approves or denies GithubAccessRequests and grants the
+// approved access until it expires: the membership of a team with a
+// GithubTeamMembershipGrant, a permission on repositories with a GithubTeamRepository.
+type GithubAccessRequestReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+	// UsernamePrefix prefixes the Greenhouse IDs of the members of approvers teams to
+	// their Kubernetes user names, e.g. the username prefix of the OIDC authenticator.
+	UsernamePrefix string
+}
+
+// +kubebuilder:rbac:groups=repo-guard.cloudoperators.dev,resources=githubaccessrequests,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=repo-guard.cloudoperators.dev,resources=githubaccessrequests/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=repo-guard.cloudoperators.dev,resources=githubaccessrequests/finalizers,verbs=update
+
+func (r *GithubAccessRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	l := log.FromContext(ctx)
+	done := ghmetrics.StartReconcileTimer("GithubAccessRequest")
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
+		} else if res.RequeueAfter > 0 {
+			result = "requeue"
+		}
+		done(result)
+	}()
+
+	accessRequest := &v1.GithubAccessRequest{}
+	if err = r.Get(ctx, req.NamespacedName, accessRequest); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !accessRequest.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	status := accessRequest.Status.DeepCopy()
+	res, err = r.reconcileAccessRequest(ctx, accessRequest, status, metav1.Now())
+	if reflect.DeepEqual(&accessRequest.Status, status) {
+		return res, err
+	}
+	uerr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubAccessRequest{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status = *status
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if uerr != nil {
+		l.Error(uerr, "error during status update")
+		return reconcile.Result{}, uerr
+	}
+	return res, err
+}
+
+// reconcileAccessRequest moves the request through its states and records them in
+// status.
+func (r *GithubAccessRequestReconciler) reconcileAccessRequest(ctx context.Context, accessRequest *v1.GithubAccessRequest, status *v1.GithubAccessRequestStatus, now metav1.Time) (reconcile.Result, error) {
+	l := log.FromContext(ctx)
+	switch status.State {
+	case v1.GithubAccessRequestStateDenied, v1.GithubAccessRequestStateExpired, v1.GithubAccessRequestStateRevoked:
+		return reconcile.Result{}, nil
+	case "":
+		// a decision is only taken on a request the approver could see: one created
+		// with a decision was not decided by an approver
+		if accessRequest.Annotations[v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY] != "" || accessRequest.Annotations[v1.GITHUB_ACCESS_REQUEST_ANNOTATION_DENIED_BY] != "" {
+			l.Info("access request is created with a decision: it is denied")
+			status.State = v1.GithubAccessRequestStateDenied
+			status.Error = "the request is created with a decision: create a new request without the approved-by and denied-by annotations"
+			r.observeDecision(accessRequest, v1.GithubAccessRequestStateDenied, EVENT_REASON_ACCESS_REQUEST_DENIED, "Deny",
+				"Access request of %s denied: it is created with a decision", accessRequest.Spec.Requester)
+			return reconcile.Result{}, nil
+		}
+	}
+
+	if status.ApprovedAt == nil {
+		if msg := accessRequestValidationError(accessRequest.Spec); msg != "" {
+			status.State = v1.GithubAccessRequestStateFailed
+			status.Error = msg
+			return reconcile.Result{}, nil
+		}
+		githubTeam := &v1.GithubTeam{}
+		if err := r.Get(ctx, types.NamespacedName{Name: accessRequest.Spec.GithubTeam, Namespace: accessRequest.Namespace}, githubTeam); err != nil {
+			if !errors.IsNotFound(err) {
+				return reconcile.Result{}, err
+			}
+			status.State = v1.GithubAccessRequestStateFailed
+			status.Error = "GithubTeam " + accessRequest.Spec.GithubTeam + " not found"
+			return reconcile.Result{}, nil
+		}
+		if githubTeam.Spec.AccessRequestApprovers == nil {
+			status.State = v1.GithubAccessRequestStateFailed
+			status.Error = "GithubTeam " + accessRequest.Spec.GithubTeam + " has no access request approvers"
+			return reconcile.Result{}, nil
+		}
+		if msg := accessRequestApproversError(*githubTeam.Spec.AccessRequestApprovers); msg != "" {
+			status.State = v1.GithubAccessRequestStateFailed
+			status.Error = "GithubTeam " + accessRequest.Spec.GithubTeam + ": " + msg
+			return reconcile.Result{}, nil
+		}
+		if accessRequest.Spec.Repository == nil {
+			if msg := membershipGrantTeamError(githubTeam); msg != "" {
+				status.State = v1.GithubAccessRequestStateFailed
+				status.Error = msg
+				return reconcile.Result{}, nil
+			}
+		}
+		status.State = v1.GithubAccessRequestStatePending
+		status.Error = ""
+
+		if approver := accessRequest.Annotations[v1.GITHUB_ACCESS_REQUEST_ANNOTATION_DENIED_BY]; approver != "" {
+			ok, _, err := r.isApprover(ctx, githubTeam, approver)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if !ok {
+				status.Error = approver + " is not an approver of the request"
+				return reconcile.Result{}, nil
+			}
+			l.Info("access request is denied", "deniedBy", approver)
+			status.State = v1.GithubAccessRequestStateDenied
+			status.DeniedBy = approver
+			r.observeDecision(accessRequest, v1.GithubAccessRequestStateDenied, EVENT_REASON_ACCESS_REQUEST_DENIED, "Deny",
+				"Access request of %s denied by %s", accessRequest.Spec.Requester, approver)
+			return reconcile.Result{}, nil
+		}
+
+		approver := accessRequest.Annotations[v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY]
+		if approver == "" {
+			return reconcile.Result{}, nil
+		}
+		requester, err := r.requesterIdentities(ctx, accessRequest, githubTeam)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if requester.Has(strings.ToLower(approver)) {
+			status.Error = "the requester cannot approve the request"
+			return reconcile.Result{}, nil
+		}
+		ok, member, err := r.isApprover(ctx, githubTeam, approver)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !ok {
+			status.Error = approver + " is not an approver of the request"
+			return reconcile.Result{}, nil
+		}
+		// the requester cannot approve as a member with another of their names
+		if requester.HasAny(strings.ToLower(member.GreenhouseID), strings.ToLower(member.GithubUsername)) {
+			status.Error = "the requester cannot approve the request"
+			return reconcile.Result{}, nil
+		}
+		l.Info("access request is approved", "approvedBy", approver, "duration", accessRequest.Spec.Duration.Duration)
+		expiresAt := metav1.NewTime(now.Add(accessRequest.Spec.Duration.Duration))
+		status.ApprovedBy = approver
+		status.ApprovedAt = &now
+		status.ApprovedGeneration = accessRequest.Generation
+		status.ExpiresAt = &expiresAt
+		r.observeDecision(accessRequest, "approved", EVENT_REASON_ACCESS_REQUEST_APPROVED, "Approve",
+			"Access request of %s approved by %s until %s", accessRequest.Spec.Requester, approver, expiresAt.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+
+	if accessRequest.Generation != status.ApprovedGeneration {
+		if err := r.removeGrant(ctx, accessRequest); err != nil {
+			return reconcile.Result{}, err
+		}
+		l.Info("spec of the approved access request changed: access is revoked")
+		status.State = v1.GithubAccessRequestStateRevoked
+		status.Error = "spec changed after the approval"
+		r.observeDecision(accessRequest, v1.GithubAccessRequestStateRevoked, EVENT_REASON_ACCESS_REQUEST_REVOKED, "Revoke",
+			"Access of %s revoked: spec changed after the approval", accessRequest.Spec.Requester)
+		return reconcile.Result{}, nil
+	}
+	if !now.Before(status.ExpiresAt) {
+		if err := r.removeGrant(ctx, accessRequest); err != nil {
+			return reconcile.Result{}, err
+		}
+		l.Info("access request is expired")
+		status.State = v1.GithubAccessRequestStateExpired
+		status.Error = ""
+		r.observeDecision(accessRequest, v1.GithubAccessRequestStateExpired, EVENT_REASON_ACCESS_REQUEST_EXPIRED, "Expire",
+			"Access of %s expired", accessRequest.Spec.Requester)
+		return reconcile.Result{}, nil
+	}
+
+	if msg, err := r.ensureGrant(ctx, accessRequest, *status.ExpiresAt); err != nil {
+		l.Error(err, "error during creating the grant of the access request")
+		status.Error = "error during creating the grant: " + err.Error()
+		return reconcile.Result{}, err
+	} else if msg != "" {
+		status.Error = msg
+	} else {
+		status.Error = ""
+	}
+	status.State = v1.GithubAccessRequestStateActive
+	status.Grant = accessRequest.Name
+	return reconcile.Result{RequeueAfter: status.ExpiresAt.Sub(now.Time)}, nil
+}
+
+// accessRequestValidationError returns why spec cannot be approved, or "".
+func accessRequestValidationError(spec v1.GithubAccessRequestSpec) string {
+	switch {
+	case spec.Requester == "":
+		return "requester not provided"
+	case spec.GithubTeam == "":
+		return "githubTeam not provided"
+	case spec.Justification == "":
+		return "justification not provided"
+	case spec.Duration.Duration <= 0:
+		return "duration must be positive"
+	}
+	if repository := spec.Repository; repository != nil {
+		switch {
+		case len(repository.Repositories) == 0:
+			return "repository repositories not provided"
+		case repository.Permission == "":
+			return "repository permission not provided"
+		}
+	}
+	return ""
+}
+
+// accessRequestApproversError returns why approvers cannot approve requests, or "".
+func accessRequestApproversError(approvers v1.AccessRequestApprovers) string {
+	if approvers.GithubTeam == "" && len(approvers.Subjects) == 0 {
+		return "access request approvers not provided"
+	}
+	for _, subject := range approvers.Subjects {
+		if subject.Kind != rbacv1.UserKind && subject.Kind != rbacv1.ServiceAccountKind {
+			return "approver subjects must be of kind User or ServiceAccount"
+		}
+	}
+	return ""
+}
+
+// isApprover reports whether the Kubernetes user approver is one of the access request
+// approvers of githubTeam. Members of the approvers team are the users named
+// UsernamePrefix and their Greenhouse ID; member is the one that matched.
+func (r *GithubAccessRequestReconciler) isApprover(ctx context.Context, githubTeam *v1.GithubTeam, approver string) (ok bool, member v1.Member, err error) {
+	approvers := githubTeam.Spec.AccessRequestApprovers
+	if subjectsContain(approvers.Subjects, githubTeam.Namespace, approver) {
+		return true, v1.Member{}, nil
+	}
+	if approvers.GithubTeam == "" {
+		return false, v1.Member{}, nil
+	}
+	approversTeam := &v1.GithubTeam{}
+	if err := r.Get(ctx, types.NamespacedName{Name: approvers.GithubTeam, Namespace: githubTeam.Namespace}, approversTeam); err != nil {
+		if errors.IsNotFound(err) {
+			return false, v1.Member{}, nil
+		}
+		return false, v1.Member{}, err
+	}
+	member, ok = teamMember(approversTeam.Status.Members, r.UsernamePrefix, approver)
+	return ok, member, nil
+}
+
+// requesterIdentities returns the lower-cased names of the requester of accessRequest:
+// the requester, their Kubernetes user, and the Greenhouse ID, Kubernetes user and
+// Github login of the GithubAccountLinks of the requester on the Github of githubTeam.
+// The requester cannot approve with any of them.
+func (r *GithubAccessRequestReconciler) requesterIdentities(ctx context.Context, accessRequest *v1.GithubAccessRequest, githubTeam *v1.GithubTeam) (sets.Set[string], error) {
+	requester := accessRequest.Spec.Requester
+	identities := sets.New(strings.ToLower(requester), strings.ToLower(r.UsernamePrefix+requester))
+	links := &v1.GithubAccountLinkList{}
+	if err := r.List(ctx, links); err != nil {
+		return nil, err
+	}
+	for _, link := range links.Items {
+		if link.Spec.Github != githubTeam.Spec.Github {
+			continue
+		}
+		if !strings.EqualFold(link.Spec.GreenhouseUserID, requester) && !strings.EqualFold(link.Status.Login, requester) {
+			continue
+		}
+		for _, name := range []string{link.Spec.GreenhouseUserID, r.UsernamePrefix + link.Spec.GreenhouseUserID, link.Status.Login} {
+			if name != "" && name != r.UsernamePrefix {
+				identities.Insert(strings.ToLower(name))
+			}
+		}
+	}
+	return identities, nil
+}
+
+// subjectsContain reports whether name is the user or service account of one of
+// subjects. Service accounts are named system:serviceaccount:<namespace>:<name>, the
+// namespace defaults to namespace.
+func subjectsContain(subjects []rbacv1.Subject, namespace, name string) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.UserKind:
+			if subject.Name == name {
+				return true
+			}
+		case rbacv1.ServiceAccountKind:
+			ns := subject.Namespace
+			if ns == "" {
+				ns = namespace
+			}
+			if "system:serviceaccount:"+ns+":"+subject.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// teamMember returns the member whose Kubernetes user name is username: prefix and
+// the Greenhouse ID. The Github login is not an identity of the cluster.
+func teamMember(members []v1.Member, prefix, username string) (v1.Member, bool) {
+	for _, member := range members {
+		if member.GreenhouseID != "" && prefix+member.GreenhouseID == username {
+			return member, true
+		}
+	}
+	return v1.Member{}, false
+}
+
+// ensureGrant creates or updates the grant of the approved accessRequest, named like
+// the request. msg reports a grant that cannot be created.
+func (r *GithubAccessRequestReconciler) ensureGrant(ctx context.Context, accessRequest *v1.GithubAccessRequest, expiresAt metav1.Time) (msg string, err error) {
+	spec := accessRequest.Spec
+	key := types.NamespacedName{Name: accessRequest.Name, Namespace: accessRequest.Namespace}
+	var grant client.Object
+	var mutate func()
+	if spec.Repository == nil {
+		membershipGrant := &v1.GithubTeamMembershipGrant{}
+		grant = membershipGrant
+		mutate = func() {
+			membershipGrant.Spec = v1.GithubTeamMembershipGrantSpec{GithubTeam: spec.GithubTeam, User: spec.Requester, ExpiresAt: expiresAt, Reason: spec.Justification}
+		}
+	} else {
+		githubTeam := &v1.GithubTeam{}
+		if err := r.Get(ctx, types.NamespacedName{Name: spec.GithubTeam, Namespace: accessRequest.Namespace}, githubTeam); err != nil {
+			if errors.IsNotFound(err) {
+				return "GithubTeam " + spec.GithubTeam + " not found", nil
+			}
+			return "", err
+		}
+		teamRepository := &v1.GithubTeamRepository{}
+		grant = teamRepository
+		mutate = func() {
+			teamRepository.Spec = v1.GithubTeamRepositorySpec{
+				Github:         githubTeam.Spec.Github,
+				Organization:   githubTeam.Spec.Organization,
+				Team:           githubTeam.Spec.Team,
+				Repository:     spec.Repository.Repositories,
+				Permission:     spec.Repository.Permission,
+				DeletionPolicy: v1.DeletionPolicyDelete,
+			}
+		}
+	}
+	kind := reflect.TypeOf(grant).Elem().Name()
+
+	if err := r.Get(ctx, key, grant); err != nil {
+		if !errors.IsNotFound(err) {
+			return "", err
+		}
+		grant.SetName(key.Name)
+		grant.SetNamespace(key.Namespace)
+		mutate()
+		if err := controllerutil.SetControllerReference(accessRequest, grant, r.Client.Scheme()); err != nil {
+			return "", err
+		}
+		if err := r.Create(ctx, grant); err != nil {
+			return "", err
+		}
+		log.FromContext(ctx).Info("grant of the access request is created", "kind", kind, "name", key.Name)
+		return "", nil
+	}
+	if !metav1.IsControlledBy(grant, accessRequest) {
+		return fmt.Sprintf("%s %s exists and is not owned by the request", kind, key.Name), nil
+	}
+	before := grant.DeepCopyObject()
+	mutate()
+	if reflect.DeepEqual(before, grant) {
+		return "", nil
+	}
+	return "", r.Update(ctx, grant)
+}
+
+// removeGrant deletes the grant of accessRequest. Both kinds are looked up since the
+// spec may have changed after the approval. The GithubTeamRepository of a repository
+// request removes the access of the team in Github on its deletion.
+func (r *GithubAccessRequestReconciler) removeGrant(ctx context.Context, accessRequest *v1.GithubAccessRequest) error {
+	for _, grant := range []client.Object{&v1.GithubTeamMembershipGrant{}, &v1.GithubTeamRepository{}} {
+		if err := r.Get(ctx, types.NamespacedName{Name: accessRequest.Name, Namespace: accessRequest.Namespace}, grant); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !metav1.IsControlledBy(grant, accessRequest) {
+			continue
+		}
+		if err := r.Delete(ctx, grant); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// observeDecision counts decision and records an event on accessRequest.
+func (r *GithubAccessRequestReconciler) observeDecision(accessRequest *v1.GithubAccessRequest, decision, reason, action, note string, args ...interface{}) {
+	ghmetrics.AccessRequestDecisionsTotal.WithLabelValues(accessRequest.Namespace, decision).Inc()
+	if r.Recorder != nil {
+		r.Recorder.Eventf(accessRequest, nil, corev1.EventTypeNormal, reason, action, note, args...)
+	}
+}
+
+// githubTeamToAccessRequests enqueues the GithubAccessRequests for a GithubTeam and for
+// the teams whose approvers team it is.
+func (r *GithubAccessRequestReconciler) githubTeamToAccessRequests(ctx context.Context, o client.Object) []reconcile.Request {
+	teamList := &v1.GithubTeamList{}
+	if err := r.List(ctx, teamList, client.InNamespace(o.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list GithubTeams")
+		return nil
+	}
+	teams := sets.New(o.GetName())
+	for _, githubTeam := range teamList.Items {
+		if approvers := githubTeam.Spec.AccessRequestApprovers; approvers != nil && approvers.GithubTeam == o.GetName() {
+			teams.Insert(githubTeam.Name)
+		}
+	}
+	requestList := &v1.GithubAccessRequestList{}
+	if err := r.List(ctx, requestList, client.InNamespace(o.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list GithubAccessRequests")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, accessRequest := range requestList.Items {
+		if accessRequest.Status.ApprovedAt != nil {
+			continue
+		}
+		if teams.Has(accessRequest.Spec.GithubTeam) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: accessRequest.Namespace, Name: accessRequest.Name}})
+		}
+	}
+	return requests
+}
+
+func (r *GithubAccessRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.GithubAccessRequest{}).
+		Owns(&v1.GithubTeamMembershipGrant{}).
+		Owns(&v1.GithubTeamRepository{}).
+		Watches(&v1.GithubTeam{}, handler.EnqueueRequestsFromMapFunc(r.githubTeamToAccessRequests)).
+		Complete(r)
+}
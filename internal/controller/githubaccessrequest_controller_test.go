@@ -0,0 +1,373 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func newAccessRequestTestClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	sre := &v1.GithubTeam{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "sre"},
+		Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "SRE", GreenhouseTeam: "sre",
+			AccessRequestApprovers: &v1.AccessRequestApprovers{
+				GithubTeam: "leads",
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "carol@example.com"}},
+			},
+		},
+	}
+	leads := &v1.GithubTeam{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "leads"},
+		Spec:       v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "leads", GreenhouseTeam: "leads"},
+		Status: v1.GithubTeamStatus{Members: []v1.Member{
+			{GreenhouseID: "I100", GithubUsername: "alice"},
+			{GreenhouseID: "I200", GithubUsername: "bob"},
+		}},
+	}
+	objs = append(objs, sre, leads)
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithStatusSubresource(&v1.GithubAccessRequest{}, &v1.GithubTeam{}).Build()
+}
+
+func accessRequest(name string, annotations map[string]string) *v1.GithubAccessRequest {
+	return &v1.GithubAccessRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, Annotations: annotations},
+		Spec: v1.GithubAccessRequestSpec{
+			Requester:     "I200",
+			GithubTeam:    "sre",
+			Justification: "incident 42",
+			Duration:      metav1.Duration{Duration: 8 * time.Hour},
+		},
+	}
+}
+
+func reconcileAccessRequest(t *testing.T, c client.Client, accessRequest *v1.GithubAccessRequest) (ctrl.Result, *v1.GithubAccessRequest) {
+	t.Helper()
+	r := &GithubAccessRequestReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(accessRequest)}
+	res, err := r.Reconcile(t.Context(), req)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	latest := &v1.GithubAccessRequest{}
+	if err := c.Get(t.Context(), req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	return res, latest
+}
+
+func TestAccessRequestValidationError(t *testing.T) {
+	valid := accessRequest("valid", nil).Spec
+	if msg := accessRequestValidationError(valid); msg != "" {
+		t.Fatalf("expected a valid spec, got %q", msg)
+	}
+	for _, tc := range []struct {
+		mutate func(*v1.GithubAccessRequestSpec)
+		want   string
+	}{
+		{func(s *v1.GithubAccessRequestSpec) { s.Justification = "" }, "justification not provided"},
+		{func(s *v1.GithubAccessRequestSpec) { s.Duration = metav1.Duration{} }, "duration must be positive"},
+		{func(s *v1.GithubAccessRequestSpec) {
+			s.Repository = &v1.RepositoryAccess{Repositories: []string{"app"}}
+		}, "repository permission not provided"},
+	} {
+		spec := *valid.DeepCopy()
+		tc.mutate(&spec)
+		if msg := accessRequestValidationError(spec); msg != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, msg)
+		}
+	}
+}
+
+func TestAccessRequestApproversError(t *testing.T) {
+	for _, tc := range []struct {
+		approvers v1.AccessRequestApprovers
+		want      string
+	}{
+		{approvers: v1.AccessRequestApprovers{GithubTeam: "leads"}},
+		{approvers: v1.AccessRequestApprovers{}, want: "access request approvers not provided"},
+		{
+			approvers: v1.AccessRequestApprovers{Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "admins"}}},
+			want:      "approver subjects must be of kind User or ServiceAccount",
+		},
+	} {
+		if msg := accessRequestApproversError(tc.approvers); msg != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, msg)
+		}
+	}
+}
+
+func TestSubjectsContain(t *testing.T) {
+	subjects := []rbacv1.Subject{
+		{Kind: rbacv1.UserKind, Name: "carol@example.com"},
+		{Kind: rbacv1.ServiceAccountKind, Name: "approver"},
+		{Kind: rbacv1.ServiceAccountKind, Name: "bot", Namespace: "ops"},
+	}
+	for name, want := range map[string]bool{
+		"carol@example.com":                    true,
+		"system:serviceaccount:ns:approver":    true,
+		"system:serviceaccount:ops:bot":        true,
+		"system:serviceaccount:other:bot":      false,
+		"dave@example.com":                     false,
+		"system:serviceaccount:ops:approver":   false,
+		"system:serviceaccount:ns:nonexisting": false,
+	} {
+		if got := subjectsContain(subjects, "ns", name); got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+// decideAccessRequest sets the decision annotation of the pending accessRequest to
+// username, like the approver does.
+func decideAccessRequest(t *testing.T, c client.Client, accessRequest *v1.GithubAccessRequest, annotation, username string) {
+	t.Helper()
+	latest := &v1.GithubAccessRequest{}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(accessRequest), latest); err != nil {
+		t.Fatal(err)
+	}
+	if latest.Status.State != v1.GithubAccessRequestStatePending {
+		t.Fatalf("%s: expected the request to be pending before the decision, got %+v", accessRequest.Name, latest.Status)
+	}
+	latest.Annotations = map[string]string{annotation: username}
+	if err := c.Update(t.Context(), latest); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGithubAccessRequestApproval(t *testing.T) {
+	pending := accessRequest("pending", nil)
+	selfApproved := accessRequest("self-approved", nil)
+	outsider := accessRequest("outsider", nil)
+	githubLogin := accessRequest("github-login", nil)
+	denied := accessRequest("denied", nil)
+	approved := accessRequest("approved", nil)
+	c := newAccessRequestTestClient(t, pending, selfApproved, outsider, githubLogin, denied, approved)
+
+	for _, tc := range []struct {
+		accessRequest *v1.GithubAccessRequest
+		annotation    string
+		username      string
+		state         string
+		err           string
+	}{
+		{accessRequest: pending, state: v1.GithubAccessRequestStatePending},
+		{accessRequest: selfApproved, annotation: v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY, username: "I200", state: v1.GithubAccessRequestStatePending, err: "the requester cannot approve the request"},
+		{accessRequest: outsider, annotation: v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY, username: "mallory", state: v1.GithubAccessRequestStatePending, err: "mallory is not an approver of the request"},
+		// the Github login of a member is not a Kubernetes identity
+		{accessRequest: githubLogin, annotation: v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY, username: "alice", state: v1.GithubAccessRequestStatePending, err: "alice is not an approver of the request"},
+		{accessRequest: denied, annotation: v1.GITHUB_ACCESS_REQUEST_ANNOTATION_DENIED_BY, username: "carol@example.com", state: v1.GithubAccessRequestStateDenied},
+		{accessRequest: approved, annotation: v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY, username: "I100", state: v1.GithubAccessRequestStateActive},
+	} {
+		_, latest := reconcileAccessRequest(t, c, tc.accessRequest)
+		if tc.annotation != "" {
+			decideAccessRequest(t, c, tc.accessRequest, tc.annotation, tc.username)
+			_, latest = reconcileAccessRequest(t, c, tc.accessRequest)
+		}
+		if string(latest.Status.State) != tc.state || latest.Status.Error != tc.err {
+			t.Errorf("%s: unexpected status %+v", tc.accessRequest.Name, latest.Status)
+		}
+	}
+
+	grant := &v1.GithubTeamMembershipGrant{}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(approved), grant); err != nil {
+		t.Fatalf("expected the membership grant of the approved request: %v", err)
+	}
+	_, latest := reconcileAccessRequest(t, c, approved)
+	if grant.Spec.User != "I200" || grant.Spec.GithubTeam != "sre" || !grant.Spec.ExpiresAt.Equal(latest.Status.ExpiresAt) {
+		t.Errorf("unexpected grant %+v", grant.Spec)
+	}
+	if !metav1.IsControlledBy(grant, latest) {
+		t.Error("expected the grant to be owned by the request")
+	}
+	if latest.Status.ApprovedBy != "I100" || latest.Status.Grant != "approved" {
+		t.Errorf("unexpected status %+v", latest.Status)
+	}
+	for _, name := range []string{"pending", "github-login", "denied"} {
+		if err := c.Get(t.Context(), client.ObjectKey{Namespace: "ns", Name: name}, &v1.GithubTeamMembershipGrant{}); !errors.IsNotFound(err) {
+			t.Errorf("%s: expected no grant, got %v", name, err)
+		}
+	}
+}
+
+func TestGithubAccessRequestSelfApproval(t *testing.T) {
+	// mallory is an approver of the team in the cluster and requests access for her
+	// Github login, which her GithubAccountLink maps to her Greenhouse ID
+	link := &v1.GithubAccountLink{
+		ObjectMeta: metav1.ObjectMeta{Name: "mallory"},
+		Spec:       v1.GithubAccountLinkSpec{GreenhouseUserID: "mallory", GithubUserID: "42", Github: "com"},
+		Status:     v1.GithubAccountLinkStatus{Login: "mallory-gh"},
+	}
+	selfApproved := accessRequest("self-approved", nil)
+	selfApproved.Spec.Requester = "mallory-gh"
+	c := newAccessRequestTestClient(t, link, selfApproved)
+	sre := &v1.GithubTeam{}
+	if err := c.Get(t.Context(), client.ObjectKey{Namespace: "ns", Name: "sre"}, sre); err != nil {
+		t.Fatal(err)
+	}
+	sre.Spec.AccessRequestApprovers.Subjects = append(sre.Spec.AccessRequestApprovers.Subjects, rbacv1.Subject{Kind: rbacv1.UserKind, Name: "mallory"})
+	if err := c.Update(t.Context(), sre); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileAccessRequest(t, c, selfApproved)
+	decideAccessRequest(t, c, selfApproved, v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY, "mallory")
+	_, latest := reconcileAccessRequest(t, c, selfApproved)
+	if latest.Status.State != v1.GithubAccessRequestStatePending || latest.Status.Error != "the requester cannot approve the request" {
+		t.Errorf("expected the self-approval to be refused, got %+v", latest.Status)
+	}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(selfApproved), &v1.GithubTeamMembershipGrant{}); !errors.IsNotFound(err) {
+		t.Errorf("expected no grant, got %v", err)
+	}
+}
+
+func TestGithubAccessRequestTeamWithoutApprovers(t *testing.T) {
+	pending := accessRequest("pending", nil)
+	pending.Spec.GithubTeam = "leads"
+	c := newAccessRequestTestClient(t, pending)
+
+	_, latest := reconcileAccessRequest(t, c, pending)
+	if latest.Status.State != v1.GithubAccessRequestStateFailed || latest.Status.Error != "GithubTeam leads has no access request approvers" {
+		t.Errorf("unexpected status %+v", latest.Status)
+	}
+}
+
+func TestGithubAccessRequestCreatedWithDecision(t *testing.T) {
+	approved := accessRequest("approved", map[string]string{v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY: "I100"})
+	c := newAccessRequestTestClient(t, approved)
+
+	_, latest := reconcileAccessRequest(t, c, approved)
+	if latest.Status.State != v1.GithubAccessRequestStateDenied || latest.Status.ApprovedAt != nil || latest.Status.Error == "" {
+		t.Errorf("expected the request created with an approval to be denied, got %+v", latest.Status)
+	}
+	// the denial is final
+	_, latest = reconcileAccessRequest(t, c, approved)
+	if latest.Status.State != v1.GithubAccessRequestStateDenied {
+		t.Errorf("expected the request to stay denied, got %+v", latest.Status)
+	}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(approved), &v1.GithubTeamMembershipGrant{}); !errors.IsNotFound(err) {
+		t.Errorf("expected no grant, got %v", err)
+	}
+}
+
+func TestGithubAccessRequestUsernamePrefix(t *testing.T) {
+	for _, tc := range []struct {
+		username string
+		state    string
+		err      string
+	}{
+		{username: "oidc:I100", state: v1.GithubAccessRequestStateActive},
+		{username: "I100", state: v1.GithubAccessRequestStatePending, err: "I100 is not an approver of the request"},
+		{username: "oidc:I200", state: v1.GithubAccessRequestStatePending, err: "the requester cannot approve the request"},
+	} {
+		pending := accessRequest("pending", nil)
+		c := newAccessRequestTestClient(t, pending)
+		r := &GithubAccessRequestReconciler{Client: c, UsernamePrefix: "oidc:"}
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pending)}
+		if _, err := r.Reconcile(t.Context(), req); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		decideAccessRequest(t, c, pending, v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY, tc.username)
+		if _, err := r.Reconcile(t.Context(), req); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		latest := &v1.GithubAccessRequest{}
+		if err := c.Get(t.Context(), req.NamespacedName, latest); err != nil {
+			t.Fatal(err)
+		}
+		if string(latest.Status.State) != tc.state || latest.Status.Error != tc.err {
+			t.Errorf("%s: unexpected status %+v", tc.username, latest.Status)
+		}
+	}
+}
+
+func TestGithubAccessRequestRepositoryExpiry(t *testing.T) {
+	repositoryRequest := accessRequest("deploy", nil)
+	repositoryRequest.Spec.Repository = &v1.RepositoryAccess{Repositories: []string{"app"}, Permission: v1.GithubTeamPermissionPush}
+	c := newAccessRequestTestClient(t, repositoryRequest)
+	reconcileAccessRequest(t, c, repositoryRequest)
+	decideAccessRequest(t, c, repositoryRequest, v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY, "carol@example.com")
+
+	res, latest := reconcileAccessRequest(t, c, repositoryRequest)
+	if latest.Status.State != v1.GithubAccessRequestStateActive || res.RequeueAfter <= 0 {
+		t.Fatalf("expected the request to be active and requeued at expiry, got %+v %v", latest.Status, res)
+	}
+	teamRepository := &v1.GithubTeamRepository{}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(repositoryRequest), teamRepository); err != nil {
+		t.Fatalf("expected the GithubTeamRepository of the approved request: %v", err)
+	}
+	if teamRepository.Spec.Team != "SRE" || teamRepository.Spec.Organization != "acme" || teamRepository.Spec.Permission != v1.GithubTeamPermissionPush || teamRepository.Spec.DeletionPolicy != v1.DeletionPolicyDelete {
+		t.Errorf("unexpected GithubTeamRepository %+v", teamRepository.Spec)
+	}
+
+	// the access ends at expiry
+	expiresAt := metav1.NewTime(time.Now().Add(-time.Second))
+	latest.Status.ExpiresAt = &expiresAt
+	if err := c.Status().Update(t.Context(), latest); err != nil {
+		t.Fatal(err)
+	}
+	_, latest = reconcileAccessRequest(t, c, repositoryRequest)
+	if latest.Status.State != v1.GithubAccessRequestStateExpired {
+		t.Errorf("expected the request to be expired, got %+v", latest.Status)
+	}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(repositoryRequest), &v1.GithubTeamRepository{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the GithubTeamRepository to be deleted, got %v", err)
+	}
+}
+
+func TestGithubAccessRequestRevokedOnSpecChange(t *testing.T) {
+	approved := accessRequest("approved", nil)
+	c := newAccessRequestTestClient(t, approved)
+	reconcileAccessRequest(t, c, approved)
+	decideAccessRequest(t, c, approved, v1.GITHUB_ACCESS_REQUEST_ANNOTATION_APPROVED_BY, "I100")
+	_, latest := reconcileAccessRequest(t, c, approved)
+	if latest.Status.State != v1.GithubAccessRequestStateActive {
+		t.Fatalf("expected the request to be active, got %+v", latest.Status)
+	}
+
+	latest.Status.ApprovedGeneration = latest.Generation - 1
+	if err := c.Status().Update(t.Context(), latest); err != nil {
+		t.Fatal(err)
+	}
+	_, latest = reconcileAccessRequest(t, c, approved)
+	if latest.Status.State != v1.GithubAccessRequestStateRevoked {
+		t.Errorf("expected the request to be revoked, got %+v", latest.Status)
+	}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(approved), &v1.GithubTeamMembershipGrant{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the grant to be deleted, got %v", err)
+	}
+}
+
+func TestGithubTeamToAccessRequests(t *testing.T) {
+	pending := accessRequest("pending", nil)
+	c := newAccessRequestTestClient(t, pending)
+	r := &GithubAccessRequestReconciler{Client: c}
+
+	// a change of the approvers team enqueues the requests for the teams it approves
+	for _, name := range []string{"sre", "leads"} {
+		requests := r.githubTeamToAccessRequests(t.Context(), &v1.GithubTeam{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}})
+		if len(requests) != 1 || requests[0].Name != "pending" {
+			t.Errorf("%s: unexpected requests %v", name, requests)
+		}
+	}
+	if requests := r.githubTeamToAccessRequests(t.Context(), &v1.GithubTeam{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other"}}); len(requests) != 0 {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
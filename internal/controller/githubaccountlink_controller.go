@@ -6,13 +6,18 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	v1 "github.com/cloudoperators/repo-guard/api/v1"
 	"github.com/cloudoperators/repo-guard/internal/github"
 	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+	"github.com/palantir/go-githubapp/githubapp"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
@@ -53,217 +58,351 @@ func (r *GithubAccountLinkReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return reconcile.Result{}, err
 	}
 
-	// Determine which checks to run
-	needEmailDomainCheck := false
-	hasMultiOrgConfig := false
-	var multiOrgConfig map[string]struct {
-		Domain  string `json:"domain"`
-		Enabled bool   `json:"enabled"`
-		TTL     string `json:"ttl"`
-	}
-	if githubAccountLink.Annotations != nil {
-		if raw, ok := githubAccountLink.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG]; ok && raw != "" {
-			tmp := make(map[string]struct {
-				Domain  string `json:"domain"`
-				Enabled bool   `json:"enabled"`
-				TTL     string `json:"ttl"`
-			})
-			if err := json.Unmarshal([]byte(raw), &tmp); err == nil {
-				if len(tmp) > 0 {
-					hasMultiOrgConfig = true
-					multiOrgConfig = tmp
-					// run checks only when at least one entry is enabled and has domain
-					for _, cfg := range multiOrgConfig {
-						if cfg.Enabled && cfg.Domain != "" {
-							needEmailDomainCheck = true
-							break
-						}
-					}
-				}
-			}
+	// Rewrite links still configured through the deprecated annotations
+	if _, ok := githubAccountLink.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG]; ok {
+		if err := r.migrateEmailCheckAnnotations(ctx, githubAccountLink.Name); err != nil {
+			l.Error(err, "error migrating email check annotations to spec.emailVerification")
+			return ctrl.Result{}, err
+		}
+		if err := r.Get(ctx, types.NamespacedName{Name: req.Name}, githubAccountLink); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
 		}
 	}
 
-	// If no checks are requested, do nothing
-	if !needEmailDomainCheck {
+	// If no checks are requested and no results are left to prune, do nothing
+	if len(githubAccountLink.Spec.EmailVerification) == 0 && len(githubAccountLink.Status.EmailVerification) == 0 {
 		return reconcile.Result{}, nil
 	}
 
-	// Track whether we updated anything, and what requeue-after to use
-	updated := false
-	var minRequeueAfter time.Duration
+	githubName := githubAccountLink.Spec.Github
+	orgs, err := r.emailCheckOrganizations(ctx, githubName)
+	if err != nil {
+		l.Error(err, "listing GithubOrganization to resolve org for email check")
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	previous := make(map[string]v1.EmailVerificationStatus)
+	for _, result := range githubAccountLink.Status.EmailVerification {
+		previous[result.Organization] = result
+	}
 
-	// Email domain verification with TTL (via GitHub API)
-	if hasMultiOrgConfig {
-		// Load existing results
-		type resultEntry struct {
-			Domain    string `json:"domain"`
-			Verified  bool   `json:"verified,omitzero"`
-			Status    string `json:"status"`
-			Timestamp string `json:"timestamp"`
+	results := make(map[string]v1.EmailVerificationStatus)
+	var minRequeueAfter time.Duration
+	var checkErr error
+	for _, cfg := range githubAccountLink.Spec.EmailVerification {
+		org, hasOrg := orgs[cfg.Organization]
+		prev, hasPrev := previous[cfg.Organization]
+		due, next := emailVerificationDue(cfg, prev, hasPrev, now)
+		if next > 0 && (minRequeueAfter == 0 || next < minRequeueAfter) {
+			// also for batch-checked entries, so they are picked up again if batch mode is disabled
+			minRequeueAfter = next
 		}
-		results := make(map[string]resultEntry)
-		if githubAccountLink.Annotations != nil {
-			if raw, ok := githubAccountLink.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS]; ok && raw != "" {
-				_ = json.Unmarshal([]byte(raw), &results)
+		if hasOrg && batchEmailVerificationEnabled(&org) {
+			// checked by GithubOrganizationEmailVerificationReconciler
+			continue
+		}
+		if !due || checkErr != nil {
+			if hasPrev {
+				results[cfg.Organization] = prev
 			}
+			continue
 		}
 
-		// Iterate per org config
-		for orgName, cfg := range multiOrgConfig {
-			if !cfg.Enabled || cfg.Domain == "" {
-				continue
-			}
+		// Resolve installation for this org under the same Github instance
+		githubClient, okClient := GithubClients.Get(githubName)
+		if !okClient {
+			l.Info("waiting for github to be initialized", "github", githubName)
+			return reconcile.Result{}, nil
+		}
 
-			// TTL handling
-			needCheck := false
-			var ttlDur time.Duration
-			hasTTL := false
-			if cfg.TTL != "" {
-				if d, err := time.ParseDuration(cfg.TTL); err == nil {
-					ttlDur = d
-					hasTTL = true
-					if minRequeueAfter == 0 || d < minRequeueAfter {
-						minRequeueAfter = d
-					}
-				}
+		var status string
+		if org.ResolvedInstallationID() == 0 && !github.UsesTokenAuth(githubClient) {
+			l.Info("installation not resolved for email check; skipping GitHub call and marking as skipped",
+				"github", githubName, "org", cfg.Organization)
+			status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_SKIPPED
+			if minRequeueAfter == 0 || minRequeueAfter > 10*time.Second {
+				minRequeueAfter = 10 * time.Second
 			}
-			if prev, ok := results[orgName]; !ok || prev.Timestamp == "" {
-				needCheck = true
-			} else if hasTTL {
-				if ts, err := time.Parse(time.RFC3339, prev.Timestamp); err == nil {
-					if time.Now().After(ts.Add(ttlDur)) {
-						needCheck = true
-					}
-				} else {
-					needCheck = true
-				}
+		} else if resetAt, deferred := github.DeferLowPriority(githubClient, org.ResolvedInstallationID(), github.RateLimitResourceCore, now); deferred {
+			// keep the rate limit of the installation for team membership changes
+			l.Info("rate limit budget low; email check deferred", "github", githubName, "org", cfg.Organization, "until", resetAt)
+			if hasPrev {
+				results[cfg.Organization] = prev
 			}
-
-			if !needCheck {
+			if wait := resetAt.Sub(now); minRequeueAfter == 0 || wait < minRequeueAfter {
+				minRequeueAfter = wait
+			}
+			continue
+		} else {
+			status, err = checkVerifiedDomainEmail(ctx, githubClient, githubName, org.ResolvedInstallationID(), githubAccountLink.Spec.GithubUserID, cfg)
+			if err != nil {
+				// keep previous results and write what was checked so far
+				checkErr = err
+				if hasPrev {
+					results[cfg.Organization] = prev
+				}
 				continue
 			}
+		}
 
-			// Resolve installation for this org under the same Github instance
-			githubName := githubAccountLink.Spec.Github
-			githubClient, okClient := GithubClients[githubName]
-			if !okClient {
-				l.Info("waiting for github to be initialized", "github", githubName)
-				return reconcile.Result{RequeueAfter: time.Second}, nil
-			}
-			var orgList v1.GithubOrganizationList
-			if err := r.List(ctx, &orgList); err != nil {
-				l.Error(err, "listing GithubOrganization to resolve org for email check")
-				return ctrl.Result{}, err
-			}
-			var installationID int64
-			for _, goItem := range orgList.Items {
-				if goItem.Spec.Github == githubName && goItem.Spec.Organization == orgName {
-					installationID = goItem.Spec.InstallationID
-					break
-				}
+		results[cfg.Organization] = v1.EmailVerificationStatus{
+			Organization: cfg.Organization,
+			Domain:       cfg.Domain,
+			Status:       status,
+			LastChecked:  metav1.NewTime(now),
+		}
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubAccountLink{}
+		if err := r.Get(ctx, types.NamespacedName{Name: githubAccountLink.Name}, latest); err != nil {
+			return err
+		}
+		// results of batch-checked organizations are taken from the latest status
+		merged := make(map[string]v1.EmailVerificationStatus, len(results))
+		for _, result := range latest.Status.EmailVerification {
+			if org, ok := orgs[result.Organization]; ok && batchEmailVerificationEnabled(&org) {
+				merged[result.Organization] = result
 			}
+		}
+		for org, result := range results {
+			merged[org] = result
+		}
+		updated := orderedEmailVerificationResults(githubAccountLink.Spec.EmailVerification, merged)
+		if equality.Semantic.DeepEqual(updated, latest.Status.EmailVerification) {
+			return nil
+		}
+		latest.Status.EmailVerification = updated
+		return r.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		l.Error(err, "error updating GithubAccountLink status after checks")
+		return ctrl.Result{}, err
+	}
+	if checkErr != nil {
+		return ctrl.Result{}, checkErr
+	}
 
-			var status string
-			if installationID == 0 {
-				l.Info("installation not resolved for email check; skipping GitHub call and marking as skipped",
-					"github", githubName, "org", orgName)
-				status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_SKIPPED
-				if minRequeueAfter == 0 || minRequeueAfter > 10*time.Second {
-					minRequeueAfter = 10 * time.Second
-				}
-			} else {
-				usersProvider, err := github.NewUsersProvider(githubClient, githubName, installationID)
-				if err != nil {
-					l.Error(err, "error during creating the users provider")
-					return ctrl.Result{}, err
-				}
+	if minRequeueAfter > 0 {
+		return ctrl.Result{RequeueAfter: minRequeueAfter}, nil
+	}
+	return ctrl.Result{}, nil
+}
 
-				// Check membership first to avoid deadlock
-				isMember, err := usersProvider.IsMemberOfOrg(ctx, orgName, githubAccountLink.Spec.GithubUserID)
-				if err != nil {
-					l.Error(err, "error checking org membership via GitHub API", "uid", githubAccountLink.Spec.GithubUserID, "org", orgName)
-					return ctrl.Result{}, err
-				}
+// emailVerificationDue reports whether the check configured by cfg has to run now, and
+// otherwise how long until its TTL expires. Checks without a valid TTL run only when
+// there is no previous result for the configured domain.
+func emailVerificationDue(cfg v1.EmailVerificationSpec, prev v1.EmailVerificationStatus, hasPrev bool, now time.Time) (bool, time.Duration) {
+	if !hasPrev || prev.Domain != cfg.Domain || prev.LastChecked.IsZero() {
+		return true, 0
+	}
+	ttl, err := time.ParseDuration(cfg.TTL)
+	if err != nil || ttl <= 0 {
+		return false, 0
+	}
+	next := prev.LastChecked.Add(ttl).Sub(now)
+	if next <= 0 {
+		return true, ttl
+	}
+	return false, next
+}
 
-				if !isMember {
-					status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NOT_PART_OF_ORG
-				} else {
-					ok, checkErr := usersProvider.HasVerifiedEmailDomainForGithubUID(ctx, orgName, githubAccountLink.Spec.GithubUserID, cfg.Domain)
-					if checkErr != nil {
-						l.Error(checkErr, "error verifying email domain via GitHub API", "uid", githubAccountLink.Spec.GithubUserID, "domain", cfg.Domain, "org", orgName)
-						return ctrl.Result{}, checkErr
-					}
-					if ok {
-						status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED
-					} else {
-						status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO
-					}
-				}
-			}
+// checkVerifiedDomainEmail checks whether the GitHub user uid has a verified email under
+// cfg.Domain in cfg.Organization and returns the resulting email check status.
+func checkVerifiedDomainEmail(ctx context.Context, githubClient githubapp.ClientCreator, githubName string, installationID int64, uid string, cfg v1.EmailVerificationSpec) (string, error) {
+	l := log.FromContext(ctx)
+	usersProvider, err := github.NewUsersProvider(githubClient, githubName, installationID)
+	if err != nil {
+		l.Error(err, "error during creating the users provider")
+		return "", err
+	}
 
-			if githubAccountLink.Annotations == nil {
-				githubAccountLink.Annotations = make(map[string]string)
-			}
-			// update results map
-			results[orgName] = resultEntry{
-				Domain:    cfg.Domain,
-				Status:    status,
-				Verified:  status == v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED,
-				Timestamp: time.Now().Format(time.RFC3339),
-			}
-			// write back annotation after loop
-			updated = true
+	// Check membership first to avoid deadlock
+	isMember, err := usersProvider.IsMemberOfOrg(ctx, cfg.Organization, uid)
+	if err != nil {
+		l.Error(err, "error checking org membership via GitHub API", "uid", uid, "org", cfg.Organization)
+		return "", err
+	}
+	if !isMember {
+		ghmetrics.ObserveEmailVerificationPerLink(githubName, cfg.Organization, ghmetrics.EmailVerificationPerLinkCallsNonMember)
+		return v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NOT_PART_OF_ORG, nil
+	}
+
+	ok, err := usersProvider.HasVerifiedEmailDomainForGithubUID(ctx, cfg.Organization, uid, cfg.Domain)
+	ghmetrics.ObserveEmailVerificationPerLink(githubName, cfg.Organization, ghmetrics.EmailVerificationPerLinkCallsMember)
+	if err != nil {
+		l.Error(err, "error verifying email domain via GitHub API", "uid", uid, "domain", cfg.Domain, "org", cfg.Organization)
+		return "", err
+	}
+	if ok {
+		return v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED, nil
+	}
+	return v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO, nil
+}
+
+// emailCheckOrganizations returns the GithubOrganizations of the given Github keyed by organization.
+func (r *GithubAccountLinkReconciler) emailCheckOrganizations(ctx context.Context, githubName string) (map[string]v1.GithubOrganization, error) {
+	var orgList v1.GithubOrganizationList
+	if err := r.List(ctx, &orgList); err != nil {
+		return nil, err
+	}
+	orgs := make(map[string]v1.GithubOrganization)
+	for _, goItem := range orgList.Items {
+		if goItem.Spec.Github != githubName {
+			continue
+		}
+		if _, ok := orgs[goItem.Spec.Organization]; !ok {
+			orgs[goItem.Spec.Organization] = goItem
 		}
+	}
+	return orgs, nil
+}
+
+// orderedEmailVerificationResults returns the results for the entries of spec in spec order.
+// Results for organizations that are no longer configured, or for another domain, are dropped.
+func orderedEmailVerificationResults(spec []v1.EmailVerificationSpec, results map[string]v1.EmailVerificationStatus) []v1.EmailVerificationStatus {
+	var ordered []v1.EmailVerificationStatus
+	for _, cfg := range spec {
+		if result, ok := results[cfg.Organization]; ok && result.Domain == cfg.Domain {
+			ordered = append(ordered, result)
+		}
+	}
+	return ordered
+}
+
+// legacyEmailCheckConfig is one entry of the deprecated email-check-config annotation.
+type legacyEmailCheckConfig struct {
+	Domain  string `json:"domain"`
+	Enabled bool   `json:"enabled"`
+	TTL     string `json:"ttl"`
+}
 
-		if updated {
-			if b, err := json.Marshal(results); err == nil {
-				githubAccountLink.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS] = string(b)
+// legacyEmailCheckResult is one entry of the deprecated email-check-results annotation.
+type legacyEmailCheckResult struct {
+	Domain    string `json:"domain"`
+	Status    string `json:"status"`
+	Verified  bool   `json:"verified"` // Legacy support
+	Timestamp string `json:"timestamp"`
+}
+
+// convertEmailCheckAnnotations converts the deprecated email check annotations into
+// spec.emailVerification entries and status.emailVerification results. Disabled config
+// entries are dropped, and results are only kept for a configured organization and domain.
+// An existing spec.emailVerification takes precedence over the config annotation.
+func convertEmailCheckAnnotations(link *v1.GithubAccountLink) ([]v1.EmailVerificationSpec, []v1.EmailVerificationStatus, error) {
+	var config map[string]legacyEmailCheckConfig
+	if err := json.Unmarshal([]byte(link.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG]), &config); err != nil {
+		return nil, nil, fmt.Errorf("parse annotation %s: %w", v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG, err)
+	}
+
+	spec := link.Spec.EmailVerification
+	if len(spec) == 0 {
+		orgs := make([]string, 0, len(config))
+		for org := range config {
+			orgs = append(orgs, org)
+		}
+		sort.Strings(orgs)
+		for _, org := range orgs {
+			cfg := config[org]
+			if !cfg.Enabled || cfg.Domain == "" {
+				continue
 			}
+			spec = append(spec, v1.EmailVerificationSpec{Organization: org, Domain: cfg.Domain, TTL: cfg.TTL})
 		}
 	}
 
-	if updated {
-		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			latest := &v1.GithubAccountLink{}
-			if err := r.Get(ctx, types.NamespacedName{Name: githubAccountLink.Name}, latest); err != nil {
-				return err
+	legacyResults := make(map[string]legacyEmailCheckResult)
+	if raw := link.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS]; raw != "" {
+		_ = json.Unmarshal([]byte(raw), &legacyResults)
+	}
+	existing := make(map[string]v1.EmailVerificationStatus)
+	for _, result := range link.Status.EmailVerification {
+		existing[result.Organization] = result
+	}
+
+	var status []v1.EmailVerificationStatus
+	for _, cfg := range spec {
+		if legacy, ok := legacyResults[cfg.Organization]; ok && legacy.Domain == cfg.Domain {
+			result := v1.EmailVerificationStatus{Organization: cfg.Organization, Domain: cfg.Domain, Status: legacy.Status}
+			if result.Status == "" {
+				result.Status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO
+				if legacy.Verified {
+					result.Status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED
+				}
 			}
-			if latest.Annotations == nil {
-				latest.Annotations = make(map[string]string)
+			// an unparsable timestamp leaves LastChecked empty, which forces a re-check
+			if ts, err := time.Parse(time.RFC3339, legacy.Timestamp); err == nil {
+				result.LastChecked = metav1.NewTime(ts)
 			}
-			latest.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS] = githubAccountLink.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS]
-			return r.Update(ctx, latest)
-		})
-		if err != nil {
-			l.Error(err, "error updating GithubAccountLink after checks")
-			return ctrl.Result{}, err
+			status = append(status, result)
+		} else if result, ok := existing[cfg.Organization]; ok {
+			status = append(status, result)
 		}
 	}
+	return spec, status, nil
+}
 
-	if minRequeueAfter > 0 {
-		return ctrl.Result{RequeueAfter: minRequeueAfter}, nil
-	}
-	return ctrl.Result{}, nil
+// migrateEmailCheckAnnotations moves the deprecated email check annotations of a link into
+// spec.emailVerification and status.emailVerification. The status is written first so that
+// an interrupted migration still finds the annotations on the next attempt.
+func (r *GithubAccountLinkReconciler) migrateEmailCheckAnnotations(ctx context.Context, name string) error {
+	l := log.FromContext(ctx)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubAccountLink{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, latest); err != nil {
+			return err
+		}
+		if _, ok := latest.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG]; !ok {
+			return nil
+		}
+		spec, status, err := convertEmailCheckAnnotations(latest)
+		if err != nil {
+			// Leave the annotation in place so the user can fix it.
+			l.Error(err, "email check config annotation is invalid; migration skipped")
+			return nil
+		}
+
+		if !equality.Semantic.DeepEqual(status, latest.Status.EmailVerification) {
+			latest.Status.EmailVerification = status
+			if err := r.Status().Update(ctx, latest); err != nil {
+				return err
+			}
+		}
+
+		latest.Spec.EmailVerification = spec
+		delete(latest.Annotations, v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG)
+		delete(latest.Annotations, v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS)
+		if err := r.Update(ctx, latest); err != nil {
+			return err
+		}
+		l.Info("migrated email check annotations to spec.emailVerification", "entries", len(spec))
+		return nil
+	})
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *GithubAccountLinkReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	// Reconcile only when email-domain check is requested
+	// Reconcile only when email-domain checks are requested, results are left to prune,
+	// or the deprecated config annotation is waiting to be migrated
 	pred := predicate.NewPredicateFuncs(func(o client.Object) bool {
-		annotations := o.GetAnnotations()
-		if annotations == nil {
+		link, ok := o.(*v1.GithubAccountLink)
+		if !ok {
 			return false
 		}
-		if _, ok := annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG]; ok {
+		if len(link.Spec.EmailVerification) > 0 || len(link.Status.EmailVerification) > 0 {
 			return true
 		}
-		return false
+		_, ok = link.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG]
+		return ok
 	})
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1.GithubAccountLink{}, builder.WithPredicates(pred)).
 		Named("githubaccountlink").
+		WatchesRawSource(githubClientSource(githubAccountLinksOfGithub(r.Client, func(link *v1.GithubAccountLink) bool {
+			return len(link.Spec.EmailVerification) > 0
+		}))).
 		Complete(r)
 }
 
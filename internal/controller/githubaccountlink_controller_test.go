@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func TestConvertEmailCheckAnnotations(t *testing.T) {
+	link := accountLink("com-jdoe", "jdoe", "42", "com")
+	link.Annotations = map[string]string{
+		v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG: `{
+			"org-b": {"domain": "example.com", "enabled": true, "ttl": "24h"},
+			"org-a": {"domain": "example.org", "enabled": true},
+			"org-c": {"domain": "example.net", "enabled": false}
+		}`,
+		v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS: `{
+			"org-a": {"domain": "example.org", "verified": true, "timestamp": "2024-01-15T10:00:00Z"},
+			"org-b": {"domain": "old.example.com", "status": "verified", "timestamp": "2024-01-15T10:00:00Z"},
+			"org-c": {"domain": "example.net", "status": "no", "timestamp": "2024-01-15T10:00:00Z"}
+		}`,
+	}
+
+	spec, status, err := convertEmailCheckAnnotations(&link)
+	if err != nil {
+		t.Fatalf("convertEmailCheckAnnotations: unexpected error: %v", err)
+	}
+
+	wantSpec := []v1.EmailVerificationSpec{
+		{Organization: "org-a", Domain: "example.org"},
+		{Organization: "org-b", Domain: "example.com", TTL: "24h"},
+	}
+	if len(spec) != len(wantSpec) {
+		t.Fatalf("spec = %+v, want %+v", spec, wantSpec)
+	}
+	for i := range wantSpec {
+		if spec[i] != wantSpec[i] {
+			t.Errorf("spec[%d] = %+v, want %+v", i, spec[i], wantSpec[i])
+		}
+	}
+
+	// org-b result is for another domain and org-c is disabled, so only org-a is kept.
+	if len(status) != 1 {
+		t.Fatalf("status = %+v, want one result for org-a", status)
+	}
+	if status[0].Organization != "org-a" || status[0].Status != v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED {
+		t.Errorf("unexpected result: %+v", status[0])
+	}
+	if !status[0].LastChecked.Time.Equal(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("lastChecked = %v, want the annotation timestamp", status[0].LastChecked)
+	}
+}
+
+func TestConvertEmailCheckAnnotations_SpecWins(t *testing.T) {
+	link := accountLink("com-jdoe", "jdoe", "42", "com")
+	link.Spec.EmailVerification = []v1.EmailVerificationSpec{{Organization: "org-a", Domain: "example.com"}}
+	link.Annotations = map[string]string{
+		v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG: `{"org-b": {"domain": "example.org", "enabled": true}}`,
+	}
+
+	spec, _, err := convertEmailCheckAnnotations(&link)
+	if err != nil {
+		t.Fatalf("convertEmailCheckAnnotations: unexpected error: %v", err)
+	}
+	if len(spec) != 1 || spec[0].Organization != "org-a" {
+		t.Errorf("spec = %+v, want the existing spec", spec)
+	}
+}
+
+func TestConvertEmailCheckAnnotations_InvalidConfig(t *testing.T) {
+	link := accountLink("com-jdoe", "jdoe", "42", "com")
+	link.Annotations = map[string]string{v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_CONFIG: "not json"}
+
+	if _, _, err := convertEmailCheckAnnotations(&link); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestEmailVerificationDue(t *testing.T) {
+	now := time.Now()
+	cfg := v1.EmailVerificationSpec{Organization: "org", Domain: "example.com", TTL: "1h"}
+	checked := func(ago time.Duration, domain string) v1.EmailVerificationStatus {
+		return v1.EmailVerificationStatus{Organization: "org", Domain: domain, LastChecked: metav1.NewTime(now.Add(-ago))}
+	}
+
+	tests := []struct {
+		name     string
+		cfg      v1.EmailVerificationSpec
+		prev     v1.EmailVerificationStatus
+		hasPrev  bool
+		wantDue  bool
+		wantNext time.Duration
+	}{
+		{"no previous result", cfg, v1.EmailVerificationStatus{}, false, true, 0},
+		{"domain changed", cfg, checked(time.Minute, "other.com"), true, true, 0},
+		{"within ttl", cfg, checked(15*time.Minute, "example.com"), true, false, 45 * time.Minute},
+		{"ttl expired", cfg, checked(2*time.Hour, "example.com"), true, true, time.Hour},
+		{"no ttl checks once", v1.EmailVerificationSpec{Organization: "org", Domain: "example.com"}, checked(48*time.Hour, "example.com"), true, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due, next := emailVerificationDue(tt.cfg, tt.prev, tt.hasPrev, now)
+			if due != tt.wantDue || next != tt.wantNext {
+				t.Errorf("emailVerificationDue = (%v, %v), want (%v, %v)", due, next, tt.wantDue, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestEmailVerificationResult(t *testing.T) {
+	ctx := context.Background()
+	legacy := map[string]string{
+		v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS: `{"org": {"domain": "example.com", "verified": true}}`,
+	}
+
+	t.Run("status takes precedence over annotation", func(t *testing.T) {
+		link := accountLink("com-jdoe", "jdoe", "42", "com")
+		link.Annotations = legacy
+		link.Status.EmailVerification = []v1.EmailVerificationStatus{
+			{Organization: "org", Domain: "example.com", Status: v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO},
+		}
+		status, ok := emailVerificationResult(ctx, &link, "org", "example.com")
+		if !ok || status != v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO {
+			t.Errorf("got (%q, %v), want (%q, true)", status, ok, v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO)
+		}
+		if _, ok := emailVerificationResult(ctx, &link, "org", "other.com"); ok {
+			t.Error("expected no result for a different domain")
+		}
+	})
+
+	t.Run("legacy annotation fallback", func(t *testing.T) {
+		link := accountLink("com-jdoe", "jdoe", "42", "com")
+		link.Annotations = legacy
+		status, ok := emailVerificationResult(ctx, &link, "org", "example.com")
+		if !ok || status != v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED {
+			t.Errorf("got (%q, %v), want (%q, true)", status, ok, v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED)
+		}
+		if _, ok := emailVerificationResult(ctx, &link, "other-org", "example.com"); ok {
+			t.Error("expected no result for an unknown org")
+		}
+	})
+}
@@ -0,0 +1,341 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// defaultAccountLinkRefreshInterval is used when RefreshInterval is not set.
+const defaultAccountLinkRefreshInterval = 24 * time.Hour
+
+// GithubAccountLinkStatusReconciler resolves the GitHub user of every GithubAccountLink
+// and reports it, together with conflicting links, in the link's status.
+type GithubAccountLinkStatusReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// RefreshInterval is the time between two verifications of the same link.
+	RefreshInterval time.Duration
+}
+
+func (r *GithubAccountLinkStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	l := log.FromContext(ctx)
+	done := ghmetrics.StartReconcileTimer("GithubAccountLinkStatus")
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
+		} else if res.RequeueAfter > 0 {
+			result = "requeue"
+		}
+		done(result)
+	}()
+
+	link := &v1.GithubAccountLink{}
+	if err = r.Get(ctx, types.NamespacedName{Name: req.Name}, link); err != nil {
+		if errors.IsNotFound(err) {
+			l.Info("resource not found in kubernetes: reconcile is skipped")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	interval := r.RefreshInterval
+	if interval <= 0 {
+		interval = defaultAccountLinkRefreshInterval
+	}
+
+	status := link.Status.DeepCopy()
+
+	byUID, byUserID, err := r.listAccountLinkCandidates(ctx, link)
+	if err != nil {
+		l.Error(err, "error listing GithubAccountLinks for conflict detection")
+		return ctrl.Result{}, err
+	}
+	status.Conflicts = accountLinkConflicts(link, byUID, byUserID)
+	setAccountLinkConflictCondition(status, link.Generation)
+
+	now := time.Now()
+	var requeueAfter time.Duration
+	var verifyErr error
+	if accountLinkVerificationDue(link, interval, now) {
+		requeueAfter, verifyErr = r.verify(ctx, link, status, now)
+	}
+
+	if !equality.Semantic.DeepEqual(link.Status, *status) {
+		if err := r.updateAccountLinkStatus(ctx, link.Name, status); err != nil {
+			l.Error(err, "error updating GithubAccountLink status")
+			return ctrl.Result{}, err
+		}
+	}
+	if verifyErr != nil {
+		return ctrl.Result{}, verifyErr
+	}
+
+	if requeueAfter == 0 {
+		requeueAfter = time.Until(status.LastVerified.Add(interval))
+		if requeueAfter <= 0 {
+			requeueAfter = interval
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// verify resolves the link's GitHub user and records the outcome in status.
+// A non-zero duration asks for an earlier requeue when verification could not run yet.
+func (r *GithubAccountLinkStatusReconciler) verify(ctx context.Context, link *v1.GithubAccountLink, status *v1.GithubAccountLinkStatus, now time.Time) (time.Duration, error) {
+	l := log.FromContext(ctx)
+
+	uid := link.Spec.GithubUserID
+	if _, err := strconv.ParseInt(uid, 10, 64); err != nil {
+		recordAccountLinkVerification(status, link.Generation, now, "", false, false)
+		setAccountLinkVerifiedCondition(status, link.Generation, metav1.ConditionFalse,
+			v1.GITHUB_ACCOUNT_LINK_REASON_INVALID_GITHUB_USER_ID,
+			fmt.Sprintf("githubUserID %q is not a numeric GitHub user ID", uid))
+		return 0, nil
+	}
+
+	githubName := link.Spec.Github
+	githubClient, ok := GithubClients.Get(githubName)
+	if !ok {
+		l.Info("waiting for github to be initialized", "github", githubName)
+		return 0, nil
+	}
+
+	installationID, err := r.accountLinkInstallationID(ctx, githubName)
+	if err != nil {
+		return 0, err
+	}
+	if installationID == 0 && !github.UsesTokenAuth(githubClient) {
+		setAccountLinkVerifiedCondition(status, link.Generation, metav1.ConditionUnknown,
+			v1.GITHUB_ACCOUNT_LINK_REASON_INSTALLATION_NOT_RESOLVED,
+			fmt.Sprintf("no GithubOrganization with an installation ID found for github %q", githubName))
+		return time.Minute, nil
+	}
+
+	usersProvider, err := github.NewUsersProvider(githubClient, githubName, installationID)
+	if err != nil {
+		l.Error(err, "error during creating the users provider")
+		return 0, err
+	}
+	return 0, verifyAccountLink(ctx, usersProvider, link, status, now)
+}
+
+// verifyAccountLink resolves githubUserID through usersProvider and records login,
+// existence and suspension state in status. On GitHub errors the Verified condition
+// becomes Unknown and the previously verified state is kept.
+func verifyAccountLink(ctx context.Context, usersProvider github.UsersProvider, link *v1.GithubAccountLink, status *v1.GithubAccountLinkStatus, now time.Time) error {
+	uid := link.Spec.GithubUserID
+	githubError := func(err error) error {
+		setAccountLinkVerifiedCondition(status, link.Generation, metav1.ConditionUnknown,
+			v1.GITHUB_ACCOUNT_LINK_REASON_GITHUB_ERROR, err.Error())
+		return fmt.Errorf("verify github user %s: %w", uid, err)
+	}
+
+	login, found, suspended, err := usersProvider.GithubUserByID(ctx, uid)
+	if err != nil {
+		return githubError(err)
+	}
+	if !found {
+		recordAccountLinkVerification(status, link.Generation, now, "", false, false)
+		setAccountLinkVerifiedCondition(status, link.Generation, metav1.ConditionFalse,
+			v1.GITHUB_ACCOUNT_LINK_REASON_USER_NOT_FOUND,
+			fmt.Sprintf("GitHub user ID %s does not exist", uid))
+		return nil
+	}
+
+	recordAccountLinkVerification(status, link.Generation, now, login, true, suspended)
+	if suspended {
+		setAccountLinkVerifiedCondition(status, link.Generation, metav1.ConditionFalse,
+			v1.GITHUB_ACCOUNT_LINK_REASON_USER_SUSPENDED,
+			fmt.Sprintf("GitHub user %s (%s) is suspended", login, uid))
+		return nil
+	}
+	setAccountLinkVerifiedCondition(status, link.Generation, metav1.ConditionTrue,
+		v1.GITHUB_ACCOUNT_LINK_REASON_USER_FOUND,
+		fmt.Sprintf("GitHub user ID %s resolves to %s", uid, login))
+	return nil
+}
+
+func recordAccountLinkVerification(status *v1.GithubAccountLinkStatus, generation int64, now time.Time, login string, exists, suspended bool) {
+	status.Login = login
+	status.Exists = exists
+	status.Suspended = suspended
+	status.LastVerified = metav1.NewTime(now)
+	status.ObservedGeneration = generation
+}
+
+func setAccountLinkVerifiedCondition(status *v1.GithubAccountLinkStatus, generation int64, conditionStatus metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1.GITHUB_ACCOUNT_LINK_CONDITION_VERIFIED,
+		Status:             conditionStatus,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func setAccountLinkConflictCondition(status *v1.GithubAccountLinkStatus, generation int64) {
+	condition := metav1.Condition{
+		Type:               v1.GITHUB_ACCOUNT_LINK_CONDITION_CONFLICT,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             v1.GITHUB_ACCOUNT_LINK_REASON_NO_CONFLICT,
+		Message:            "no other GithubAccountLink maps the same identity",
+	}
+	if len(status.Conflicts) > 0 {
+		var parts []string
+		for _, c := range status.Conflicts {
+			parts = append(parts, fmt.Sprintf("%s (%s)", c.Name, c.Field))
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1.GITHUB_ACCOUNT_LINK_REASON_DUPLICATE_IDENTITY
+		condition.Message = "identity is also mapped by " + strings.Join(parts, ", ")
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+// accountLinkVerificationDue reports whether the link was never verified, its spec
+// changed since the last verification or the refresh interval elapsed.
+func accountLinkVerificationDue(link *v1.GithubAccountLink, interval time.Duration, now time.Time) bool {
+	if link.Status.LastVerified.IsZero() || link.Status.ObservedGeneration != link.Generation {
+		return true
+	}
+	return !now.Before(link.Status.LastVerified.Add(interval))
+}
+
+// accountLinkConflicts returns the other links of the same Github that share the
+// githubUserID (byUID) or, case-insensitively, the userID (byUserID) of link.
+func accountLinkConflicts(link *v1.GithubAccountLink, byUID, byUserID []v1.GithubAccountLink) []v1.GithubAccountLinkConflict {
+	var conflicts []v1.GithubAccountLinkConflict
+	add := func(candidates []v1.GithubAccountLink, field string, matches func(v1.GithubAccountLink) bool) {
+		for _, other := range candidates {
+			if other.Name == link.Name || other.Spec.Github != link.Spec.Github || !matches(other) {
+				continue
+			}
+			conflicts = append(conflicts, v1.GithubAccountLinkConflict{Name: other.Name, Field: field})
+		}
+	}
+	if link.Spec.GithubUserID != "" {
+		add(byUID, v1.GITHUB_ACCOUNT_LINK_CONFLICT_FIELD_GITHUB_USER_ID, func(o v1.GithubAccountLink) bool {
+			return o.Spec.GithubUserID == link.Spec.GithubUserID
+		})
+	}
+	if link.Spec.GreenhouseUserID != "" {
+		add(byUserID, v1.GITHUB_ACCOUNT_LINK_CONFLICT_FIELD_USER_ID, func(o v1.GithubAccountLink) bool {
+			return strings.EqualFold(o.Spec.GreenhouseUserID, link.Spec.GreenhouseUserID)
+		})
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Name != conflicts[j].Name {
+			return conflicts[i].Name < conflicts[j].Name
+		}
+		return conflicts[i].Field < conflicts[j].Field
+	})
+	return conflicts
+}
+
+// listAccountLinkCandidates lists the links sharing the githubUserID or userID of link.
+func (r *GithubAccountLinkStatusReconciler) listAccountLinkCandidates(ctx context.Context, link *v1.GithubAccountLink) ([]v1.GithubAccountLink, []v1.GithubAccountLink, error) {
+	var byUID, byUserID v1.GithubAccountLinkList
+	if link.Spec.GithubUserID != "" {
+		if err := r.List(ctx, &byUID, client.MatchingFields{"spec.githubUserID": link.Spec.GithubUserID}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if link.Spec.GreenhouseUserID != "" {
+		if err := r.List(ctx, &byUserID, client.MatchingFields{"spec.userID": strings.ToLower(link.Spec.GreenhouseUserID)}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return byUID.Items, byUserID.Items, nil
+}
+
+// accountLinkInstallationID returns the installation ID of any GithubOrganization of the
+// given Github. User lookups by ID are not org-specific, so any installation will do.
+func (r *GithubAccountLinkStatusReconciler) accountLinkInstallationID(ctx context.Context, githubName string) (int64, error) {
+	var orgList v1.GithubOrganizationList
+	if err := r.List(ctx, &orgList); err != nil {
+		return 0, err
+	}
+	for i := range orgList.Items {
+		org := &orgList.Items[i]
+		if org.Spec.Github == githubName && org.ResolvedInstallationID() != 0 {
+			return org.ResolvedInstallationID(), nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *GithubAccountLinkStatusReconciler) updateAccountLinkStatus(ctx context.Context, name string, status *v1.GithubAccountLinkStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubAccountLink{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, latest); err != nil {
+			return err
+		}
+		// status.emailVerification is owned by GithubAccountLinkReconciler.
+		emailVerification := latest.Status.EmailVerification
+		latest.Status = *status
+		latest.Status.EmailVerification = emailVerification
+		return r.Status().Update(ctx, latest)
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GithubAccountLinkStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Re-evaluate conflicts of links sharing an identity whenever a link is created,
+	// deleted or changes its spec.
+	mapConflicting := func(ctx context.Context, obj client.Object) []reconcile.Request {
+		link, ok := obj.(*v1.GithubAccountLink)
+		if !ok {
+			return nil
+		}
+		byUID, byUserID, err := r.listAccountLinkCandidates(ctx, link)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "error listing GithubAccountLinks for conflict detection")
+			return nil
+		}
+		seen := map[string]bool{link.Name: true}
+		var requests []reconcile.Request
+		for _, other := range append(byUID, byUserID...) {
+			if seen[other.Name] {
+				continue
+			}
+			seen[other.Name] = true
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: other.Name}})
+		}
+		return requests
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.GithubAccountLink{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&v1.GithubAccountLink{}, handler.EnqueueRequestsFromMapFunc(mapConflicting),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("githubaccountlink-status").
+		WatchesRawSource(githubClientSource(githubAccountLinksOfGithub(r.Client, nil))).
+		Complete(r)
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func accountLink(name, userID, uid, github string) v1.GithubAccountLink {
+	return v1.GithubAccountLink{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+		Spec:       v1.GithubAccountLinkSpec{GreenhouseUserID: userID, GithubUserID: uid, Github: github},
+	}
+}
+
+func TestAccountLinkConflicts(t *testing.T) {
+	link := accountLink("com-jdoe", "jdoe", "42", "com")
+	byUID := []v1.GithubAccountLink{
+		link,
+		accountLink("com-jdoe-2", "john", "42", "com"),
+		accountLink("ghe-jdoe", "jdoe", "42", "ghe"),
+	}
+	byUserID := []v1.GithubAccountLink{
+		link,
+		accountLink("com-jdoe-2", "JDoe", "42", "com"),
+		accountLink("com-alt", "JDOE", "43", "com"),
+		accountLink("ghe-jdoe", "jdoe", "42", "ghe"),
+	}
+
+	got := accountLinkConflicts(&link, byUID, byUserID)
+	want := []v1.GithubAccountLinkConflict{
+		{Name: "com-alt", Field: v1.GITHUB_ACCOUNT_LINK_CONFLICT_FIELD_USER_ID},
+		{Name: "com-jdoe-2", Field: v1.GITHUB_ACCOUNT_LINK_CONFLICT_FIELD_GITHUB_USER_ID},
+		{Name: "com-jdoe-2", Field: v1.GITHUB_ACCOUNT_LINK_CONFLICT_FIELD_USER_ID},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("conflicts = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("conflicts[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	status := &v1.GithubAccountLinkStatus{Conflicts: got}
+	setAccountLinkConflictCondition(status, 1)
+	if !meta.IsStatusConditionTrue(status.Conditions, v1.GITHUB_ACCOUNT_LINK_CONDITION_CONFLICT) {
+		t.Errorf("expected Conflict condition to be True: %+v", status.Conditions)
+	}
+	status.Conflicts = nil
+	setAccountLinkConflictCondition(status, 1)
+	if !meta.IsStatusConditionFalse(status.Conditions, v1.GITHUB_ACCOUNT_LINK_CONDITION_CONFLICT) {
+		t.Errorf("expected Conflict condition to be False: %+v", status.Conditions)
+	}
+}
+
+func TestAccountLinkVerificationDue(t *testing.T) {
+	now := time.Now()
+	verified := func(ago time.Duration, observed int64) *v1.GithubAccountLink {
+		link := accountLink("com-jdoe", "jdoe", "42", "com")
+		link.Status.LastVerified = metav1.NewTime(now.Add(-ago))
+		link.Status.ObservedGeneration = observed
+		return &link
+	}
+	never := accountLink("com-jdoe", "jdoe", "42", "com")
+
+	tests := []struct {
+		name string
+		link *v1.GithubAccountLink
+		want bool
+	}{
+		{"never verified", &never, true},
+		{"recently verified", verified(time.Hour, 1), false},
+		{"interval elapsed", verified(25*time.Hour, 1), true},
+		{"spec changed", verified(time.Hour, 0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := accountLinkVerificationDue(tt.link, 24*time.Hour, now); got != tt.want {
+				t.Errorf("accountLinkVerificationDue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAccountLink(t *testing.T) {
+	now := time.Now()
+	provider := &fakeUsersProvider{
+		logins:    map[string]string{"42": "jdoe", "43": "banned"},
+		suspended: map[string]bool{"43": true},
+	}
+
+	tests := []struct {
+		name          string
+		uid           string
+		wantLogin     string
+		wantExists    bool
+		wantSuspended bool
+		wantStatus    metav1.ConditionStatus
+		wantReason    string
+	}{
+		{"found", "42", "jdoe", true, false, metav1.ConditionTrue, v1.GITHUB_ACCOUNT_LINK_REASON_USER_FOUND},
+		{"suspended", "43", "banned", true, true, metav1.ConditionFalse, v1.GITHUB_ACCOUNT_LINK_REASON_USER_SUSPENDED},
+		{"not found", "44", "", false, false, metav1.ConditionFalse, v1.GITHUB_ACCOUNT_LINK_REASON_USER_NOT_FOUND},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := accountLink("com-x", "x", tt.uid, "com")
+			status := &v1.GithubAccountLinkStatus{Login: "stale"}
+			provider.lookups = nil
+			if err := verifyAccountLink(t.Context(), provider, &link, status, now); err != nil {
+				t.Fatalf("verifyAccountLink: unexpected error: %v", err)
+			}
+			if len(provider.lookups) != 1 {
+				t.Errorf("expected a single GitHub lookup, got %v", provider.lookups)
+			}
+			if status.Login != tt.wantLogin || status.Exists != tt.wantExists || status.Suspended != tt.wantSuspended {
+				t.Errorf("status = %+v, want login=%q exists=%v suspended=%v", status, tt.wantLogin, tt.wantExists, tt.wantSuspended)
+			}
+			if !status.LastVerified.Time.Equal(now) || status.ObservedGeneration != 1 {
+				t.Errorf("lastVerified/observedGeneration not recorded: %+v", status)
+			}
+			cond := meta.FindStatusCondition(status.Conditions, v1.GITHUB_ACCOUNT_LINK_CONDITION_VERIFIED)
+			if cond == nil || cond.Status != tt.wantStatus || cond.Reason != tt.wantReason {
+				t.Errorf("Verified condition = %+v, want %s/%s", cond, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestVerifyAccountLink_GithubErrorKeepsPreviousState(t *testing.T) {
+	provider := &fakeUsersProvider{err: errors.New("boom")}
+	link := accountLink("com-jdoe", "jdoe", "42", "com")
+	previous := metav1.NewTime(time.Now().Add(-48 * time.Hour))
+	status := &v1.GithubAccountLinkStatus{Login: "jdoe", Exists: true, LastVerified: previous}
+
+	if err := verifyAccountLink(t.Context(), provider, &link, status, time.Now()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if status.Login != "jdoe" || !status.Exists || !status.LastVerified.Equal(&previous) {
+		t.Errorf("previous verification was overwritten: %+v", status)
+	}
+	cond := meta.FindStatusCondition(status.Conditions, v1.GITHUB_ACCOUNT_LINK_CONDITION_VERIFIED)
+	if cond == nil || cond.Status != metav1.ConditionUnknown || cond.Reason != v1.GITHUB_ACCOUNT_LINK_REASON_GITHUB_ERROR {
+		t.Errorf("Verified condition = %+v, want Unknown/%s", cond, v1.GITHUB_ACCOUNT_LINK_REASON_GITHUB_ERROR)
+	}
+}
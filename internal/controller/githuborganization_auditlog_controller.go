@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// defaultAuditLogPollInterval is used when spec.auditLog.interval is unset or invalid.
+const defaultAuditLogPollInterval = 15 * time.Minute
+
+// auditLogOverlap is how far before status.auditLog.lastEntry a poll reads again, so
+// that entries created in the same second or indexed late by GitHub are not missed.
+// Entries read again are recognised by their ID in status.auditLog.recentEntries.
+const auditLogOverlap = 10 * time.Minute
+
+// GithubOrganizationAuditLogReconciler polls the audit log of GithubOrganizations that
+// opt in via spec.auditLog and reports the access changes made by other actors than
+// repo-guard in status.auditLog and the repo_guard_github_out_of_band_changes_total metric.
+type GithubOrganizationAuditLogReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (r *GithubOrganizationAuditLogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	l := log.FromContext(ctx)
+	done := ghmetrics.StartReconcileTimer("GithubOrganizationAuditLog")
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
+		} else if res.RequeueAfter > 0 {
+			result = "requeue"
+		}
+		done(result)
+	}()
+
+	githubOrganization := &v1.GithubOrganization{}
+	if err = r.Get(ctx, req.NamespacedName, githubOrganization); err != nil {
+		if errors.IsNotFound(err) {
+			l.Info("resource not found in kubernetes: reconcile is skipped")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	cfg := githubOrganization.Spec.AuditLog
+	if cfg == nil || !cfg.Enabled {
+		return reconcile.Result{}, nil
+	}
+
+	interval := defaultAuditLogPollInterval
+	if cfg.Interval != "" {
+		if d, perr := time.ParseDuration(cfg.Interval); perr == nil && d > 0 {
+			interval = d
+		} else {
+			l.Info("invalid audit log poll interval; using default", "value", cfg.Interval, "default", interval)
+		}
+	}
+
+	now := time.Now().UTC()
+	current := githubOrganization.Status.AuditLog
+	if current != nil && !current.LastPoll.IsZero() {
+		if next := current.LastPoll.Add(interval); now.Before(next) {
+			return reconcile.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+
+	githubName := githubOrganization.Spec.Github
+	githubClient, ok := GithubClients.Get(githubName)
+	if !ok {
+		l.Info("waiting for github to be initialized", "github", githubName)
+		return reconcile.Result{}, nil
+	}
+
+	newStatus := &v1.AuditLogStatus{LastPoll: metav1.NewTime(now)}
+	if current != nil {
+		newStatus.LastEntry = current.LastEntry
+		newStatus.RecentEntries = current.RecentEntries
+		newStatus.Actor = current.Actor
+		newStatus.OutOfBandChanges = current.OutOfBandChanges
+	}
+	if githubOrganization.ResolvedInstallationID() == 0 && !github.UsesTokenAuth(githubClient) {
+		newStatus.Error = "installation ID is not set or discovered"
+		return reconcile.Result{RequeueAfter: interval}, r.updateAuditLogStatus(ctx, req, newStatus)
+	}
+
+	if newStatus.Actor == "" {
+		actor, err := github.ActorLogin(ctx, githubClient)
+		if err != nil {
+			l.Error(err, "error during resolving the actor of repo-guard")
+			newStatus.Error = err.Error()
+			return r.auditLogRequeue(err, interval, now), r.updateAuditLogStatus(ctx, req, newStatus)
+		}
+		newStatus.Actor = actor
+	}
+
+	auditLogProvider, err := github.NewAuditLogProvider(githubClient, githubName, githubOrganization.Spec.Organization, githubOrganization.ResolvedInstallationID())
+	if err != nil {
+		l.Error(err, "error during creating the audit log provider")
+		return reconcile.Result{}, err
+	}
+
+	// The first poll only looks one interval back, so that enabling the poller does
+	// not report the whole history of the organization.
+	since := newStatus.LastEntry.Add(-auditLogOverlap)
+	if newStatus.LastEntry.IsZero() {
+		since = now.Add(-interval)
+	}
+	actions := cfg.Actions
+	if len(actions) == 0 {
+		actions = github.DefaultAuditLogActions
+	}
+	entries, truncated, err := auditLogProvider.Entries(ctx, actions, since)
+	if err != nil {
+		l.Error(err, "error during reading the audit log")
+		newStatus.Error = err.Error()
+		return r.auditLogRequeue(err, interval, now), r.updateAuditLogStatus(ctx, req, newStatus)
+	}
+
+	if !truncated.IsZero() {
+		l.Info("audit log page limit reached; the remaining entries are read by the next polls", "until", truncated)
+	}
+	newStatus.Truncated = !truncated.IsZero()
+
+	entries = unseenAuditLogEntries(entries, newStatus.RecentEntries)
+	ignored := append([]string{newStatus.Actor}, cfg.IgnoredActors...)
+	changes, lastEntry := planAuditLogChanges(entries, ignored, newStatus.OutOfBandChanges)
+	for _, change := range changes[:len(changes)-len(newStatus.OutOfBandChanges)] {
+		l.Info("out-of-band change in audit log", "action", change.Action, "actor", change.Actor,
+			"user", change.User, "team", change.Team, "repository", change.Repository)
+		ghmetrics.OutOfBandChangesTotal.WithLabelValues(githubName, githubOrganization.Spec.Organization, change.Action).Inc()
+	}
+	newStatus.OutOfBandChanges = truncateOutOfBandChanges(changes)
+	if lastEntry.After(newStatus.LastEntry.Time) {
+		newStatus.LastEntry = metav1.NewTime(lastEntry)
+	} else if newStatus.LastEntry.IsZero() {
+		newStatus.LastEntry = metav1.NewTime(since)
+	}
+	newStatus.RecentEntries = recentAuditLogEntries(newStatus.RecentEntries, entries, ignored, newStatus.LastEntry.Time)
+
+	if err := r.updateAuditLogStatus(ctx, req, newStatus); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// auditLogRequeue returns the requeue of a failed poll: at the reset of a rate limit
+// hit by err, otherwise after interval.
+func (r *GithubOrganizationAuditLogReconciler) auditLogRequeue(err error, interval time.Duration, now time.Time) reconcile.Result {
+	requeueAfter := interval
+	if t, ok := parseGitHubRateLimitReset(err.Error()); ok {
+		recordOrgRateLimitHit(err.Error(), t)
+		if t.After(now) {
+			requeueAfter = t.Sub(now)
+		}
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}
+}
+
+// updateAuditLogStatus writes only status.auditLog so that the organization
+// reconciler's status fields are left untouched.
+func (r *GithubOrganizationAuditLogReconciler) updateAuditLogStatus(ctx context.Context, req ctrl.Request, status *v1.AuditLogStatus) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubOrganization{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status.AuditLog = status
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+	}
+	return err
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GithubOrganizationAuditLogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Reconcile only organizations that opted in; status-only updates are ignored
+	// because the poller schedules itself via RequeueAfter.
+	enabled := func(org *v1.GithubOrganization) bool {
+		return org.Spec.AuditLog != nil && org.Spec.AuditLog.Enabled
+	}
+	pred := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		org, ok := o.(*v1.GithubOrganization)
+		return ok && enabled(org)
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.GithubOrganization{}, builder.WithPredicates(pred, predicate.GenerationChangedPredicate{})).
+		Named("githuborganization-auditlog").
+		WatchesRawSource(githubClientSource(githubOrganizationsOfGithub(r.Client, enabled))).
+		Complete(r)
+}
+
+// planAuditLogChanges prepends the entries of actors not in ignored to changes, newest
+// first, and returns them with the creation time of the newest entry. Actors are
+// compared case-insensitively.
+func planAuditLogChanges(entries []github.AuditLogEntry, ignored []string, changes []v1.OutOfBandChange) ([]v1.OutOfBandChange, time.Time) {
+	var lastEntry time.Time
+	var added []v1.OutOfBandChange
+	for _, entry := range entries {
+		if entry.CreatedAt.After(lastEntry) {
+			lastEntry = entry.CreatedAt
+		}
+		if isIgnoredActor(entry.Actor, ignored) {
+			continue
+		}
+		added = append(added, v1.OutOfBandChange{
+			Action:     entry.Action,
+			Actor:      entry.Actor,
+			User:       entry.User,
+			Team:       entry.Team,
+			Repository: entry.Repository,
+			Timestamp:  metav1.NewTime(entry.CreatedAt),
+		})
+	}
+	result := make([]v1.OutOfBandChange, 0, len(added)+len(changes))
+	for i := len(added) - 1; i >= 0; i-- {
+		result = append(result, added[i])
+	}
+	return append(result, changes...), lastEntry
+}
+
+// unseenAuditLogEntries drops the entries in recent, which were reported by a previous poll.
+func unseenAuditLogEntries(entries []github.AuditLogEntry, recent []v1.AuditLogEntryRef) []github.AuditLogEntry {
+	seen := make(map[string]bool, len(recent))
+	for _, ref := range recent {
+		seen[ref.ID] = true
+	}
+	unseen := make([]github.AuditLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !seen[entry.ID] {
+			seen[entry.ID] = true
+			unseen = append(unseen, entry)
+		}
+	}
+	return unseen
+}
+
+// recentAuditLogEntries adds the entries of actors not in ignored to recent and keeps
+// those created within auditLogOverlap before lastEntry, which the next poll reads again.
+func recentAuditLogEntries(recent []v1.AuditLogEntryRef, entries []github.AuditLogEntry, ignored []string, lastEntry time.Time) []v1.AuditLogEntryRef {
+	oldest := lastEntry.Add(-auditLogOverlap)
+	var result []v1.AuditLogEntryRef
+	for _, ref := range recent {
+		if !ref.Timestamp.Time.Before(oldest) {
+			result = append(result, ref)
+		}
+	}
+	for _, entry := range entries {
+		if !entry.CreatedAt.Before(oldest) && !isIgnoredActor(entry.Actor, ignored) {
+			result = append(result, v1.AuditLogEntryRef{ID: entry.ID, Timestamp: metav1.NewTime(entry.CreatedAt)})
+		}
+	}
+	return result
+}
+
+// truncateOutOfBandChanges keeps the newest GITHUB_ORG_AUDIT_LOG_MAX_CHANGES changes.
+func truncateOutOfBandChanges(changes []v1.OutOfBandChange) []v1.OutOfBandChange {
+	if len(changes) > v1.GITHUB_ORG_AUDIT_LOG_MAX_CHANGES {
+		return changes[:v1.GITHUB_ORG_AUDIT_LOG_MAX_CHANGES]
+	}
+	return changes
+}
+
+func isIgnoredActor(actor string, ignored []string) bool {
+	// entries without an actor cannot be attributed to anyone
+	if actor == "" {
+		return true
+	}
+	for _, i := range ignored {
+		if strings.EqualFold(actor, i) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+func TestPlanAuditLogChanges(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	previous := []v1.OutOfBandChange{
+		{Action: "org.add_member", Actor: "mallory", User: "trent", Timestamp: metav1.NewTime(base.Add(-time.Hour))},
+	}
+	entries := []github.AuditLogEntry{
+		{Action: "team.add_member", Actor: "repo-guard[bot]", User: "bob", Team: "org/admins", CreatedAt: base.Add(time.Minute)},
+		{Action: "team.add_member", Actor: "alice", User: "bob", Team: "org/admins", CreatedAt: base.Add(2 * time.Minute)},
+		{Action: "repo.add_member", Actor: "Terraform-Bot", User: "carol", Repository: "org/app", CreatedAt: base.Add(3 * time.Minute)},
+		{Action: "org.add_member", Actor: "", User: "dave", CreatedAt: base.Add(4 * time.Minute)},
+		{Action: "team.add_repository", Actor: "erin", Team: "org/admins", Repository: "org/app", CreatedAt: base.Add(5 * time.Minute)},
+	}
+
+	changes, lastEntry := planAuditLogChanges(entries, []string{"repo-guard[bot]", "terraform-bot"}, previous)
+
+	if !lastEntry.Equal(base.Add(5 * time.Minute)) {
+		t.Errorf("expected last entry %v, got %v", base.Add(5*time.Minute), lastEntry)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Action+"/"+c.Actor)
+	}
+	want := []string{"team.add_repository/erin", "team.add_member/alice", "org.add_member/mallory"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected changes %v, got %v", want, got)
+	}
+	if changes[0].Team != "org/admins" || changes[0].Repository != "org/app" {
+		t.Errorf("expected team and repository to be kept, got %+v", changes[0])
+	}
+}
+
+func TestTruncateOutOfBandChanges(t *testing.T) {
+	changes := make([]v1.OutOfBandChange, v1.GITHUB_ORG_AUDIT_LOG_MAX_CHANGES+10)
+	for i := range changes {
+		changes[i].User = fmt.Sprintf("user-%d", i)
+	}
+	truncated := truncateOutOfBandChanges(changes)
+	if len(truncated) != v1.GITHUB_ORG_AUDIT_LOG_MAX_CHANGES {
+		t.Fatalf("expected %d changes, got %d", v1.GITHUB_ORG_AUDIT_LOG_MAX_CHANGES, len(truncated))
+	}
+	if truncated[0].User != "user-0" {
+		t.Errorf("expected the newest change to be kept, got %s", truncated[0].User)
+	}
+}
+
+func TestRecentAuditLogEntries(t *testing.T) {
+	lastEntry := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	recent := []v1.AuditLogEntryRef{
+		{ID: "old", Timestamp: metav1.NewTime(lastEntry.Add(-time.Hour))},
+		{ID: "reported", Timestamp: metav1.NewTime(lastEntry.Add(-time.Minute))},
+	}
+	// the overlap is read again: the reported entry and a late entry in the same second
+	entries := []github.AuditLogEntry{
+		{ID: "reported", Action: "team.add_member", Actor: "alice", CreatedAt: lastEntry.Add(-time.Minute)},
+		{ID: "late", Action: "team.add_member", Actor: "bob", CreatedAt: lastEntry.Add(-time.Minute)},
+		{ID: "own", Action: "team.add_member", Actor: "repo-guard[bot]", CreatedAt: lastEntry},
+	}
+
+	unseen := unseenAuditLogEntries(entries, recent)
+	if len(unseen) != 2 || unseen[0].ID != "late" || unseen[1].ID != "own" {
+		t.Fatalf("expected the reported entry to be dropped, got %+v", unseen)
+	}
+	var got []string
+	for _, ref := range recentAuditLogEntries(recent, unseen, []string{"repo-guard[bot]"}, lastEntry) {
+		got = append(got, ref.ID)
+	}
+	if want := []string{"reported", "late"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected recent entries %v, got %v", want, got)
+	}
+}
@@ -14,23 +14,30 @@ import (
 	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
 	"github.com/palantir/go-githubapp/githubapp"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // GithubOrganizationReconciler reconciles a GithubOrganization object
 type GithubOrganizationReconciler struct {
 	client.Client
 	Scheme                  *runtime.Scheme
+	Recorder                events.EventRecorder
 	MaxConcurrentReconciles int
+	// WebhookEvents, if set, enqueues the objects affected by GitHub webhook events.
+	WebhookEvents <-chan event.GenericEvent
 }
 
 // +kubebuilder:rbac:groups=repo-guard.cloudoperators.dev,resources=githuborganizations,verbs=get;list;watch;create;update;patch;delete
@@ -116,6 +123,16 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 	// Update metrics to reflect current state at the beginning of reconcile
 	ghmetrics.SetGithubOrganizationMetrics(githubOrganization)
 
+	// a deleted organization waits for its GithubTeams and GithubTeamRepositories to
+	// clean up Github, which needs the organization
+	if !githubOrganization.DeletionTimestamp.IsZero() {
+		return r.reconcileOrganizationDeletion(ctx, req, githubOrganization)
+	}
+	if err = setCleanupFinalizer(ctx, r.Client, githubOrganization, true); err != nil {
+		l.Error(err, "error during updating the finalizer")
+		return reconcile.Result{}, err
+	}
+
 	// If the forceReconcile label is present, wipe the status first (via safeStatusUpdate so
 	// payload metrics stay accurate and the write is conflict-retried), then remove the label
 	// inside a RetryOnConflict loop.  Status is reset before the label is removed so that if
@@ -333,25 +350,48 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 	}
 
-	githubClient, ok := GithubClients[githubName]
+	githubClient, ok := GithubClients.Get(githubName)
 	if !ok {
 		l.Info("waiting for github to be initialized", "github", githubName)
+		return reconcile.Result{}, nil
+	}
+
+	// Discover the installation of the GitHub App when spec.installationID is not set.
+	// Token authentication does not use installations.
+	if githubOrganization.Spec.InstallationID == 0 && !github.UsesTokenAuth(githubClient) && installationDiscoveryDue(githubOrganization) {
+		id, found, derr := github.OrganizationInstallationID(ctx, githubClient, githubOrganizationName)
+		newStatus := githubOrganization.Status
+		applyInstallationDiscovery(&newStatus, githubOrganization.Generation, githubOrganizationName, id, found, derr)
+		if err := r.safeStatusUpdate(ctx, req, &newStatus, githubOrganization, githubName); err != nil {
+			l.Error(err, "error during status update")
+			return reconcile.Result{}, err
+		}
+		switch {
+		case derr != nil:
+			l.Error(derr, "error during discovering the installation of the github app", "org", githubOrganizationName)
+			return reconcile.Result{}, derr
+		case !found:
+			l.Info("github app is not installed on the organization", "org", githubOrganizationName)
+			return reconcile.Result{RequeueAfter: installationDiscoveryRetryInterval}, nil
+		}
+		l.Info("installation of the github app discovered, resource will be reconciled", "org", githubOrganizationName, "installationID", id)
 		return reconcile.Result{RequeueAfter: time.Second}, nil
 	}
+	installationID := githubOrganization.ResolvedInstallationID()
 
-	reposProvider, err := github.NewRepositoryProvider(githubClient, githubName, githubOrganizationName, githubOrganization.Spec.InstallationID)
+	reposProvider, err := github.NewRepositoryProvider(githubClient, githubName, githubOrganizationName, installationID)
 	if err != nil {
 		l.Error(err, "error during creating the repository provider")
 		return reconcile.Result{}, err
 	}
 
-	organizationsProvider, err := github.NewOrganizationProvider(githubClient, githubName, githubOrganizationName, githubOrganization.Spec.InstallationID)
+	organizationsProvider, err := github.NewOrganizationProvider(githubClient, githubName, githubOrganizationName, installationID)
 	if err != nil {
 		l.Error(err, "error during creating the organizations provider")
 		return reconcile.Result{}, err
 	}
 
-	teamsProvider, err := github.NewTeamsProvider(githubClient, githubName, githubOrganizationName, githubOrganization.Spec.InstallationID)
+	teamsProvider, err := github.NewTeamsProvider(githubClient, githubName, githubOrganizationName, installationID, emuShortcode(githubInstance, githubOrganization))
 	if err != nil {
 		l.Error(err, "error during creating the teams provider")
 		return reconcile.Result{}, err
@@ -484,6 +524,7 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 			l.Error(err, "error during extending github members with greenhouse ids")
 			return reconcile.Result{}, err
 		}
+		ownerRenames := loginRenames(githubOrganization.Status.OrganizationOwners, ownerListExtended)
 		if !elementsMatch(githubOrganization.Status.OrganizationOwners, ownerListExtended) {
 			l.Info("organization owner list will be updated")
 			githubOrganization.Status.OrganizationOwners = ownerListExtended
@@ -507,6 +548,8 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 				return reconcile.Result{}, err
 			}
 			latest.Status.OrganizationOwners = githubOrganization.Status.OrganizationOwners
+			// Renamed owners keep their operations under the new login.
+			renameOperationUsers(latest.Status.Operations.OrganizationOwnerOperations, ownerRenames)
 			latest.Status.Teams = githubOrganization.Status.Teams
 			// Also compact bulky repo lists; this is what triggered the
 			// updateRequired flag at the repo-list compaction block above.
@@ -517,6 +560,10 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 				l.Error(err, "error during status update")
 				return reconcile.Result{}, err
 			}
+			for _, rename := range ownerRenames {
+				l.Info("github login of organization owner renamed", "uid", rename.uid, "old", rename.oldLogin, "new", rename.newLogin)
+			}
+			recordLoginRenames(r.Recorder, githubOrganization, ownerRenames)
 			return reconcile.Result{}, nil
 		}
 
@@ -582,7 +629,7 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 			}
 		}
 		if syncTeams {
-			teamsFromKubernetes, err := r.teamsFromGithubTeams(ctx, githubOrganization)
+			teamsFromKubernetes, teamRenames, err := r.teamsFromGithubTeams(ctx, githubOrganization)
 			if err != nil {
 				l.Error(err, "error in getting github teams for the organization")
 				githubOrganization.Status.OrganizationStatus = v1.GithubOrganizationStateFailed
@@ -601,7 +648,7 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 				}
 				return reconcile.Result{}, nil
 			}
-			statusChanged, newStatus := githubOrganization.TeamChangeCalculator(teamsFromKubernetes)
+			statusChanged, newStatus := githubOrganization.TeamChangeCalculator(teamsFromKubernetes, teamRenames)
 			if statusChanged {
 				l.Info("status update for organization due to team change calculation")
 				err := r.safeStatusUpdate(ctx, req, newStatus, githubOrganization, githubName)
@@ -820,7 +867,15 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 		if githubOrganization.Labels != nil {
 			removeRepoCollabLabelValue = githubOrganization.Labels[GITHUB_ORG_LABEL_REMOVE_REPOSITORY_DIRECT_COLLABORATOR]
 		}
-		if removeRepoCollabLabelValue == GITHUB_ORG_LABEL_REMOVE_REPOSITORY_DIRECT_COLLABORATOR_ENABLED_VALUE || removeRepoCollabLabelValue == GITHUB_ORG_LABEL_REMOVE_REPOSITORY_DIRECT_COLLABORATOR_DRYRUN_VALUE {
+		collaboratorScan := removeRepoCollabLabelValue == GITHUB_ORG_LABEL_REMOVE_REPOSITORY_DIRECT_COLLABORATOR_ENABLED_VALUE || removeRepoCollabLabelValue == GITHUB_ORG_LABEL_REMOVE_REPOSITORY_DIRECT_COLLABORATOR_DRYRUN_VALUE
+		if collaboratorScan {
+			// The scan costs one call per repository: keep the rest of the rate limit for team and owner changes.
+			if resetAt, deferred := github.DeferLowPriority(githubClient, installationID, github.RateLimitResourceCore, time.Now()); deferred {
+				l.Info("rate limit budget low; repo-collaborator scan deferred", "until", resetAt)
+				collaboratorScan = false
+			}
+		}
+		if collaboratorScan {
 			// Build owner login list from extended owner data
 			orgOwnerLogins := make([]string, 0, len(ownerList))
 			for _, o := range ownerList {
@@ -1100,7 +1155,10 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 
 		// GithubTeamOperations
-		for i, githubTeamOperation := range newStatus.Operations.GithubTeamOperations {
+		teams := r.githubTeamHierarchy(ctx, githubOrganization, teamsProvider, newStatus.Operations.GithubTeamOperations)
+		removedTeams := pendingTeamRemovals(newStatus.Operations.GithubTeamOperations)
+		for _, i := range orderGithubTeamOperations(newStatus.Operations.GithubTeamOperations, teams.parents, teams.children) {
+			githubTeamOperation := newStatus.Operations.GithubTeamOperations[i]
 
 			if githubTeamOperation.State == v1.GithubTeamOperationStatePending {
 
@@ -1115,7 +1173,8 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 						failed = false
 
 					} else {
-						err := teamsProvider.AddTeam(ctx, githubTeamOperation.Team)
+						team := strings.ToLower(githubTeamOperation.Team)
+						err := teamsProvider.AddTeam(ctx, githubTeamOperation.Team, teams.parents[team], teams.settings[team])
 						if err != nil {
 							l.Error(err, "error during adding team", "team", githubTeamOperation.Team)
 							newStatus.Operations.GithubTeamOperations[i].State = v1.GithubTeamStateFailed
@@ -1141,6 +1200,20 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 						newStatus.Operations.GithubTeamOperations[i].Timestamp = metav1.Now()
 						statusChanged = true
 						failed = false
+					} else if err := teams.childErrs[strings.ToLower(githubTeamOperation.Team)]; err != nil {
+						l.Error(err, "error during listing child teams", "team", githubTeamOperation.Team)
+						newStatus.Operations.GithubTeamOperations[i].State = v1.GithubTeamOperationStateFailed
+						newStatus.Operations.GithubTeamOperations[i].Error = err.Error()
+						newStatus.Operations.GithubTeamOperations[i].Timestamp = metav1.Now()
+						statusChanged = true
+						failed = true
+					} else if kept := keptChildTeams(githubTeamOperation.Team, teams.children, removedTeams); len(kept) > 0 {
+						// Github deletes the child teams of a deleted team as well
+						l.Info("removing team skipped: it has child teams that are kept", "team", githubTeamOperation.Team, "childTeams", kept)
+						newStatus.Operations.GithubTeamOperations[i].State = v1.GithubTeamOperationStateSkipped
+						newStatus.Operations.GithubTeamOperations[i].Error = "team has child teams that are kept: " + strings.Join(kept, ", ")
+						newStatus.Operations.GithubTeamOperations[i].Timestamp = metav1.Now()
+						statusChanged = true
 					} else {
 						err := teamsProvider.RemoveTeam(ctx, githubTeamOperation.Team)
 						if err != nil {
@@ -1507,35 +1580,94 @@ func (r *GithubOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.R
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *GithubOrganizationReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		For(&v1.GithubOrganization{}).
 		Watches(&v1.GithubTeam{}, handler.EnqueueRequestsFromMapFunc(r.githubTeamToGithubOrganizationAsOrganizationOwner)).
 		Watches(&v1.GithubTeamRepository{}, handler.EnqueueRequestsFromMapFunc(r.githubTeamRepositoryToGithubOrganization)).
-		Complete(r)
+		WatchesRawSource(githubClientSource(githubOrganizationsOfGithub(r.Client, nil)))
+	if r.WebhookEvents != nil {
+		b = b.WatchesRawSource(source.Channel(r.WebhookEvents, &handler.EnqueueRequestForObject{}))
+	}
+	return b.Complete(r)
 }
 
-func (r *GithubOrganizationReconciler) teamsFromGithubTeams(ctx context.Context, githubOrganization *v1.GithubOrganization) ([]string, error) {
+// teamsFromGithubTeams returns the teams of the GithubTeams of githubOrganization and
+// the renames of the GithubTeams whose team is renamed, keyed by the lower-cased slug
+// of the team in Github.
+func (r *GithubOrganizationReconciler) teamsFromGithubTeams(ctx context.Context, githubOrganization *v1.GithubOrganization) ([]string, map[string]string, error) {
 
 	teamList := make([]string, 0)
+	renames := make(map[string]string)
 	githubTeamList := &v1.GithubTeamList{}
 	l := log.FromContext(ctx)
 
 	err := r.List(context.Background(), githubTeamList)
 	if err != nil {
 		l.Error(err, "failed to list GithubTeams")
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, team := range githubTeamList.Items {
+		if cleansUpGithub(&team, team.Spec.DeletionPolicy) {
+			continue
+		}
 		if team.Spec.Organization == githubOrganization.Spec.Organization && team.Spec.Github == githubOrganization.Spec.Github {
 			teamList = append(teamList, team.Spec.Team)
+			if from := team.PendingRename(); from != "" {
+				renames[strings.ToLower(from)] = team.Spec.Team
+			}
 		}
 	}
-	return teamList, nil
+	return teamList, renames, nil
 
 }
 
+// orgTeamHierarchy is the input of the team operations of an organization. All maps
+// are keyed by lower-cased team names.
+type orgTeamHierarchy struct {
+	// parents and settings are the desired ones of the GithubTeams.
+	parents  map[string]string
+	settings map[string]github.TeamSettings
+	// children are the child teams in Github of the teams with a pending remove operation.
+	children map[string][]string
+	// childErrs are the errors during listing the child teams of a team.
+	childErrs map[string]error
+}
+
+// githubTeamHierarchy returns the hierarchy the team operations of githubOrganization
+// are applied in. Without the GithubTeams, teams are created at the top level with
+// default settings; the GithubTeamReconciler corrects them.
+func (r *GithubOrganizationReconciler) githubTeamHierarchy(ctx context.Context, githubOrganization *v1.GithubOrganization, teamsProvider github.TeamsProvider, operations []v1.GithubTeamOperation) orgTeamHierarchy {
+	hierarchy := orgTeamHierarchy{
+		parents:   make(map[string]string),
+		settings:  make(map[string]github.TeamSettings),
+		children:  make(map[string][]string),
+		childErrs: make(map[string]error),
+	}
+	githubTeamList := &v1.GithubTeamList{}
+	if err := r.List(ctx, githubTeamList); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list GithubTeams: teams are created at the top level")
+	} else {
+		hierarchy.parents = githubTeamParents(githubTeamList.Items, githubOrganization.Spec.Github, githubOrganization.Spec.Organization)
+		for _, team := range githubTeamList.Items {
+			if team.Spec.Github == githubOrganization.Spec.Github && team.Spec.Organization == githubOrganization.Spec.Organization {
+				hierarchy.settings[strings.ToLower(team.Spec.Team)] = desiredTeamSettings(team.Spec)
+			}
+		}
+	}
+
+	for team := range pendingTeamRemovals(operations) {
+		childTeams, err := teamsProvider.ChildTeams(ctx, team)
+		if err != nil {
+			hierarchy.childErrs[team] = err
+			continue
+		}
+		hierarchy.children[team] = childTeams
+	}
+	return hierarchy
+}
+
 func (r *GithubOrganizationReconciler) ownersFromGithubTeams(ctx context.Context, githubOrganization *v1.GithubOrganization) ([]v1.Member, bool, error) {
 
 	ownerMap := make(map[string]v1.Member, 0)
@@ -1591,6 +1723,9 @@ func (r *GithubOrganizationReconciler) GithubTeamRepositoryListByOrganization(ct
 	githubTeamRepositoryListFiltered := make([]v1.GithubTeamRepository, 0)
 
 	for _, githubTeamRepository := range list.Items {
+		if cleansUpGithub(&githubTeamRepository, githubTeamRepository.Spec.DeletionPolicy) {
+			continue
+		}
 		if githubTeamRepository.Spec.Github == github && githubTeamRepository.Spec.Organization == organization {
 			githubTeamRepositoryListFiltered = append(githubTeamRepositoryListFiltered, githubTeamRepository)
 		}
@@ -1662,6 +1797,54 @@ func (r *GithubOrganizationReconciler) githubTeamRepositoryToGithubOrganization(
 
 }
 
+// installationDiscoveryRetryInterval is how long to wait before looking up the
+// installation again for an organization the GitHub App is not installed on.
+const installationDiscoveryRetryInterval = 5 * time.Minute
+
+// installationDiscoveryDue reports whether the installation of the GitHub App has to be
+// looked up: it is not known yet, or the spec changed since it was discovered.
+func installationDiscoveryDue(org *v1.GithubOrganization) bool {
+	if org.Status.InstallationID == 0 {
+		return true
+	}
+	cond := meta.FindStatusCondition(org.Status.Conditions, v1.GITHUB_ORG_CONDITION_APP_INSTALLED)
+	return cond == nil || cond.ObservedGeneration != org.Generation
+}
+
+// applyInstallationDiscovery records the outcome of an installation lookup in status.
+// A failed lookup keeps a previously discovered installation.
+func applyInstallationDiscovery(status *v1.GithubOrganizationStatus, generation int64, organization string, id int64, found bool, err error) {
+	condition := metav1.Condition{
+		Type:               v1.GITHUB_ORG_CONDITION_APP_INSTALLED,
+		ObservedGeneration: generation,
+	}
+	switch {
+	case err != nil:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = v1.GITHUB_ORG_REASON_DISCOVERY_FAILED
+		condition.Message = err.Error()
+	case !found:
+		status.InstallationID = 0
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1.GITHUB_ORG_REASON_APP_NOT_INSTALLED
+		condition.Message = fmt.Sprintf("app not installed: the GitHub App is not installed on organization %s; install it or set spec.installationID", organization)
+		status.OrganizationStatus = v1.GithubOrganizationStateFailed
+		status.OrganizationStatusError = condition.Message
+		status.OrganizationStatusTimestamp = metav1.Now()
+	default:
+		if previous := meta.FindStatusCondition(status.Conditions, v1.GITHUB_ORG_CONDITION_APP_INSTALLED); previous != nil && previous.Reason == v1.GITHUB_ORG_REASON_APP_NOT_INSTALLED && status.OrganizationStatusError == previous.Message {
+			status.OrganizationStatus = ""
+			status.OrganizationStatusError = ""
+			status.OrganizationStatusTimestamp = metav1.Now()
+		}
+		status.InstallationID = id
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1.GITHUB_ORG_REASON_INSTALLATION_DISCOVERED
+		condition.Message = fmt.Sprintf("installation %d discovered", id)
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
 const GITHUB_ORG_LABEL_ADD_ORG_OWNER = "repo-guard.cloudoperators.dev/addOrganizationOwner"
 const GITHUB_ORG_LABEL_REMOVE_ORG_OWNER = "repo-guard.cloudoperators.dev/removeOrganizationOwner"
 const GITHUB_ORG_LABEL_ADD_REMOVE_ORG_OWNER_ENABLED_VALUE = "true"
@@ -1706,6 +1889,11 @@ const GITHUB_ORG_ANNOTATION_STATUS_PAYLOAD_TRUNCATED = "repo-guard.cloudoperator
 const GITHUB_ORG_LABEL_FORCE_RECONCILE = "repo-guard.cloudoperators.dev/forceReconcile"
 const GITHUB_ORG_LABEL_FORCE_RECONCILE_VALUE = "true"
 
+// Label that, when set to "true", checks the spec.emailVerification entries of all
+// GithubAccountLinks for this organization in one batch instead of once per link.
+const GITHUB_ORG_LABEL_BATCH_EMAIL_VERIFICATION = "repo-guard.cloudoperators.dev/batchEmailVerification"
+const GITHUB_ORG_LABEL_BATCH_EMAIL_VERIFICATION_ENABLED_VALUE = "true"
+
 // ttlExpired parses a duration string (e.g., "24h", "30m") and checks if since+TTL is before now.
 func ttlExpired(ttlStr string, since time.Time, now time.Time) (bool, error) {
 	d, err := time.ParseDuration(ttlStr)
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+// reconcileOrganizationDeletion deletes the GithubTeams and GithubTeamRepositories of
+// a deleted GithubOrganization with the Delete policy, and keeps the organization until
+// the ones being deleted have cleaned up Github, which needs the organization.
+func (r *GithubOrganizationReconciler) reconcileOrganizationDeletion(ctx context.Context, req ctrl.Request, githubOrganization *v1.GithubOrganization) (reconcile.Result, error) {
+	l := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(githubOrganization, v1.REPO_GUARD_FINALIZER) {
+		return reconcile.Result{}, nil
+	}
+
+	githubTeamList := &v1.GithubTeamList{}
+	if err := r.List(ctx, githubTeamList, client.InNamespace(githubOrganization.Namespace)); err != nil {
+		return reconcile.Result{}, err
+	}
+	githubTeamRepositoryList := &v1.GithubTeamRepositoryList{}
+	if err := r.List(ctx, githubTeamRepositoryList, client.InNamespace(githubOrganization.Namespace)); err != nil {
+		return reconcile.Result{}, err
+	}
+	teams := organizationGithubTeams(githubOrganization, githubTeamList.Items)
+	teamRepositories := organizationGithubTeamRepositories(githubOrganization, githubTeamRepositoryList.Items)
+
+	if githubOrganization.Spec.DeletionPolicy == v1.DeletionPolicyDelete {
+		for i := range teams {
+			if teams[i].DeletionTimestamp.IsZero() {
+				if err := r.Delete(ctx, &teams[i]); client.IgnoreNotFound(err) != nil {
+					return reconcile.Result{}, err
+				}
+				l.Info("github team of the deleted organization is deleted", "githubTeam", teams[i].Name)
+			}
+		}
+		for i := range teamRepositories {
+			if teamRepositories[i].DeletionTimestamp.IsZero() {
+				if err := r.Delete(ctx, &teamRepositories[i]); client.IgnoreNotFound(err) != nil {
+					return reconcile.Result{}, err
+				}
+				l.Info("github team repository of the deleted organization is deleted", "githubTeamRepository", teamRepositories[i].Name)
+			}
+		}
+	}
+
+	if pending := pendingOrganizationCleanups(githubOrganization.Spec.DeletionPolicy, teams, teamRepositories); len(pending) > 0 {
+		msg := "waiting for the cleanup of " + strings.Join(pending, ", ")
+		if githubOrganization.Status.OrganizationStatus != v1.GithubOrganizationStateDeleting || githubOrganization.Status.OrganizationStatusError != msg {
+			err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				latest := &v1.GithubOrganization{}
+				if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+					return err
+				}
+				latest.Status.OrganizationStatus = v1.GithubOrganizationStateDeleting
+				latest.Status.OrganizationStatusError = msg
+				latest.Status.OrganizationStatusTimestamp = metav1.Now()
+				return r.Client.Status().Update(ctx, latest)
+			})
+			if err != nil {
+				l.Error(err, "error during status update")
+				return reconcile.Result{}, err
+			}
+			githubOrganization.Status.OrganizationStatus = v1.GithubOrganizationStateDeleting
+			githubOrganization.Status.OrganizationStatusError = msg
+		}
+		return reconcile.Result{RequeueAfter: deletionRecheckInterval}, nil
+	}
+
+	return reconcile.Result{}, setCleanupFinalizer(ctx, r.Client, githubOrganization, false)
+}
+
+// organizationGithubTeams returns the GithubTeams of githubOrganization.
+func organizationGithubTeams(githubOrganization *v1.GithubOrganization, teams []v1.GithubTeam) []v1.GithubTeam {
+	var out []v1.GithubTeam
+	for _, team := range teams {
+		if team.Namespace == githubOrganization.Namespace &&
+			strings.EqualFold(team.Spec.Github, githubOrganization.Spec.Github) &&
+			strings.EqualFold(team.Spec.Organization, githubOrganization.Spec.Organization) {
+			out = append(out, team)
+		}
+	}
+	return out
+}
+
+// organizationGithubTeamRepositories returns the GithubTeamRepositories of githubOrganization.
+func organizationGithubTeamRepositories(githubOrganization *v1.GithubOrganization, teamRepositories []v1.GithubTeamRepository) []v1.GithubTeamRepository {
+	var out []v1.GithubTeamRepository
+	for _, teamRepository := range teamRepositories {
+		if teamRepository.Namespace == githubOrganization.Namespace &&
+			strings.EqualFold(teamRepository.Spec.Github, githubOrganization.Spec.Github) &&
+			strings.EqualFold(teamRepository.Spec.Organization, githubOrganization.Spec.Organization) {
+			out = append(out, teamRepository)
+		}
+	}
+	return out
+}
+
+// pendingOrganizationCleanups returns the GithubTeams and GithubTeamRepositories that
+// still clean up Github: those with the cleanup finalizer that are being deleted, or
+// that were just deleted with the Delete policy of their organization.
+func pendingOrganizationCleanups(policy v1.DeletionPolicy, teams []v1.GithubTeam, teamRepositories []v1.GithubTeamRepository) []string {
+	var pending []string
+	for _, team := range teams {
+		if controllerutil.ContainsFinalizer(&team, v1.REPO_GUARD_FINALIZER) && (policy == v1.DeletionPolicyDelete || !team.DeletionTimestamp.IsZero()) {
+			pending = append(pending, "GithubTeam "+team.Name)
+		}
+	}
+	for _, teamRepository := range teamRepositories {
+		if controllerutil.ContainsFinalizer(&teamRepository, v1.REPO_GUARD_FINALIZER) && (policy == v1.DeletionPolicyDelete || !teamRepository.DeletionTimestamp.IsZero()) {
+			pending = append(pending, "GithubTeamRepository "+teamRepository.Name)
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// GithubOrganizationEmailVerificationReconciler checks the spec.emailVerification entries of
+// all GithubAccountLinks for an organization in one pass, for organizations labeled with
+// GITHUB_ORG_LABEL_BATCH_EMAIL_VERIFICATION.
+type GithubOrganizationEmailVerificationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// batchEmailCheck is a due spec.emailVerification entry of one GithubAccountLink.
+type batchEmailCheck struct {
+	link string
+	uid  string
+	cfg  v1.EmailVerificationSpec
+}
+
+func batchEmailVerificationEnabled(org *v1.GithubOrganization) bool {
+	return org.Labels[GITHUB_ORG_LABEL_BATCH_EMAIL_VERIFICATION] == GITHUB_ORG_LABEL_BATCH_EMAIL_VERIFICATION_ENABLED_VALUE
+}
+
+func (r *GithubOrganizationEmailVerificationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	l := log.FromContext(ctx)
+	done := ghmetrics.StartReconcileTimer("GithubOrganizationEmailVerification")
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
+		} else if res.RequeueAfter > 0 {
+			result = "requeue"
+		}
+		done(result)
+	}()
+
+	org := &v1.GithubOrganization{}
+	if err = r.Get(ctx, req.NamespacedName, org); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("resource not found in kubernetes: reconcile is skipped")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !batchEmailVerificationEnabled(org) {
+		return ctrl.Result{}, nil
+	}
+
+	githubName := org.Spec.Github
+	var links v1.GithubAccountLinkList
+	if err = r.List(ctx, &links, client.MatchingFields{"spec.github": githubName}); err != nil {
+		l.Error(err, "listing GithubAccountLinks for batch email verification", "github", githubName)
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	due, next := planBatchEmailVerification(links.Items, org.Spec.Organization, now)
+	if len(due) == 0 {
+		return ctrl.Result{RequeueAfter: next}, nil
+	}
+
+	githubClient, ok := GithubClients.Get(githubName)
+	if !ok {
+		l.Info("waiting for github to be initialized", "github", githubName)
+		return ctrl.Result{}, nil
+	}
+	if org.ResolvedInstallationID() == 0 && !github.UsesTokenAuth(githubClient) {
+		l.Info("installation not resolved for batch email verification; retrying later", "org", org.Spec.Organization)
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+	if resetAt, deferred := github.DeferLowPriority(githubClient, org.ResolvedInstallationID(), github.RateLimitResourceGraphQL, now); deferred {
+		// keep the rate limit of the installation for team membership changes
+		l.Info("rate limit budget low; batch email verification deferred", "org", org.Spec.Organization, "until", resetAt)
+		return ctrl.Result{RequeueAfter: resetAt.Sub(now)}, nil
+	}
+	usersProvider, err := github.NewUsersProvider(githubClient, githubName, org.ResolvedInstallationID())
+	if err != nil {
+		l.Error(err, "error during creating the users provider")
+		return ctrl.Result{}, err
+	}
+
+	members, calls, err := usersProvider.OrgMembersVerifiedDomainEmails(ctx, org.Spec.Organization)
+	if err != nil {
+		ghmetrics.ObserveEmailVerificationBatch(githubName, org.Spec.Organization, 0, 0, calls)
+		l.Error(err, "error listing verified domain emails of organization members", "org", org.Spec.Organization)
+		return ctrl.Result{}, err
+	}
+	results, memberChecks := batchEmailVerificationResults(due, members, now)
+	ghmetrics.ObserveEmailVerificationBatch(githubName, org.Spec.Organization, len(due), memberChecks, calls)
+	l.Info("batch email verification complete", "org", org.Spec.Organization, "checks", len(due), "graphqlCalls", calls)
+
+	var errs []error
+	for i, check := range due {
+		if err := r.updateEmailVerificationResult(ctx, check.link, results[i]); err != nil {
+			errs = append(errs, err)
+		}
+		if _, n := emailVerificationDue(check.cfg, results[i], true, now); n > 0 && (next == 0 || n < next) {
+			next = n
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		l.Error(err, "error updating GithubAccountLink status after batch email verification")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: next}, nil
+}
+
+// planBatchEmailVerification returns the due spec.emailVerification entries for organization
+// across links, and the time until the earliest entry that is not yet due expires.
+func planBatchEmailVerification(links []v1.GithubAccountLink, organization string, now time.Time) ([]batchEmailCheck, time.Duration) {
+	var due []batchEmailCheck
+	var next time.Duration
+	for _, link := range links {
+		for _, cfg := range link.Spec.EmailVerification {
+			if cfg.Organization != organization {
+				continue
+			}
+			var prev v1.EmailVerificationStatus
+			hasPrev := false
+			for _, result := range link.Status.EmailVerification {
+				if result.Organization == organization {
+					prev, hasPrev = result, true
+					break
+				}
+			}
+			isDue, n := emailVerificationDue(cfg, prev, hasPrev, now)
+			if isDue {
+				due = append(due, batchEmailCheck{link: link.Name, uid: link.Spec.GithubUserID, cfg: cfg})
+				continue
+			}
+			if n > 0 && (next == 0 || n < next) {
+				next = n
+			}
+		}
+	}
+	return due, next
+}
+
+// batchEmailVerificationResults derives one result per check from the verified domain emails
+// of the organization members, and returns how many checks were for organization members.
+func batchEmailVerificationResults(checks []batchEmailCheck, members map[string][]string, now time.Time) ([]v1.EmailVerificationStatus, int) {
+	results := make([]v1.EmailVerificationStatus, 0, len(checks))
+	memberChecks := 0
+	for _, check := range checks {
+		status := v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NOT_PART_OF_ORG
+		if emails, isMember := members[check.uid]; isMember {
+			memberChecks++
+			status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO
+			if github.HasEmailInDomain(emails, check.cfg.Domain) {
+				status = v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED
+			}
+		}
+		results = append(results, v1.EmailVerificationStatus{
+			Organization: check.cfg.Organization,
+			Domain:       check.cfg.Domain,
+			Status:       status,
+			LastChecked:  metav1.NewTime(now),
+		})
+	}
+	return results, memberChecks
+}
+
+// updateEmailVerificationResult replaces the result for one organization in the status of a link.
+func (r *GithubOrganizationEmailVerificationReconciler) updateEmailVerificationResult(ctx context.Context, name string, result v1.EmailVerificationStatus) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubAccountLink{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, latest); err != nil {
+			return err
+		}
+		merged := make(map[string]v1.EmailVerificationStatus, len(latest.Status.EmailVerification)+1)
+		for _, existing := range latest.Status.EmailVerification {
+			merged[existing.Organization] = existing
+		}
+		merged[result.Organization] = result
+		updated := orderedEmailVerificationResults(latest.Spec.EmailVerification, merged)
+		if equality.Semantic.DeepEqual(updated, latest.Status.EmailVerification) {
+			return nil
+		}
+		latest.Status.EmailVerification = updated
+		return r.Status().Update(ctx, latest)
+	})
+	return client.IgnoreNotFound(err)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GithubOrganizationEmailVerificationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enabled := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		org, ok := o.(*v1.GithubOrganization)
+		return ok && batchEmailVerificationEnabled(org)
+	})
+
+	// Check new or changed links right away instead of waiting for the next batch.
+	mapLinkToOrganizations := func(ctx context.Context, obj client.Object) []reconcile.Request {
+		link, ok := obj.(*v1.GithubAccountLink)
+		if !ok || len(link.Spec.EmailVerification) == 0 {
+			return nil
+		}
+		var orgList v1.GithubOrganizationList
+		if err := r.List(ctx, &orgList); err != nil {
+			log.FromContext(ctx).Error(err, "listing GithubOrganizations for batch email verification")
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range orgList.Items {
+			org := &orgList.Items[i]
+			if org.Spec.Github != link.Spec.Github || !batchEmailVerificationEnabled(org) {
+				continue
+			}
+			for _, cfg := range link.Spec.EmailVerification {
+				if cfg.Organization == org.Spec.Organization {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: org.Namespace, Name: org.Name}})
+					break
+				}
+			}
+		}
+		return requests
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.GithubOrganization{}, builder.WithPredicates(enabled,
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Watches(&v1.GithubAccountLink{}, handler.EnqueueRequestsFromMapFunc(mapLinkToOrganizations),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("githuborganization-emailverification").
+		WatchesRawSource(githubClientSource(githubOrganizationsOfGithub(r.Client, batchEmailVerificationEnabled))).
+		Complete(r)
+}
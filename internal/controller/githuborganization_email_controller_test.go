@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func TestPlanBatchEmailVerification(t *testing.T) {
+	now := time.Now()
+	fresh := accountLink("com-fresh", "fresh", "1", "com")
+	fresh.Spec.EmailVerification = []v1.EmailVerificationSpec{{Organization: "org", Domain: "example.com", TTL: "1h"}}
+
+	recent := accountLink("com-recent", "recent", "2", "com")
+	recent.Spec.EmailVerification = []v1.EmailVerificationSpec{{Organization: "org", Domain: "example.com", TTL: "1h"}}
+	recent.Status.EmailVerification = []v1.EmailVerificationStatus{
+		{Organization: "org", Domain: "example.com", Status: "verified", LastChecked: metav1.NewTime(now.Add(-20 * time.Minute))},
+	}
+
+	otherOrg := accountLink("com-other", "other", "3", "com")
+	otherOrg.Spec.EmailVerification = []v1.EmailVerificationSpec{{Organization: "other-org", Domain: "example.com"}}
+
+	due, next := planBatchEmailVerification([]v1.GithubAccountLink{fresh, recent, otherOrg}, "org", now)
+	if len(due) != 1 || due[0].link != "com-fresh" || due[0].uid != "1" {
+		t.Fatalf("due = %+v, want only com-fresh", due)
+	}
+	if next != 40*time.Minute {
+		t.Errorf("next = %v, want 40m", next)
+	}
+}
+
+func TestBatchEmailVerificationResults(t *testing.T) {
+	now := time.Now()
+	cfg := v1.EmailVerificationSpec{Organization: "org", Domain: "example.com"}
+	checks := []batchEmailCheck{
+		{link: "verified", uid: "1", cfg: cfg},
+		{link: "other-domain", uid: "2", cfg: cfg},
+		{link: "no-emails", uid: "3", cfg: cfg},
+		{link: "outsider", uid: "4", cfg: cfg},
+	}
+	members := map[string][]string{
+		"1": {"one@Example.com"},
+		"2": {"two@example.org"},
+		"3": {},
+	}
+
+	results, memberChecks := batchEmailVerificationResults(checks, members, now)
+	if memberChecks != 3 {
+		t.Errorf("memberChecks = %d, want 3", memberChecks)
+	}
+	want := []string{
+		v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED,
+		v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO,
+		v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NO,
+		v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NOT_PART_OF_ORG,
+	}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Errorf("%s: status = %q, want %q", checks[i].link, results[i].Status, status)
+		}
+		if results[i].Organization != "org" || results[i].Domain != "example.com" || !results[i].LastChecked.Time.Equal(now) {
+			t.Errorf("%s: unexpected result %+v", checks[i].link, results[i])
+		}
+	}
+}
+
+func TestOrderedEmailVerificationResults(t *testing.T) {
+	spec := []v1.EmailVerificationSpec{
+		{Organization: "b", Domain: "example.com"},
+		{Organization: "a", Domain: "example.org"},
+		{Organization: "c", Domain: "example.net"},
+	}
+	results := map[string]v1.EmailVerificationStatus{
+		"a":       {Organization: "a", Domain: "example.org", Status: "verified"},
+		"b":       {Organization: "b", Domain: "example.com", Status: "no"},
+		"c":       {Organization: "c", Domain: "old.example.net", Status: "verified"},
+		"removed": {Organization: "removed", Domain: "example.com", Status: "verified"},
+	}
+
+	got := orderedEmailVerificationResults(spec, results)
+	if len(got) != 2 || got[0].Organization != "b" || got[1].Organization != "a" {
+		t.Errorf("got %+v, want results for b and a in spec order", got)
+	}
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func TestApplyInstallationDiscovery(t *testing.T) {
+	org := &v1.GithubOrganization{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	if !installationDiscoveryDue(org) {
+		t.Fatal("expected discovery to be due without an installation")
+	}
+
+	applyInstallationDiscovery(&org.Status, 2, "acme", 0, false, nil)
+	cond := meta.FindStatusCondition(org.Status.Conditions, v1.GITHUB_ORG_CONDITION_APP_INSTALLED)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != v1.GITHUB_ORG_REASON_APP_NOT_INSTALLED {
+		t.Fatalf("condition = %+v, want False/%s", cond, v1.GITHUB_ORG_REASON_APP_NOT_INSTALLED)
+	}
+	if org.Status.OrganizationStatus != v1.GithubOrganizationStateFailed || org.Status.OrganizationStatusError != cond.Message {
+		t.Errorf("status = %s/%q, want failed with the condition message", org.Status.OrganizationStatus, org.Status.OrganizationStatusError)
+	}
+
+	applyInstallationDiscovery(&org.Status, 2, "acme", 4242, true, nil)
+	if org.Status.InstallationID != 4242 || org.ResolvedInstallationID() != 4242 {
+		t.Errorf("installationID = %d, want 4242", org.Status.InstallationID)
+	}
+	if org.Status.OrganizationStatus != "" || org.Status.OrganizationStatusError != "" {
+		t.Errorf("not installed error was not cleared: %s/%q", org.Status.OrganizationStatus, org.Status.OrganizationStatusError)
+	}
+	if !meta.IsStatusConditionTrue(org.Status.Conditions, v1.GITHUB_ORG_CONDITION_APP_INSTALLED) {
+		t.Errorf("expected AppInstalled to be True: %+v", org.Status.Conditions)
+	}
+	if installationDiscoveryDue(org) {
+		t.Error("expected no discovery while the generation is unchanged")
+	}
+
+	applyInstallationDiscovery(&org.Status, 3, "acme", 0, false, errors.New("boom"))
+	cond = meta.FindStatusCondition(org.Status.Conditions, v1.GITHUB_ORG_CONDITION_APP_INSTALLED)
+	if cond.Status != metav1.ConditionUnknown || org.Status.InstallationID != 4242 {
+		t.Errorf("failed lookup: condition = %+v, installationID = %d; want Unknown and the previous installation", cond, org.Status.InstallationID)
+	}
+
+	org.Spec.InstallationID = 7
+	if org.ResolvedInstallationID() != 7 {
+		t.Errorf("ResolvedInstallationID = %d, want spec.installationID", org.ResolvedInstallationID())
+	}
+}
@@ -0,0 +1,339 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	goerrors "errors"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// defaultSAMLAccountLinkSyncInterval is used when spec.samlAccountLinks.interval is unset or invalid.
+const defaultSAMLAccountLinkSyncInterval = time.Hour
+
+// GithubOrganizationSAMLReconciler creates, updates and garbage-collects GithubAccountLinks
+// from the SAML external identities of GithubOrganizations that opt in via
+// spec.samlAccountLinks. Only links carrying the GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE label
+// with the organization's name are ever modified or deleted.
+type GithubOrganizationSAMLReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (r *GithubOrganizationSAMLReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	l := log.FromContext(ctx)
+	done := ghmetrics.StartReconcileTimer("GithubOrganizationSAML")
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
+		} else if res.RequeueAfter > 0 {
+			result = "requeue"
+		}
+		done(result)
+	}()
+
+	githubOrganization := &v1.GithubOrganization{}
+	if err = r.Get(ctx, req.NamespacedName, githubOrganization); err != nil {
+		if errors.IsNotFound(err) {
+			l.Info("resource not found in kubernetes: reconcile is skipped")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	cfg := githubOrganization.Spec.SAMLAccountLinks
+	if cfg == nil || !cfg.Enabled {
+		return reconcile.Result{}, nil
+	}
+
+	interval := defaultSAMLAccountLinkSyncInterval
+	if cfg.Interval != "" {
+		if d, perr := time.ParseDuration(cfg.Interval); perr == nil && d > 0 {
+			interval = d
+		} else {
+			l.Info("invalid SAML account link sync interval; using default", "value", cfg.Interval, "default", interval)
+		}
+	}
+
+	now := time.Now().UTC()
+	if st := githubOrganization.Status.SAMLAccountLinks; st != nil && !st.LastSync.IsZero() {
+		if next := st.LastSync.Add(interval); now.Before(next) {
+			return reconcile.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+
+	githubName := githubOrganization.Spec.Github
+	githubClient, ok := GithubClients.Get(githubName)
+	if !ok {
+		l.Info("waiting for github to be initialized", "github", githubName)
+		return reconcile.Result{}, nil
+	}
+
+	newStatus := &v1.SAMLAccountLinkSyncStatus{LastSync: metav1.NewTime(now)}
+	if githubOrganization.ResolvedInstallationID() == 0 && !github.UsesTokenAuth(githubClient) {
+		newStatus.Error = "installation ID is not set or discovered"
+		return reconcile.Result{RequeueAfter: interval}, r.updateSAMLStatus(ctx, req, newStatus)
+	}
+
+	identityProvider, err := github.NewIdentityProvider(githubClient, githubName, githubOrganization.Spec.Organization, githubOrganization.ResolvedInstallationID())
+	if err != nil {
+		l.Error(err, "error during creating the identity provider")
+		return reconcile.Result{}, err
+	}
+
+	identities, err := identityProvider.SAMLExternalIdentities(ctx)
+	if err != nil {
+		l.Error(err, "error during listing SAML external identities")
+		newStatus.Error = err.Error()
+		requeueAfter := interval
+		if t, ok := parseGitHubRateLimitReset(err.Error()); ok {
+			recordOrgRateLimitHit(err.Error(), t)
+			if t.After(now) {
+				requeueAfter = t.Sub(now)
+			}
+		}
+		return reconcile.Result{RequeueAfter: requeueAfter}, r.updateSAMLStatus(ctx, req, newStatus)
+	}
+
+	var linkList v1.GithubAccountLinkList
+	if err := r.List(ctx, &linkList, client.MatchingFields{"spec.github": githubName}); err != nil {
+		l.Error(err, "error during listing GithubAccountLinks")
+		return reconcile.Result{}, err
+	}
+
+	plan := planSAMLAccountLinks(githubName, githubOrganization.Name, identities, linkList.Items, cfg.StripNameIDDomain)
+	newStatus.Identities = plan.identities
+	newStatus.Conflicts = plan.conflicts
+
+	// An empty identity list most likely means the IdP is being reconfigured;
+	// never wipe all owned links because of it.
+	if plan.identities == 0 && len(plan.delete) > 0 {
+		l.Info("no SAML external identities returned: garbage collection of account links skipped", "owned", len(plan.delete))
+		newStatus.Error = "no SAML external identities returned; garbage collection skipped"
+		plan.delete = nil
+	}
+
+	var errs []error
+	// Delete first so that a user who switched GitHub accounts frees the link name
+	// before the link for the new account is created.
+	for i := range plan.delete {
+		link := &plan.delete[i]
+		if err := r.Delete(ctx, link); err != nil && !errors.IsNotFound(err) {
+			l.Error(err, "error during deleting GithubAccountLink", "link", link.Name)
+			errs = append(errs, err)
+			continue
+		}
+		l.Info("GithubAccountLink deleted: SAML external identity removed", "link", link.Name, "githubUserID", link.Spec.GithubUserID)
+		newStatus.Deleted++
+	}
+	for i := range plan.update {
+		link := &plan.update[i]
+		if err := r.Update(ctx, link); err != nil {
+			l.Error(err, "error during updating GithubAccountLink", "link", link.Name)
+			errs = append(errs, err)
+			continue
+		}
+		l.Info("GithubAccountLink updated from SAML external identity", "link", link.Name, "userID", link.Spec.GreenhouseUserID)
+		newStatus.Updated++
+	}
+	for i := range plan.create {
+		link := &plan.create[i]
+		if err := r.Create(ctx, link); err != nil {
+			if errors.IsAlreadyExists(err) {
+				l.Info("GithubAccountLink with the same name already exists: identity skipped", "link", link.Name)
+				newStatus.Conflicts++
+				continue
+			}
+			l.Error(err, "error during creating GithubAccountLink", "link", link.Name)
+			errs = append(errs, err)
+			continue
+		}
+		l.Info("GithubAccountLink created from SAML external identity", "link", link.Name, "userID", link.Spec.GreenhouseUserID)
+		newStatus.Created++
+	}
+	newStatus.Links = plan.owned - newStatus.Deleted + newStatus.Created
+	if err := goerrors.Join(errs...); err != nil {
+		newStatus.Error = err.Error()
+	}
+
+	if err := r.updateSAMLStatus(ctx, req, newStatus); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// updateSAMLStatus writes only status.samlAccountLinks so that the organization
+// reconciler's status fields are left untouched.
+func (r *GithubOrganizationSAMLReconciler) updateSAMLStatus(ctx context.Context, req ctrl.Request, status *v1.SAMLAccountLinkSyncStatus) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubOrganization{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status.SAMLAccountLinks = status
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+	}
+	return err
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GithubOrganizationSAMLReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Reconcile only organizations that opted in; status-only updates are ignored
+	// because the sync schedules itself via RequeueAfter.
+	pred := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		org, ok := o.(*v1.GithubOrganization)
+		return ok && org.Spec.SAMLAccountLinks != nil && org.Spec.SAMLAccountLinks.Enabled
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.GithubOrganization{}, builder.WithPredicates(pred, predicate.GenerationChangedPredicate{})).
+		Named("githuborganization-saml").
+		WatchesRawSource(githubClientSource(githubOrganizationsOfGithub(r.Client, func(org *v1.GithubOrganization) bool {
+			return org.Spec.SAMLAccountLinks != nil && org.Spec.SAMLAccountLinks.Enabled
+		}))).
+		Complete(r)
+}
+
+// samlAccountLinkPlan lists the changes needed to bring the GithubAccountLinks
+// owned by an organization in line with its SAML external identities.
+type samlAccountLinkPlan struct {
+	create []v1.GithubAccountLink
+	update []v1.GithubAccountLink
+	delete []v1.GithubAccountLink
+	// identities is the number of distinct GitHub users with a usable NameID.
+	identities int
+	// owned is the number of links owned by the organization before the changes.
+	owned int
+	// conflicts counts identities whose GitHub user already has a link not owned by the organization.
+	conflicts int
+}
+
+// planSAMLAccountLinks compares the external identities with the existing links
+// of a Github instance. Links not labelled with GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE=orgName
+// are never part of the update or delete lists.
+func planSAMLAccountLinks(githubName, orgName string, identities []github.ExternalIdentity, links []v1.GithubAccountLink, stripNameIDDomain bool) samlAccountLinkPlan {
+	var plan samlAccountLinkPlan
+
+	desired := make(map[string]string) // githubUserID -> userID
+	var order []string
+	for _, identity := range identities {
+		userID := samlLinkUserID(identity.NameID, stripNameIDDomain)
+		if userID == "" || identity.UID == 0 {
+			continue
+		}
+		uid := identity.UIDString()
+		if _, ok := desired[uid]; ok {
+			continue
+		}
+		desired[uid] = userID
+		order = append(order, uid)
+	}
+	plan.identities = len(order)
+
+	// A link not owned by the organization always wins: owned links for the same
+	// GitHub user are removed so that the user is never mapped twice.
+	existing := make(map[string]*v1.GithubAccountLink) // githubUserID -> link
+	for i := range links {
+		link := &links[i]
+		if link.Spec.Github != githubName {
+			continue
+		}
+		if link.Labels[v1.GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE] == orgName {
+			plan.owned++
+			if _, ok := existing[link.Spec.GithubUserID]; ok {
+				continue
+			}
+		}
+		existing[link.Spec.GithubUserID] = link
+	}
+	for i := range links {
+		link := &links[i]
+		if link.Spec.Github != githubName || link.Labels[v1.GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE] != orgName {
+			continue
+		}
+		_, wanted := desired[link.Spec.GithubUserID]
+		if !wanted || existing[link.Spec.GithubUserID] != link {
+			plan.delete = append(plan.delete, *link.DeepCopy())
+		}
+	}
+
+	for _, uid := range order {
+		userID := desired[uid]
+		link, ok := existing[uid]
+		if !ok {
+			plan.create = append(plan.create, v1.GithubAccountLink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   samlAccountLinkName(githubName, userID),
+					Labels: map[string]string{v1.GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE: orgName},
+				},
+				Spec: v1.GithubAccountLinkSpec{
+					GreenhouseUserID: userID,
+					GithubUserID:     uid,
+					Github:           githubName,
+				},
+			})
+			continue
+		}
+		if link.Labels[v1.GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE] != orgName {
+			plan.conflicts++
+			continue
+		}
+		if link.Spec.GreenhouseUserID != userID {
+			updated := link.DeepCopy()
+			updated.Spec.GreenhouseUserID = userID
+			plan.update = append(plan.update, *updated)
+		}
+	}
+	return plan
+}
+
+// samlLinkUserID derives the link user ID from a SAML NameID.
+func samlLinkUserID(nameID string, stripDomain bool) string {
+	userID := strings.TrimSpace(nameID)
+	if stripDomain {
+		if i := strings.Index(userID, "@"); i >= 0 {
+			userID = userID[:i]
+		}
+	}
+	return userID
+}
+
+// samlAccountLinkName builds a valid object name of the form <github>-<userID>.
+// Characters not allowed in object names are replaced by "-".
+func samlAccountLinkName(githubName, userID string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(githubName + "-" + userID) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	name := strings.Trim(b.String(), "-.")
+	if len(name) > 253 {
+		name = strings.Trim(name[:253], "-.")
+	}
+	return name
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+func samlLink(name, userID, uid, github, owner string) v1.GithubAccountLink {
+	link := v1.GithubAccountLink{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1.GithubAccountLinkSpec{GreenhouseUserID: userID, GithubUserID: uid, Github: github},
+	}
+	if owner != "" {
+		link.Labels = map[string]string{v1.GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE: owner}
+	}
+	return link
+}
+
+func linkNames(links []v1.GithubAccountLink) []string {
+	var names []string
+	for _, l := range links {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+func TestPlanSAMLAccountLinks(t *testing.T) {
+	const org = "com--my-org"
+	identities := []github.ExternalIdentity{
+		{NameID: "alice@example.com", Login: "alice", UID: 1},
+		{NameID: "bob@example.com", Login: "bob", UID: 2},
+		{NameID: "carol@example.com", Login: "carol", UID: 3},
+		{NameID: "dave@example.com", Login: "dave", UID: 4},
+		{NameID: "", Login: "noname", UID: 5},
+		{NameID: "alice-dup@example.com", Login: "alice", UID: 1},
+	}
+	links := []v1.GithubAccountLink{
+		// owned and unchanged
+		samlLink("com-alice", "alice", "1", "com", org),
+		// owned with stale user ID
+		samlLink("com-bob-old", "bob-old", "2", "com", org),
+		// hand-made link for carol must never be touched
+		samlLink("carol-by-hand", "carol", "3", "com", ""),
+		// owned link whose identity is gone
+		samlLink("com-eve", "eve", "6", "com", org),
+		// hand-made link for a user that is no longer in the IdP
+		samlLink("frank-by-hand", "frank", "7", "com", ""),
+		// owned by another organization
+		samlLink("com-dave", "dave", "4", "com", "com--other-org"),
+		// other github instance is ignored
+		samlLink("ghe-gina", "gina", "8", "ghe", org),
+	}
+
+	plan := planSAMLAccountLinks("com", org, identities, links, true)
+
+	if plan.identities != 4 {
+		t.Errorf("identities = %d, want 4", plan.identities)
+	}
+	if plan.owned != 3 {
+		t.Errorf("owned = %d, want 3", plan.owned)
+	}
+	if plan.conflicts != 2 {
+		t.Errorf("conflicts = %d, want 2 (carol hand-made, dave owned by other org)", plan.conflicts)
+	}
+	if got := linkNames(plan.create); len(got) != 0 {
+		t.Errorf("create = %v, want none", got)
+	}
+	if got := linkNames(plan.update); len(got) != 1 || got[0] != "com-bob-old" {
+		t.Fatalf("update = %v, want [com-bob-old]", got)
+	}
+	if plan.update[0].Spec.GreenhouseUserID != "bob" {
+		t.Errorf("updated userID = %q, want bob", plan.update[0].Spec.GreenhouseUserID)
+	}
+	if got := linkNames(plan.delete); len(got) != 1 || got[0] != "com-eve" {
+		t.Errorf("delete = %v, want [com-eve]", got)
+	}
+}
+
+func TestPlanSAMLAccountLinks_Create(t *testing.T) {
+	identities := []github.ExternalIdentity{{NameID: "Jane.Doe@Example.com", Login: "jdoe", UID: 42}}
+
+	plan := planSAMLAccountLinks("com", "com--my-org", identities, nil, false)
+
+	if len(plan.create) != 1 {
+		t.Fatalf("create = %v, want one link", linkNames(plan.create))
+	}
+	link := plan.create[0]
+	if link.Name != "com-jane.doe-example.com" {
+		t.Errorf("name = %q, want com-jane.doe-example.com", link.Name)
+	}
+	if link.Spec.GreenhouseUserID != "Jane.Doe@Example.com" || link.Spec.GithubUserID != "42" || link.Spec.Github != "com" {
+		t.Errorf("unexpected spec: %+v", link.Spec)
+	}
+	if link.Labels[v1.GITHUB_ACCOUNT_LINK_LABEL_SAML_SOURCE] != "com--my-org" {
+		t.Errorf("ownership label missing: %v", link.Labels)
+	}
+}
+
+func TestPlanSAMLAccountLinks_HandMadeLinkWins(t *testing.T) {
+	const org = "com--my-org"
+	identities := []github.ExternalIdentity{{NameID: "alice", Login: "alice", UID: 1}}
+	links := []v1.GithubAccountLink{
+		samlLink("com-alice", "alice", "1", "com", org),
+		samlLink("alice-by-hand", "alice", "1", "com", ""),
+	}
+
+	plan := planSAMLAccountLinks("com", org, identities, links, false)
+
+	if got := linkNames(plan.delete); len(got) != 1 || got[0] != "com-alice" {
+		t.Errorf("delete = %v, want [com-alice]", got)
+	}
+	if plan.conflicts != 1 {
+		t.Errorf("conflicts = %d, want 1", plan.conflicts)
+	}
+}
+
+func TestSAMLAccountLinkName(t *testing.T) {
+	tests := []struct {
+		github, userID, want string
+	}{
+		{"com", "jdoe", "com-jdoe"},
+		{"com", "J_Doe@example.com", "com-j-doe-example.com"},
+		{"com", "-trailing-", "com--trailing"},
+	}
+	for _, tt := range tests {
+		if got := samlAccountLinkName(tt.github, tt.userID); got != tt.want {
+			t.Errorf("samlAccountLinkName(%q, %q) = %q, want %q", tt.github, tt.userID, got, tt.want)
+		}
+	}
+}
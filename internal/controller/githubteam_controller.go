@@ -11,19 +11,21 @@ import (
 	"strings"
 	"time"
 
-	"github.com/palantir/go-githubapp/githubapp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	v1 "github.com/cloudoperators/repo-guard/api/v1"
 
@@ -38,10 +40,14 @@ import (
 type GithubTeamReconciler struct {
 	client.Client
 	Scheme                  *runtime.Scheme
+	Recorder                events.EventRecorder
 	MaxConcurrentReconciles int
+	// WebhookEvents, if set, enqueues the objects affected by GitHub webhook events.
+	WebhookEvents <-chan event.GenericEvent
 }
 
 // +kubebuilder:rbac:groups=greenhouse.sap,resources=teams,verbs=get;list;watch
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
 	l := log.FromContext(ctx)
 	done := ghmetrics.StartReconcileTimer("GithubTeam")
@@ -83,8 +89,18 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	// update metrics to reflect current state at the beginning of reconcile
 	ghmetrics.SetGithubTeamMetrics(githubTeam)
 
+	// a deleted team is only cleaned up in Github according to its deletion policy
+	if !githubTeam.DeletionTimestamp.IsZero() {
+		return r.reconcileTeamDeletion(ctx, req, githubTeam)
+	}
+	if err = setCleanupFinalizer(ctx, r.Client, githubTeam, githubTeam.Spec.DeletionPolicy.NeedsCleanup()); err != nil {
+		l.Error(err, "error during updating the finalizer")
+		return reconcile.Result{}, err
+	}
+
 	// If the forceReconcile label is present, wipe the status first, then remove the label,
 	// and requeue so the next reconcile starts with a clean slate regardless of any stuck state.
+	// The identity of the team in Github is kept, so that a pending rename is not lost.
 	// Both writes are wrapped in RetryOnConflict; the object is re-GET-ed between them so the
 	// label Update uses a current resourceVersion.  Status is reset before the label is removed
 	// so that if the label Update fails the trigger is not silently lost — the user can retry.
@@ -93,7 +109,7 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			if rerr := r.Get(ctx, req.NamespacedName, githubTeam); rerr != nil {
 				return rerr
 			}
-			githubTeam.Status = v1.GithubTeamStatus{}
+			githubTeam.Status = teamIdentityStatus(githubTeam.Status)
 			return r.Client.Status().Update(ctx, githubTeam)
 		}); err != nil {
 			l.Error(err, "failed to reset status after forceReconcile")
@@ -330,7 +346,6 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	// check for github instance
 	githubInstance := &v1.Github{}
-	var githubClient githubapp.ClientCreator
 	err = r.Get(ctx, types.NamespacedName{Name: githubName}, githubInstance)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -369,10 +384,10 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return reconcile.Result{}, nil
 		}
 	}
-	githubClient = GithubClients[githubName]
-	if githubClient == nil {
+	githubClient, ok := GithubClients.Get(githubName)
+	if !ok {
 		l.Info("waiting for github to be initialized", "github", githubName)
-		return reconcile.Result{RequeueAfter: time.Second}, nil
+		return reconcile.Result{}, nil
 	}
 
 	// check for github organization
@@ -457,13 +472,46 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	teamsProvider, err := github.NewTeamsProvider(githubClient, githubName, githubOrgName, githubOrganization.Spec.InstallationID)
+	if msg := teamSettingsValidationError(githubTeam.Spec); msg != "" {
+		l.Info("invalid team settings", "githubTeam", githubTeam.Name, "reason", msg)
+		githubTeam.Status.TeamStatus = v1.GithubTeamStateFailed
+		githubTeam.Status.TeamStatusError = msg
+		githubTeam.Status.TeamStatusTimestamp = metav1.Now()
+		err := r.Client.Status().Update(ctx, githubTeam)
+		if err != nil {
+			l.Error(err, "error during status update")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if msg := idpGroupValidationError(githubTeam.Spec); msg != "" {
+		l.Info("invalid idp group", "githubTeam", githubTeam.Name, "reason", msg)
+		githubTeam.Status.TeamStatus = v1.GithubTeamStateFailed
+		githubTeam.Status.TeamStatusError = msg
+		githubTeam.Status.TeamStatusTimestamp = metav1.Now()
+		err := r.Client.Status().Update(ctx, githubTeam)
+		if err != nil {
+			l.Error(err, "error during status update")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	installationID := githubOrganization.ResolvedInstallationID()
+	if installationID == 0 && !github.UsesTokenAuth(githubClient) {
+		l.Info("waiting for the installation of the github app to be discovered", "GithubOrganization", githubOrganizationName)
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	shortcode := emuShortcode(githubInstance, githubOrganization)
+	teamsProvider, err := github.NewTeamsProvider(githubClient, githubName, githubOrgName, installationID, shortcode)
 	if err != nil {
 		l.Error(err, "error during creating the teams provider")
 		return reconcile.Result{}, err
 	}
 
-	usersProvider, err := github.NewUsersProvider(githubClient, githubName, githubOrganization.Spec.InstallationID)
+	usersProvider, err := github.NewUsersProvider(githubClient, githubName, installationID)
 	if err != nil {
 		l.Error(err, "error during creating the users provider")
 		return reconcile.Result{}, err
@@ -471,6 +519,8 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	// pending means there are still waiting operations on Github side, otherwise check for teams and members in each side
 	isNoProvider := githubTeam.Spec.GreenhouseTeam == "" && githubTeam.Spec.ExternalMemberProvider == nil
+	// active GithubTeamMembershipGrants of the team; the team is requeued at their expiry
+	var membershipGrants []v1.GithubTeamMembershipGrant
 	setFailed := func(fetchErr error) (reconcile.Result, error) {
 		uerr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 			latest := &v1.GithubTeam{}
@@ -488,10 +538,34 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 		return reconcile.Result{}, nil
 	}
+	if githubTeam.Spec.ParentTeam != "" {
+		cycle, err := r.parentTeamCycle(ctx, githubTeam)
+		if err != nil {
+			l.Error(err, "error during checking the parent teams")
+			return reconcile.Result{}, err
+		}
+		if cycle != nil {
+			l.Info("parent teams form a cycle", "cycle", cycle)
+			// the cycle may be resolved by a change of another GithubTeam
+			res, err := setFailed(fmt.Errorf("parent teams form a cycle: %s", strings.Join(cycle, " -> ")))
+			if err == nil {
+				res.RequeueAfter = parentTeamCycleRecheckInterval
+			}
+			return res, err
+		}
+	}
+
 	if githubTeam.Status.TeamStatus != v1.GithubTeamStatePendingOperations {
 
 		l.Info("there are no pending operations, status check started")
 
+		// A changed spec.team renames the team recorded in status instead of creating a new one
+		if from := githubTeam.PendingRename(); from != "" {
+			if res, done, err := r.reconcileTeamRename(ctx, req, githubTeam, teamsProvider, from); done {
+				return res, err
+			}
+		}
+
 		// Check if there is a team in Github, If there is no team in Github -- create it first
 		organizationTeams, err := teamsProvider.List(ctx)
 		if err != nil {
@@ -520,13 +594,13 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 		organizationTeamFound := false
 		for _, ot := range organizationTeams {
-			if ot == githubTeamName {
+			if v1.SameTeam(ot, githubTeamName) {
 				organizationTeamFound = true
 			}
 		}
 		if !organizationTeamFound {
 			l.Info("team is not found in Github side, it will be created")
-			err := teamsProvider.AddTeam(ctx, githubTeamName)
+			err := teamsProvider.AddTeam(ctx, githubTeamName, githubTeam.Spec.ParentTeam, desiredTeamSettings(githubTeam.Spec))
 			if err != nil {
 				l.Error(err, "error during adding team to Github")
 				if t, ok := parseGitHubRateLimitReset(err.Error()); ok {
@@ -550,6 +624,27 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return reconcile.Result{RequeueAfter: time.Second}, nil
 		}
 
+		if res, done, err := r.recordTeamIdentity(ctx, req, githubTeam, teamsProvider); done {
+			return res, err
+		}
+		if githubTeam.Spec.ParentTeam != "" || githubTeam.Status.ParentTeam != "" {
+			if res, done, err := r.reconcileParentTeam(ctx, req, githubTeam, teamsProvider); done {
+				return res, err
+			}
+		}
+		if githubTeam.Spec.ManagesSettings() || len(githubTeam.Status.SettingsDrift) > 0 {
+			if res, done, err := r.reconcileTeamSettings(ctx, req, githubTeam, teamsProvider); done {
+				return res, err
+			}
+		}
+		// Teams linked with a group of the identity provider have their members synced
+		// by Github; they are only observed below, like the teams without a provider.
+		if githubTeam.Spec.IdPGroup != nil || githubTeam.Status.IdPGroup != nil {
+			if res, done, err := r.reconcileIdPGroup(ctx, req, githubTeam, teamsProvider); done {
+				return res, err
+			}
+		}
+
 		// If there is a team -- check for its members in Github
 		membersExtended, err := teamsProvider.MembersExtended(ctx, githubTeamName)
 		if err != nil {
@@ -580,21 +675,35 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			l.Error(err, "error during extending the members of the team in Github")
 			return reconcile.Result{}, err
 		}
+		membersExtendedWithGithubUsernames, err = withTeamRoles(ctx, teamsProvider, githubTeam, membersExtendedWithGithubUsernames)
+		if err != nil {
+			res, _, err := r.teamGithubError(ctx, req, "error during getting the maintainers of the team in Github", err)
+			return res, err
+		}
 
 		if !elementsMatch(githubTeam.Status.Members, membersExtendedWithGithubUsernames) {
 			l.Info("status.members will be updated", "current", githubTeam.Status.Members, "update", membersExtendedWithGithubUsernames)
+			// A member whose UID is known under another login was renamed on GitHub:
+			// carry its operations over to the new login instead of churning membership.
+			renames := loginRenames(githubTeam.Status.Members, membersExtendedWithGithubUsernames)
+			latest := &v1.GithubTeam{}
 			err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-				latest := &v1.GithubTeam{}
 				if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
 					return err
 				}
 				latest.Status.Members = membersExtendedWithGithubUsernames
+				renameOperationUsers(latest.Status.Operations, renames)
 				return r.Client.Status().Update(ctx, latest)
 			})
 			if err != nil {
 				l.Error(err, "error during status update")
 				return reconcile.Result{}, err
 			}
+			for _, rename := range renames {
+				l.Info("github login renamed", "uid", rename.uid, "old", rename.oldLogin, "new", rename.newLogin)
+			}
+			recordLoginRenames(r.Recorder, githubTeam, renames)
+			githubTeam.Status = latest.Status
 			// Do not return here; continue reconciliation to calculate desired state
 		}
 
@@ -644,10 +753,15 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 					return reconcile.Result{}, uerr
 				}
 			}
+			if githubTeam.Spec.IdPGroup != nil {
+				return reconcile.Result{RequeueAfter: idpGroupRecheckInterval}, nil
+			}
 			return reconcile.Result{}, nil
 		}
 
 		greenHouseTeamMemberList := make([]string, 0)
+		// memberProvider resolves spec.maintainers.group of external member providers
+		var memberProvider externalprovider.ExternalProvider
 
 		if githubTeam.Spec.GreenhouseTeam != "" {
 			// get the members from Greenhouse
@@ -762,6 +876,7 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 					return reconcile.Result{}, err
 				}
 				greenHouseTeamMemberList = userIDs
+				memberProvider = ldapProvider
 			}
 
 			if githubTeam.Spec.ExternalMemberProvider.GenericHTTP != nil {
@@ -829,6 +944,7 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 					return reconcile.Result{}, err
 				}
 				greenHouseTeamMemberList = userIDs
+				memberProvider = provider
 			}
 
 			// Static external member provider
@@ -895,6 +1011,7 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 					return reconcile.Result{}, err
 				}
 				greenHouseTeamMemberList = userIDs
+				memberProvider = provider
 			}
 		}
 
@@ -906,13 +1023,47 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		}
 
-		greenHouseTeamMemberListExtended, err := extendGreenhouseMembersWithGithubUsernames(ctx, greenHouseTeamMemberList, githubName, r.Client, usersProvider, requiredDomain, githubTeam.Spec.Organization)
+		greenHouseTeamMemberListExtended, err := extendGreenhouseMembersWithGithubUsernames(ctx, greenHouseTeamMemberList, githubName, r.Client, usersProvider, requiredDomain, githubTeam.Spec.Organization, shortcode)
 		if err != nil {
 			l.Error(err, "error during extending the members of the team in greenhouse team membership")
 			return reconcile.Result{}, err
 		}
 		l.Info("extended greenhouse members list", "members", greenHouseTeamMemberListExtended)
 
+		membershipGrants, err = r.teamMembershipGrants(ctx, githubTeam, metav1.Now())
+		if err != nil {
+			l.Error(err, "error during listing the membership grants of the team")
+			return reconcile.Result{}, err
+		}
+		if len(membershipGrants) > 0 {
+			granted, err := extendGreenhouseMembersWithGithubUsernames(ctx, membershipGrantUsers(membershipGrants), githubName, r.Client, usersProvider, requiredDomain, githubTeam.Spec.Organization, shortcode)
+			if err != nil {
+				l.Error(err, "error during extending the members of the membership grants")
+				return reconcile.Result{}, err
+			}
+			greenHouseTeamMemberListExtended = mergeGrantedMembers(greenHouseTeamMemberListExtended, granted)
+		}
+		if err := r.updateMembershipGrantsStatus(ctx, req, githubTeam, membershipGrantStatuses(membershipGrants)); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		if githubTeam.Spec.Maintainers != nil {
+			maintainerIDs, err := r.teamMaintainerIDs(ctx, githubTeam, memberProvider)
+			if err != nil {
+				l.Error(err, "error during getting the maintainers of the team")
+				if _, uerr := setFailed(fmt.Errorf("error during getting the maintainers of the team: %w", err)); uerr != nil {
+					return reconcile.Result{}, uerr
+				}
+				return reconcile.Result{}, err
+			}
+			maintainers, err := extendGreenhouseMembersWithGithubUsernames(ctx, maintainerIDs, githubName, r.Client, usersProvider, requiredDomain, githubTeam.Spec.Organization, shortcode)
+			if err != nil {
+				l.Error(err, "error during extending the maintainers of the team")
+				return reconcile.Result{}, err
+			}
+			greenHouseTeamMemberListExtended = applyMaintainerRoles(greenHouseTeamMemberListExtended, maintainers)
+		}
+
 		// "do not use internal usernames externally": If the flag is set,
 		// then the internal usernames will not be used in the external operations.
 		// This means if GreenhouseID == GithubUsername, we remove that member from the list.
@@ -983,6 +1134,59 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		}
 
+		// Users in the notfound state are skipped by ChangeCalculator. Re-resolve them
+		// on a per-team backoff schedule; resolved users have their notfound operation
+		// dropped so that ChangeCalculator queues a fresh add for them below.
+		if base, enabled := notFoundRecheckInterval(githubTeam.Labels); enabled {
+			now := time.Now().UTC()
+			stuck := notFoundUsers(githubTeam.Status.Operations)
+			if len(stuck) == 0 && githubTeam.Status.NotFoundRecheck != nil {
+				latest := &v1.GithubTeam{}
+				err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+						return err
+					}
+					if len(notFoundUsers(latest.Status.Operations)) > 0 {
+						return nil
+					}
+					latest.Status.NotFoundRecheck = nil
+					return r.Client.Status().Update(ctx, latest)
+				})
+				if err != nil {
+					l.Error(err, "error during status update")
+					return reconcile.Result{}, err
+				}
+				githubTeam.Status = latest.Status
+			} else if len(stuck) > 0 && !now.Before(notFoundRecheckNext(githubTeam.Status, base)) {
+				l.Info("re-checking users in notfound state", "users", stuck)
+				resolved, err := recheckNotFoundUsers(usersProvider, stuck)
+				if err != nil {
+					// Keep the schedule unchanged so the re-check is retried on the next reconcile.
+					l.Error(err, "error during re-checking notfound users")
+					ghmetrics.ObserveNotFoundRecheck(githubOrgName, githubTeamName, "error", len(stuck))
+				} else {
+					ghmetrics.ObserveNotFoundRecheck(githubOrgName, githubTeamName, "resolved", len(resolved))
+					ghmetrics.ObserveNotFoundRecheck(githubOrgName, githubTeamName, "notfound", len(stuck)-len(resolved))
+					latest := &v1.GithubTeam{}
+					err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+						if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+							return err
+						}
+						applyNotFoundRecheck(&latest.Status, resolved, base, now)
+						return r.Client.Status().Update(ctx, latest)
+					})
+					if err != nil {
+						l.Error(err, "error during status update")
+						return reconcile.Result{}, err
+					}
+					if len(resolved) > 0 {
+						l.Info("notfound users resolved on GitHub: add operations will be retried", "resolved", len(resolved))
+					}
+					githubTeam.Status = latest.Status
+				}
+			}
+		}
+
 		statusChanged, newStatus := githubTeam.ChangeCalculator(greenHouseTeamMemberListExtended)
 
 		if statusChanged {
@@ -1034,7 +1238,7 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				l.Error(err, "error during status update")
 				return reconcile.Result{}, err
 			}
-			return reconcile.Result{}, nil
+			return withMembershipGrantRequeue(reconcile.Result{}, membershipGrants), nil
 
 		} else {
 			// No diff from ChangeCalculator — team is already in desired state.
@@ -1170,81 +1374,31 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		l.Info("there are pending operations in the status")
 
 		newStatus := githubTeam.Status.DeepCopy()
-		statusChanged := false
-		failed := false
-		for i, userOperation := range newStatus.Operations {
-
-			if userOperation.State == v1.GithubUserOperationStatePending {
-
-				if userOperation.Operation == v1.GithubUserOperationTypeAdd {
-
-					// check whether action is allowed
-					if githubTeam.Labels != nil && githubTeam.Labels[GITHUB_TEAMS_LABEL_ADD_USER] != "" && githubTeam.Labels[GITHUB_TEAMS_LABEL_ADD_USER] != GITHUB_TEAMS_LABEL_ADD_REMOVE_USER_ENABLED_VALUE {
-						l.Info("adding users is not enabled for the team: operation skipped")
-						newStatus.Operations[i].State = v1.GithubUserOperationStateSkipped
-						newStatus.Operations[i].Timestamp = metav1.Now()
-						statusChanged = true
-					} else {
-						userFound, err := teamsProvider.AddUser(ctx, githubTeamName, userOperation.User)
-						if !userFound {
-							l.Info("user cannot be added to team: marking operation as notfound", "user", userOperation.User, "error", err)
-							newStatus.Operations[i].State = v1.GithubUserOperationStateNotFound
-							newStatus.Operations[i].Error = err.Error()
-							newStatus.Operations[i].Timestamp = metav1.Now()
-							statusChanged = true
-							// Don't set 'failed' to true — this is a terminal state, no retry
-						} else if err != nil {
-							l.Error(err, "error during adding user to the team", "user", userOperation.User, "team", githubTeamName)
-							newStatus.Operations[i].State = v1.GithubUserOperationStateFailed
-							newStatus.Operations[i].Error = err.Error()
-							newStatus.Operations[i].Timestamp = metav1.Now()
-							statusChanged = true
-							failed = true
-						} else {
-							l.Info("user is added to the team", "user", userOperation.User, "team", githubTeamName)
-							newStatus.Operations[i].State = v1.GithubUserOperationStateComplete
-							newStatus.Operations[i].Timestamp = metav1.Now()
-							statusChanged = true
-						}
-					}
-				}
-
-				if userOperation.Operation == v1.GithubUserOperationTypeRemove {
-
-					// check whether action is allowed
-					if githubTeam.Labels != nil && githubTeam.Labels[GITHUB_TEAMS_LABEL_REMOVE_USER] != "" && githubTeam.Labels[GITHUB_TEAMS_LABEL_REMOVE_USER] != GITHUB_TEAMS_LABEL_ADD_REMOVE_USER_ENABLED_VALUE {
-						l.Info("removing users is not enabled for the team: operation skipped")
-						newStatus.Operations[i].State = v1.GithubUserOperationStateSkipped
-						newStatus.Operations[i].Timestamp = metav1.Now()
-						statusChanged = true
-					} else {
-						err := teamsProvider.RemoveUser(ctx, githubTeamName, userOperation.User)
-						if err != nil {
-							l.Error(err, "error during removing user from the team", "user", userOperation.User, "team", githubTeamName)
-							newStatus.Operations[i].State = v1.GithubUserOperationStateFailed
-							newStatus.Operations[i].Error = err.Error()
-							newStatus.Operations[i].Timestamp = metav1.Now()
-							statusChanged = true
-							failed = true
-						} else {
-							l.Info("user is removed from the team", "user", userOperation.User, "team", githubTeamName)
-							newStatus.Operations[i].State = v1.GithubUserOperationStateComplete
-							newStatus.Operations[i].Timestamp = metav1.Now()
-							statusChanged = true
-							// Correctly reflect current members after removal
-							// Find the member in the list and remove it
-							lowerUser := strings.ToLower(userOperation.User)
-							for j, m := range newStatus.Members {
-								if strings.ToLower(m.GithubUsername) == lowerUser {
-									newStatus.Members = append(newStatus.Members[:j], newStatus.Members[j+1:]...)
-									break
-								}
-							}
-						}
-
-					}
+		statusChanged, failed, rateLimitErr := processTeamOperations(ctx, githubTeam, teamsProvider, githubTeamName, newStatus)
+		if rateLimitErr != nil {
+			// The operation that hit the limit and the ones after it stay pending; the
+			// ones processed before are kept so that they are not repeated.
+			t, _ := parseGitHubRateLimitReset(rateLimitErr.Error())
+			recordTeamRateLimitHit(rateLimitErr.Error(), t)
+			now := time.Now().UTC()
+			newStatus.TeamStatus = v1.GithubTeamStateRateLimited
+			newStatus.TeamStatusError = "error during processing pending operations: " + rateLimitErr.Error()
+			newStatus.TeamStatusTimestamp = metav1.Now()
+			if uerr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				latest := &v1.GithubTeam{}
+				if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+					return err
 				}
+				latest.Status = *newStatus
+				return r.Client.Status().Update(ctx, latest)
+			}); uerr != nil {
+				l.Error(uerr, "error during status update")
+				return reconcile.Result{}, uerr
 			}
+			if t.After(now) {
+				return reconcile.Result{RequeueAfter: t.Sub(now)}, nil
+			}
+			return reconcile.Result{RequeueAfter: time.Second}, nil
 		}
 		if statusChanged {
 			l.Info("status changed during operation processing")
@@ -1269,6 +1423,11 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				l.Error(err, "error during extending the members of the team in github membership")
 				return reconcile.Result{}, err
 			}
+			membersExtended, err = withTeamRoles(ctx, teamsProvider, githubTeam, membersExtended)
+			if err != nil {
+				l.Error(err, "error during listing the maintainers of the team in github", "team", githubTeamName)
+				return reconcile.Result{}, err
+			}
 			newStatus.Members = membersExtended
 
 			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -1287,17 +1446,31 @@ func (r *GithubTeamReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	return ctrl.Result{}, nil
+	// Come back when the notfound users of this team are due for their next re-check.
+	if base, enabled := notFoundRecheckInterval(githubTeam.Labels); enabled {
+		if next := notFoundRecheckNext(githubTeam.Status, base); !next.IsZero() {
+			if d := time.Until(next); d > 0 {
+				return withMembershipGrantRequeue(ctrl.Result{RequeueAfter: d}, membershipGrants), nil
+			}
+		}
+	}
+
+	return withMembershipGrantRequeue(ctrl.Result{}, membershipGrants), nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *GithubTeamReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		For(&v1.GithubTeam{}).
 		Watches(&greenhousesapv1alpha1.Team{}, handler.EnqueueRequestsFromMapFunc(r.greenhouseTeamToGithubTeam)).
 		Watches(&v1.GithubAccountLink{}, handler.EnqueueRequestsFromMapFunc(r.githubAccountLinkToGithubTeam)).
-		Complete(r)
+		Watches(&v1.GithubTeamMembershipGrant{}, handler.EnqueueRequestsFromMapFunc(membershipGrantToGithubTeam)).
+		WatchesRawSource(githubClientSource(githubTeamsOfGithub(r.Client)))
+	if r.WebhookEvents != nil {
+		b = b.WatchesRawSource(source.Channel(r.WebhookEvents, &handler.EnqueueRequestForObject{}))
+	}
+	return b.Complete(r)
 }
 
 func (r *GithubTeamReconciler) githubAccountLinkToGithubTeam(ctx context.Context, o client.Object) []reconcile.Request {
@@ -1345,7 +1518,8 @@ func (r *GithubTeamReconciler) greenhouseTeamToGithubTeam(ctx context.Context, o
 
 	reconcileList := make([]reconcile.Request, 0)
 	for _, team := range teamList.Items {
-		if o.GetName() == team.Spec.GreenhouseTeam {
+		maintainersTeam := team.Spec.GreenhouseTeam != "" && team.Spec.Maintainers != nil && o.GetName() == team.Spec.Maintainers.Group
+		if o.GetName() == team.Spec.GreenhouseTeam || maintainersTeam {
 			reconcileList = append(reconcileList, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: team.GetNamespace(), Name: team.GetName()}})
 		}
 	}
@@ -1354,7 +1528,11 @@ func (r *GithubTeamReconciler) greenhouseTeamToGithubTeam(ctx context.Context, o
 
 }
 
-func extendGreenhouseMembersWithGithubUsernames(ctx context.Context, members []string, githubInstance string, k8sClient client.Client, usersProvider github.UsersProvider, requiredDomain string, teamOrg string) ([]v1.Member, error) {
+// extendGreenhouseMembersWithGithubUsernames resolves the GitHub login of each member. For
+// organizations of an Enterprise Managed Users enterprise (emuShortcode set), members without a
+// GithubAccountLink are mapped to their "<handle>_<shortcode>" login, and members whose login is
+// not a managed user of the enterprise are dropped.
+func extendGreenhouseMembersWithGithubUsernames(ctx context.Context, members []string, githubInstance string, k8sClient client.Client, usersProvider github.UsersProvider, requiredDomain string, teamOrg string, emuShortcode string) ([]v1.Member, error) {
 	l := log.FromContext(ctx)
 
 	// Fetch all GithubAccountLink resources for this github instance once to build a lookup map
@@ -1379,6 +1557,7 @@ func extendGreenhouseMembersWithGithubUsernames(ctx context.Context, members []s
 	for _, greenhouseInput := range members {
 		ghID := greenhouseInput
 		githubUsername := greenhouseInput // default fallback
+		var githubUID int64
 
 		var link *v1.GithubAccountLink
 		inputLower := strings.ToLower(greenhouseInput)
@@ -1396,11 +1575,18 @@ func extendGreenhouseMembersWithGithubUsernames(ctx context.Context, members []s
 			} else if found {
 				githubUsername = fetched
 				ghID = link.Spec.GreenhouseUserID
+				githubUID, _ = strconv.ParseInt(gitID, 10, 64)
 			}
 		} else {
+			// With EMU, internal IDs map to managed user logins without a GithubAccountLink.
+			login := greenhouseInput
+			if emuShortcode != "" && !github.IsEMULogin(login, emuShortcode) {
+				login = github.EMULogin(login, emuShortcode)
+				githubUsername = login
+			}
 			// Case B: input might actually be a GitHub login; try to resolve numeric ID
-			if gitID, found, err := usersProvider.GithubIDByUsername(greenhouseInput); err != nil {
-				l.Error(err, "resolving GitHub ID by username", "login", greenhouseInput)
+			if gitID, found, err := usersProvider.GithubIDByUsername(login); err != nil {
+				l.Error(err, "resolving GitHub ID by username", "login", login)
 				return nil, err
 			} else if found {
 				// If we can map that GitHub ID back to a GreenhouseID via AccountLink, prefer it
@@ -1416,34 +1602,26 @@ func extendGreenhouseMembersWithGithubUsernames(ctx context.Context, members []s
 					return nil, err2
 				} else if ok2 {
 					githubUsername = fetched
+					githubUID, _ = strconv.ParseInt(gitID, 10, 64)
 				}
 			}
 		}
 
+		if emuShortcode != "" && !github.IsEMULogin(githubUsername, emuShortcode) {
+			l.Info("Member filtered since it is not a managed user of the enterprise", "member", ghID, "githubUsername", githubUsername, "shortcode", emuShortcode)
+			continue
+		}
+
 		// Optional filtering by verified-domain requirement
 		include := true
 		if requiredDomain != "" {
 			include = false
-			if link != nil && link.Annotations != nil {
-				if raw, ok := link.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS]; ok && raw != "" {
-					var results map[string]struct {
-						Domain    string `json:"domain"`
-						Status    string `json:"status"`
-						Verified  bool   `json:"verified"` // Legacy support
-						Timestamp string `json:"timestamp"`
-					}
-					if err := json.Unmarshal([]byte(raw), &results); err == nil {
-						if r, ok2 := results[teamOrg]; ok2 && r.Domain == requiredDomain {
-							// include if verified OR not yet in org
-							if r.Status == v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED ||
-								r.Status == v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NOT_PART_OF_ORG ||
-								(r.Status == "" && r.Verified) { // fallback for legacy result format
-								include = true
-							}
-						}
-					} else {
-						l.Error(err, "failed to unmarshal email check results", "member", ghID, "raw", raw)
-					}
+			if link != nil {
+				// include if verified OR not yet in org
+				if status, ok := emailVerificationResult(ctx, link, teamOrg, requiredDomain); ok &&
+					(status == v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED ||
+						status == v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_NOT_PART_OF_ORG) {
+					include = true
 				}
 			}
 		}
@@ -1452,6 +1630,7 @@ func extendGreenhouseMembersWithGithubUsernames(ctx context.Context, members []s
 			out = append(out, v1.Member{
 				GreenhouseID:   ghID,
 				GithubUsername: githubUsername,
+				GithubUID:      githubUID,
 			})
 		} else {
 			l.Info("Member filtered due to domain email verification requirement", "member", ghID, "org", teamOrg, "domain", requiredDomain)
@@ -1461,6 +1640,35 @@ func extendGreenhouseMembersWithGithubUsernames(ctx context.Context, members []s
 	return out, nil
 }
 
+// emailVerificationResult returns the verified-domain email check status of link for org and
+// domain. Results in status.emailVerification take precedence; links that were never migrated
+// fall back to the deprecated email-check-results annotation.
+func emailVerificationResult(ctx context.Context, link *v1.GithubAccountLink, org, domain string) (string, bool) {
+	for _, result := range link.Status.EmailVerification {
+		if result.Organization == org {
+			return result.Status, result.Domain == domain
+		}
+	}
+
+	raw, ok := link.Annotations[v1.GITHUB_ACCOUNT_LINK_EMAIL_CHECK_RESULTS]
+	if !ok || raw == "" {
+		return "", false
+	}
+	var results map[string]legacyEmailCheckResult
+	if err := json.Unmarshal([]byte(raw), &results); err != nil {
+		log.FromContext(ctx).Error(err, "failed to unmarshal email check results", "link", link.Name, "raw", raw)
+		return "", false
+	}
+	r, ok := results[org]
+	if !ok || r.Domain != domain {
+		return "", false
+	}
+	if r.Status == "" && r.Verified { // fallback for legacy result format
+		return v1.GITHUB_ACCOUNT_LINK_EMAIL_VERIFIED_DOMAIN_STATUS_VERIFIED, true
+	}
+	return r.Status, true
+}
+
 func extendGithubMembersWithGreenhouseIDs(ctx context.Context, members []github.GithubMember, githubInstance string, k8sClient client.Client) ([]v1.Member, error) {
 	l := log.FromContext(ctx)
 
@@ -1495,6 +1703,7 @@ func extendGithubMembersWithGreenhouseIDs(ctx context.Context, members []github.
 		out = append(out, v1.Member{
 			GreenhouseID:   greenhouseID,
 			GithubUsername: githubMember.Login,
+			GithubUID:      githubMember.UID,
 		})
 	}
 
@@ -1507,6 +1716,8 @@ const GITHUB_TEAMS_LABEL_DRY_RUN_ENABLED_VALUE = "true"
 const GITHUB_TEAMS_LABEL_ADD_USER = "repo-guard.cloudoperators.dev/addUser"
 const GITHUB_TEAMS_LABEL_REMOVE_USER = "repo-guard.cloudoperators.dev/removeUser"
 const GITHUB_TEAMS_LABEL_ADD_REMOVE_USER_ENABLED_VALUE = "true"
+const GITHUB_TEAMS_LABEL_UPDATE_SETTINGS = "repo-guard.cloudoperators.dev/updateSettings"
+const GITHUB_TEAMS_LABEL_CHANGE_ROLE = "repo-guard.cloudoperators.dev/changeRole"
 const GITHUB_TEAMS_LABEL_DISABLE_INTERNAL_USERNAMES = "repo-guard.cloudoperators.dev/disableInternalUsernames"
 const GITHUB_TEAMS_LABEL_DISABLE_INTERNAL_USERNAMES_VALUE = "true"
 
@@ -1525,12 +1736,148 @@ const GITHUB_TEAM_LABEL_COMPLETED_TTL = "repo-guard.cloudoperators.dev/completed
 const GITHUB_TEAM_LABEL_NOTFOUND_TTL = "repo-guard.cloudoperators.dev/notfoundTTL"
 const GITHUB_TEAM_LABEL_SKIPPED_TTL = "repo-guard.cloudoperators.dev/skippedTTL"
 
+// GITHUB_TEAM_LABEL_NOTFOUND_RECHECK_INTERVAL overrides the base interval of the
+// periodic re-check of users in the notfound state. The interval doubles after
+// every re-check up to 24h. Set it to "0" to disable the re-check for a team.
+const GITHUB_TEAM_LABEL_NOTFOUND_RECHECK_INTERVAL = "repo-guard.cloudoperators.dev/notfoundRecheckInterval"
+
 // Label that, when set to "true", causes the controller to wipe the resource status and requeue
 // for a clean full reconcile — bypassing any ratelimited/failed holdoff.
 // The label is removed by the controller after it is processed.
 const GITHUB_TEAM_LABEL_FORCE_RECONCILE = "repo-guard.cloudoperators.dev/forceReconcile"
 const GITHUB_TEAM_LABEL_FORCE_RECONCILE_VALUE = "true"
 
+// processTeamOperations applies the pending operations in newStatus to the team in
+// Github. It stops at the first operation that hits a rate limit, leaves it and the
+// remaining ones pending and returns the error of that operation, which carries the
+// time when Github accepts changes again.
+func processTeamOperations(ctx context.Context, githubTeam *v1.GithubTeam, teamsProvider github.TeamsProvider, githubTeamName string, newStatus *v1.GithubTeamStatus) (statusChanged, failed bool, rateLimitErr error) {
+	l := log.FromContext(ctx)
+	for i, userOperation := range newStatus.Operations {
+
+		if userOperation.State == v1.GithubUserOperationStatePending {
+
+			if userOperation.Operation == v1.GithubUserOperationTypeAdd {
+
+				// check whether action is allowed
+				if githubTeam.Labels != nil && githubTeam.Labels[GITHUB_TEAMS_LABEL_ADD_USER] != "" && githubTeam.Labels[GITHUB_TEAMS_LABEL_ADD_USER] != GITHUB_TEAMS_LABEL_ADD_REMOVE_USER_ENABLED_VALUE {
+					l.Info("adding users is not enabled for the team: operation skipped")
+					newStatus.Operations[i].State = v1.GithubUserOperationStateSkipped
+					newStatus.Operations[i].Timestamp = metav1.Now()
+					statusChanged = true
+				} else {
+					userFound, err := teamsProvider.AddUser(ctx, githubTeamName, userOperation.User, userOperation.Role)
+					if isRateLimitError(err) {
+						l.Info("rate limited during adding user to the team: operation stays pending", "user", userOperation.User, "team", githubTeamName, "error", err)
+						return statusChanged, failed, err
+					}
+					if !userFound {
+						l.Info("user cannot be added to team: marking operation as notfound", "user", userOperation.User, "error", err)
+						newStatus.Operations[i].State = v1.GithubUserOperationStateNotFound
+						newStatus.Operations[i].Error = err.Error()
+						newStatus.Operations[i].Timestamp = metav1.Now()
+						statusChanged = true
+						// Don't set 'failed' to true — this is a terminal state, no retry
+					} else if err != nil {
+						l.Error(err, "error during adding user to the team", "user", userOperation.User, "team", githubTeamName)
+						newStatus.Operations[i].State = v1.GithubUserOperationStateFailed
+						newStatus.Operations[i].Error = err.Error()
+						newStatus.Operations[i].Timestamp = metav1.Now()
+						statusChanged = true
+						failed = true
+					} else {
+						l.Info("user is added to the team", "user", userOperation.User, "team", githubTeamName)
+						newStatus.Operations[i].State = v1.GithubUserOperationStateComplete
+						newStatus.Operations[i].Timestamp = metav1.Now()
+						statusChanged = true
+					}
+				}
+			}
+
+			if userOperation.Operation == v1.GithubUserOperationTypeRole {
+
+				// check whether action is allowed
+				if githubTeam.Labels != nil && githubTeam.Labels[GITHUB_TEAMS_LABEL_CHANGE_ROLE] != "" && githubTeam.Labels[GITHUB_TEAMS_LABEL_CHANGE_ROLE] != GITHUB_TEAMS_LABEL_ADD_REMOVE_USER_ENABLED_VALUE {
+					l.Info("changing roles is not enabled for the team: operation skipped")
+					newStatus.Operations[i].State = v1.GithubUserOperationStateSkipped
+					newStatus.Operations[i].Timestamp = metav1.Now()
+					statusChanged = true
+				} else {
+					err := teamsProvider.SetRole(ctx, githubTeamName, userOperation.User, userOperation.Role)
+					if isRateLimitError(err) {
+						l.Info("rate limited during changing the role of user in the team: operation stays pending", "user", userOperation.User, "team", githubTeamName, "error", err)
+						return statusChanged, failed, err
+					}
+					if err != nil {
+						l.Error(err, "error during changing the role of user in the team", "user", userOperation.User, "role", userOperation.Role, "team", githubTeamName)
+						newStatus.Operations[i].State = v1.GithubUserOperationStateFailed
+						newStatus.Operations[i].Error = err.Error()
+						newStatus.Operations[i].Timestamp = metav1.Now()
+						statusChanged = true
+						failed = true
+					} else {
+						l.Info("role of user is changed in the team", "user", userOperation.User, "role", userOperation.Role, "team", githubTeamName)
+						newStatus.Operations[i].State = v1.GithubUserOperationStateComplete
+						newStatus.Operations[i].Timestamp = metav1.Now()
+						statusChanged = true
+					}
+				}
+			}
+
+			if userOperation.Operation == v1.GithubUserOperationTypeRemove {
+
+				// check whether action is allowed
+				if githubTeam.Labels != nil && githubTeam.Labels[GITHUB_TEAMS_LABEL_REMOVE_USER] != "" && githubTeam.Labels[GITHUB_TEAMS_LABEL_REMOVE_USER] != GITHUB_TEAMS_LABEL_ADD_REMOVE_USER_ENABLED_VALUE {
+					l.Info("removing users is not enabled for the team: operation skipped")
+					newStatus.Operations[i].State = v1.GithubUserOperationStateSkipped
+					newStatus.Operations[i].Timestamp = metav1.Now()
+					statusChanged = true
+				} else {
+					err := teamsProvider.RemoveUser(ctx, githubTeamName, userOperation.User)
+					if isRateLimitError(err) {
+						l.Info("rate limited during removing user from the team: operation stays pending", "user", userOperation.User, "team", githubTeamName, "error", err)
+						return statusChanged, failed, err
+					}
+					if err != nil {
+						l.Error(err, "error during removing user from the team", "user", userOperation.User, "team", githubTeamName)
+						newStatus.Operations[i].State = v1.GithubUserOperationStateFailed
+						newStatus.Operations[i].Error = err.Error()
+						newStatus.Operations[i].Timestamp = metav1.Now()
+						statusChanged = true
+						failed = true
+					} else {
+						l.Info("user is removed from the team", "user", userOperation.User, "team", githubTeamName)
+						newStatus.Operations[i].State = v1.GithubUserOperationStateComplete
+						newStatus.Operations[i].Timestamp = metav1.Now()
+						statusChanged = true
+						// Correctly reflect current members after removal
+						// Find the member in the list and remove it
+						lowerUser := strings.ToLower(userOperation.User)
+						for j, m := range newStatus.Members {
+							if strings.ToLower(m.GithubUsername) == lowerUser {
+								newStatus.Members = append(newStatus.Members[:j], newStatus.Members[j+1:]...)
+								break
+							}
+						}
+					}
+
+				}
+			}
+		}
+	}
+	return statusChanged, failed, nil
+}
+
+// isRateLimitError reports whether err is a primary or secondary rate limit of Github
+// with a known reset time.
+func isRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, ok := parseGitHubRateLimitReset(err.Error())
+	return ok
+}
+
 // recordTeamRateLimitHit records a rate-limit event for the team controller and observes the backoff.
 func recordTeamRateLimitHit(errMsg string, resetAt time.Time) {
 	limitType := "api"
@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+// reconcileTeamDeletion cleans up the team of a deleted GithubTeam in Github according
+// to spec.deletionPolicy and removes the cleanup finalizer once it is done.
+func (r *GithubTeamReconciler) reconcileTeamDeletion(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam) (reconcile.Result, error) {
+	l := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(githubTeam, v1.REPO_GUARD_FINALIZER) {
+		return reconcile.Result{}, nil
+	}
+
+	policy := githubTeam.Spec.DeletionPolicy
+	if !policy.NeedsCleanup() || githubTeam.Labels[GITHUB_TEAMS_LABEL_DRY_RUN] == GITHUB_TEAMS_LABEL_DRY_RUN_ENABLED_VALUE {
+		l.Info("github team is deleted without cleanup in Github", "deletionPolicy", policy)
+		return reconcile.Result{}, setCleanupFinalizer(ctx, r.Client, githubTeam, false)
+	}
+
+	teamsProvider, orphaned, err := r.cleanupTeamsProvider(ctx, githubTeam)
+	if err != nil {
+		l.Error(err, "error during creating the teams provider for the cleanup")
+		return reconcile.Result{}, err
+	}
+	if orphaned != "" {
+		l.Info("github team is deleted without cleanup in Github", "reason", orphaned)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(githubTeam, nil, corev1.EventTypeWarning, EVENT_REASON_CLEANUP_ORPHANED, "Delete",
+				"Team %s is left in Github: %s", githubTeam.Spec.Team, orphaned)
+		}
+		return reconcile.Result{}, setCleanupFinalizer(ctx, r.Client, githubTeam, false)
+	}
+	if teamsProvider == nil {
+		if err := r.setTeamDeleting(ctx, req, githubTeam, "waiting for the github client"); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: deletionRecheckInterval}, nil
+	}
+
+	team := githubTeam.Spec.Team
+	if from := githubTeam.PendingRename(); from != "" {
+		team = from
+	}
+	id, found, err := teamsProvider.TeamID(ctx, team)
+	if err != nil {
+		return r.teamDeletionError(ctx, req, githubTeam, "error during getting the team in Github", err)
+	}
+	// a team with another ID was created outside of the GithubTeam and is kept
+	if !found || (githubTeam.Status.TeamID != 0 && id != githubTeam.Status.TeamID) {
+		l.Info("team is not found in Github: nothing to clean up", "team", team)
+		return reconcile.Result{}, setCleanupFinalizer(ctx, r.Client, githubTeam, false)
+	}
+
+	switch policy {
+	case v1.DeletionPolicyDelete:
+		githubTeamList := &v1.GithubTeamList{}
+		if err := r.List(ctx, githubTeamList, client.InNamespace(githubTeam.Namespace)); err != nil {
+			return reconcile.Result{}, err
+		}
+		// deleting the team in Github deletes its child teams as well
+		if children := childGithubTeams(githubTeamList.Items, githubTeam); len(children) > 0 {
+			msg := "waiting for the child teams to be deleted: " + strings.Join(children, ", ")
+			if err := r.setTeamDeleting(ctx, req, githubTeam, msg); err != nil {
+				return reconcile.Result{}, err
+			}
+			return reconcile.Result{RequeueAfter: deletionRecheckInterval}, nil
+		}
+		if err := r.setTeamDeleting(ctx, req, githubTeam, ""); err != nil {
+			return reconcile.Result{}, err
+		}
+		if err := teamsProvider.RemoveTeam(ctx, team); err != nil {
+			return r.teamDeletionError(ctx, req, githubTeam, "error during deleting the team in Github", err)
+		}
+		l.Info("team is deleted in Github", "team", team)
+
+	case v1.DeletionPolicyRemoveMembersOnly:
+		if err := r.setTeamDeleting(ctx, req, githubTeam, ""); err != nil {
+			return reconcile.Result{}, err
+		}
+		members, err := teamsProvider.Members(ctx, team)
+		if err != nil {
+			return r.teamDeletionError(ctx, req, githubTeam, "error during getting the members of the team in Github", err)
+		}
+		for _, member := range members {
+			if err := teamsProvider.RemoveUser(ctx, team, member); err != nil {
+				return r.teamDeletionError(ctx, req, githubTeam, fmt.Sprintf("error during removing %s from the team in Github", member), err)
+			}
+		}
+		l.Info("members of the team are removed in Github", "team", team, "members", len(members))
+	}
+
+	return reconcile.Result{}, setCleanupFinalizer(ctx, r.Client, githubTeam, false)
+}
+
+// cleanupTeamsProvider returns the teams provider of the organization of githubTeam.
+// orphaned is the reason why Github cannot be cleaned up anymore; the provider is nil
+// while the Github client is not ready yet.
+func (r *GithubTeamReconciler) cleanupTeamsProvider(ctx context.Context, githubTeam *v1.GithubTeam) (teamsProvider github.TeamsProvider, orphaned string, err error) {
+	githubName := githubTeam.Spec.Github
+	githubInstance := &v1.Github{}
+	if err := r.Get(ctx, types.NamespacedName{Name: githubName}, githubInstance); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Sprintf("github %s not found", githubName), nil
+		}
+		return nil, "", err
+	}
+	githubOrganization := &v1.GithubOrganization{}
+	githubOrganizationName := fmt.Sprintf("%s--%s", strings.ToLower(githubName), strings.ToLower(githubTeam.Spec.Organization))
+	if err := r.Get(ctx, types.NamespacedName{Name: githubOrganizationName, Namespace: githubTeam.Namespace}, githubOrganization); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Sprintf("organization %s not found", githubOrganizationName), nil
+		}
+		return nil, "", err
+	}
+
+	githubClient, ok := GithubClients.Get(githubName)
+	if !ok {
+		return nil, "", nil
+	}
+	installationID := githubOrganization.ResolvedInstallationID()
+	if installationID == 0 && !github.UsesTokenAuth(githubClient) {
+		return nil, "", nil
+	}
+	teamsProvider, err = github.NewTeamsProvider(githubClient, githubName, githubTeam.Spec.Organization, installationID, emuShortcode(githubInstance, githubOrganization))
+	return teamsProvider, "", err
+}
+
+// childGithubTeams returns the names of the GithubTeams whose parent team is the team
+// of githubTeam.
+func childGithubTeams(teams []v1.GithubTeam, githubTeam *v1.GithubTeam) []string {
+	var children []string
+	for _, team := range teams {
+		if team.Name == githubTeam.Name && team.Namespace == githubTeam.Namespace {
+			continue
+		}
+		if team.Spec.Github != githubTeam.Spec.Github || team.Spec.Organization != githubTeam.Spec.Organization || team.Spec.ParentTeam == "" {
+			continue
+		}
+		if v1.SameTeam(team.Spec.ParentTeam, githubTeam.Spec.Team) {
+			children = append(children, team.Name)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// setTeamDeleting reports the deletion of githubTeam in its status, with msg as error.
+func (r *GithubTeamReconciler) setTeamDeleting(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, msg string) error {
+	if githubTeam.Status.TeamStatus == v1.GithubTeamStateDeleting && githubTeam.Status.TeamStatusError == msg {
+		return nil
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubTeam{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status.TeamStatus = v1.GithubTeamStateDeleting
+		latest.Status.TeamStatusError = msg
+		latest.Status.TeamStatusTimestamp = metav1.Now()
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+		return err
+	}
+	githubTeam.Status.TeamStatus = v1.GithubTeamStateDeleting
+	githubTeam.Status.TeamStatusError = msg
+	return nil
+}
+
+// teamDeletionError records err of a Github call during the cleanup in the status of
+// the team, which stays in the deleting state.
+func (r *GithubTeamReconciler) teamDeletionError(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, msg string, err error) (reconcile.Result, error) {
+	log.FromContext(ctx).Error(err, msg)
+	if uerr := r.setTeamDeleting(ctx, req, githubTeam, msg+": "+err.Error()); uerr != nil {
+		return reconcile.Result{}, uerr
+	}
+	if res, ok := deletionRetry(err); ok {
+		return res, nil
+	}
+	return reconcile.Result{}, err
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+// parentTeamCycleRecheckInterval is the requeue of a GithubTeam whose parent teams form
+// a cycle; the cycle may be resolved by a change of another GithubTeam.
+const parentTeamCycleRecheckInterval = 5 * time.Minute
+
+// parentTeamCycle returns the cycle formed by the parent teams of githubTeam, or nil.
+func (r *GithubTeamReconciler) parentTeamCycle(ctx context.Context, githubTeam *v1.GithubTeam) ([]string, error) {
+	githubTeamList := &v1.GithubTeamList{}
+	if err := r.List(ctx, githubTeamList); err != nil {
+		return nil, err
+	}
+	parents := githubTeamParents(githubTeamList.Items, githubTeam.Spec.Github, githubTeam.Spec.Organization)
+	// the cached list may not contain the latest spec of githubTeam yet
+	parents[strings.ToLower(githubTeam.Spec.Team)] = githubTeam.Spec.ParentTeam
+	return parentTeamCycle(githubTeam.Spec.Team, parents), nil
+}
+
+// reconcileParentTeam moves the team in Github below spec.parentTeam and records the
+// parent in status.parentTeam. done is set when the reconcile must return res and err.
+func (r *GithubTeamReconciler) reconcileParentTeam(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, teamsProvider github.TeamsProvider) (res reconcile.Result, done bool, err error) {
+	l := log.FromContext(ctx)
+	team := githubTeam.Spec.Team
+
+	current, err := teamsProvider.ParentTeam(ctx, team)
+	if err != nil {
+		return r.teamGithubError(ctx, req, "error during getting the parent team in Github", err)
+	}
+	if slug.Make(current) != slug.Make(githubTeam.Spec.ParentTeam) {
+		if err := teamsProvider.SetParentTeam(ctx, team, githubTeam.Spec.ParentTeam); err != nil {
+			return r.teamGithubError(ctx, req, "error during changing the parent team in Github", err)
+		}
+		l.Info("parent team is changed in Github", "from", current, "to", githubTeam.Spec.ParentTeam)
+		current = githubTeam.Spec.ParentTeam
+	}
+
+	if githubTeam.Status.ParentTeam != current {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			latest := &v1.GithubTeam{}
+			if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+				return err
+			}
+			latest.Status.ParentTeam = current
+			return r.Client.Status().Update(ctx, latest)
+		})
+		if err != nil {
+			l.Error(err, "error during status update")
+			return reconcile.Result{}, true, err
+		}
+		githubTeam.Status.ParentTeam = current
+	}
+	return reconcile.Result{}, false, nil
+}
+
+// githubTeamParents maps the lower-cased names of the GithubTeams of an organization
+// to their spec.parentTeam.
+func githubTeamParents(teams []v1.GithubTeam, githubName, organization string) map[string]string {
+	parents := make(map[string]string)
+	for _, team := range teams {
+		if team.Spec.Github == githubName && team.Spec.Organization == organization && team.Spec.ParentTeam != "" {
+			parents[strings.ToLower(team.Spec.Team)] = team.Spec.ParentTeam
+		}
+	}
+	return parents
+}
+
+// parentTeamCycle returns the chain of parent teams leading from team back to itself,
+// e.g. [a b a], or nil if the parents of team end at a top-level team.
+func parentTeamCycle(team string, parents map[string]string) []string {
+	chain := []string{team}
+	visited := map[string]bool{strings.ToLower(team): true}
+	for parent := parents[strings.ToLower(team)]; parent != ""; parent = parents[strings.ToLower(parent)] {
+		chain = append(chain, parent)
+		if strings.EqualFold(parent, team) {
+			return chain
+		}
+		// a cycle further up does not include team; it is reported by its own teams
+		if visited[strings.ToLower(parent)] {
+			return nil
+		}
+		visited[strings.ToLower(parent)] = true
+	}
+	return nil
+}
+
+// teamDepth returns the number of ancestors of team in parents. Cycles end the count.
+func teamDepth(team string, parents map[string]string) int {
+	depth := 0
+	visited := map[string]bool{strings.ToLower(team): true}
+	for parent := parents[strings.ToLower(team)]; parent != "" && !visited[strings.ToLower(parent)]; parent = parents[strings.ToLower(parent)] {
+		visited[strings.ToLower(parent)] = true
+		depth++
+	}
+	return depth
+}
+
+// teamHeight returns the number of levels of descendants of team in children that
+// are removed as well. Cycles end the count.
+func teamHeight(team string, children map[string][]string, removed map[string]bool, visited map[string]bool) int {
+	if visited[strings.ToLower(team)] {
+		return 0
+	}
+	visited[strings.ToLower(team)] = true
+	height := 0
+	for _, child := range children[strings.ToLower(team)] {
+		if !removed[strings.ToLower(child)] {
+			continue
+		}
+		if h := teamHeight(child, children, removed, visited) + 1; h > height {
+			height = h
+		}
+	}
+	return height
+}
+
+// pendingTeamRemovals returns the lower-cased teams with a pending remove operation.
+func pendingTeamRemovals(operations []v1.GithubTeamOperation) map[string]bool {
+	removed := make(map[string]bool)
+	for _, op := range operations {
+		if op.Operation == v1.GithubTeamOperationTypeRemove && op.State == v1.GithubTeamOperationStatePending {
+			removed[strings.ToLower(op.Team)] = true
+		}
+	}
+	return removed
+}
+
+// keptChildTeams returns the child teams of team that are not removed. Deleting a team
+// in Github deletes its child teams as well, so team must be kept while they are.
+func keptChildTeams(team string, children map[string][]string, removed map[string]bool) []string {
+	var kept []string
+	for _, child := range children[strings.ToLower(team)] {
+		if !removed[strings.ToLower(child)] {
+			kept = append(kept, child)
+		}
+	}
+	return kept
+}
+
+// orderGithubTeamOperations returns the indices of operations in the order they are
+// applied: team additions from the top of the hierarchy down, so that parents exist
+// before their children are created, then removals from the bottom up, so that child
+// teams are removed before their parents. parents is the desired hierarchy, children
+// the one observed in Github.
+func orderGithubTeamOperations(operations []v1.GithubTeamOperation, parents map[string]string, children map[string][]string) []int {
+	removed := pendingTeamRemovals(operations)
+	key := make([]int, len(operations))
+	for i, op := range operations {
+		switch op.Operation {
+		case v1.GithubTeamOperationTypeAdd:
+			key[i] = teamDepth(op.Team, parents)
+		case v1.GithubTeamOperationTypeRemove:
+			// removals follow all additions
+			key[i] = len(operations) + teamHeight(op.Team, children, removed, map[string]bool{})
+		}
+	}
+	order := make([]int, len(operations))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return key[order[a]] < key[order[b]] })
+	return order
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func TestParentTeamCycle(t *testing.T) {
+	cases := []struct {
+		name    string
+		team    string
+		parents map[string]string
+		want    []string
+	}{
+		{name: "top-level team", team: "a", parents: map[string]string{}, want: nil},
+		{name: "chain", team: "c", parents: map[string]string{"c": "b", "b": "a"}, want: nil},
+		{name: "own parent", team: "a", parents: map[string]string{"a": "A"}, want: []string{"a", "A"}},
+		{name: "cycle", team: "a", parents: map[string]string{"a": "b", "b": "c", "c": "a"}, want: []string{"a", "b", "c", "a"}},
+		{name: "cycle above the team", team: "x", parents: map[string]string{"x": "a", "a": "b", "b": "a"}, want: nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parentTeamCycle(tc.team, tc.parents); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGithubTeamParents(t *testing.T) {
+	teams := []v1.GithubTeam{
+		{Spec: v1.GithubTeamSpec{Github: "com", Organization: "org", Team: "Squad", ParentTeam: "department"}},
+		{Spec: v1.GithubTeamSpec{Github: "com", Organization: "org", Team: "department"}},
+		{Spec: v1.GithubTeamSpec{Github: "com", Organization: "other", Team: "x", ParentTeam: "y"}},
+	}
+	parents := githubTeamParents(teams, "com", "org")
+	if len(parents) != 1 || parents["squad"] != "department" {
+		t.Errorf("got %v, want map[squad:department]", parents)
+	}
+}
+
+func TestOrderGithubTeamOperations(t *testing.T) {
+	pending := func(op v1.GithubTeamOperationType, team string) v1.GithubTeamOperation {
+		return v1.GithubTeamOperation{Operation: op, Team: team, State: v1.GithubTeamOperationStatePending}
+	}
+	operations := []v1.GithubTeamOperation{
+		pending(v1.GithubTeamOperationTypeRemove, "old-department"),
+		pending(v1.GithubTeamOperationTypeAdd, "squad"),
+		pending(v1.GithubTeamOperationTypeRemove, "old-squad"),
+		pending(v1.GithubTeamOperationTypeAdd, "tribe"),
+		pending(v1.GithubTeamOperationTypeAdd, "department"),
+	}
+	parents := map[string]string{"squad": "tribe", "tribe": "department"}
+	children := map[string][]string{"old-department": {"old-squad", "kept"}, "old-squad": {}}
+
+	var got []string
+	for _, i := range orderGithubTeamOperations(operations, parents, children) {
+		got = append(got, string(operations[i].Operation)+" "+operations[i].Team)
+	}
+	want := []string{"add department", "add tribe", "add squad", "remove old-squad", "remove old-department"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	kept := keptChildTeams("old-department", children, pendingTeamRemovals(operations))
+	if fmt.Sprint(kept) != "[kept]" {
+		t.Errorf("kept child teams: got %v, want [kept]", kept)
+	}
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+// idpGroupRecheckInterval is the requeue of a GithubTeam linked with a group of the
+// identity provider, which verifies that the link is still in place.
+const idpGroupRecheckInterval = 15 * time.Minute
+
+// EVENT_REASON_GITHUB_TEAM_IDP_GROUP_LINKED is the reason of the event emitted when a
+// team is linked with the group of spec.idpGroup in Github.
+const EVENT_REASON_GITHUB_TEAM_IDP_GROUP_LINKED = "GithubTeamIdPGroupLinked"
+
+// EVENT_REASON_GITHUB_TEAM_IDP_GROUP_UNLINKED is the reason of the event emitted when
+// the link of a team with a group is removed after spec.idpGroup was removed.
+const EVENT_REASON_GITHUB_TEAM_IDP_GROUP_UNLINKED = "GithubTeamIdPGroupUnlinked"
+
+// idpGroupValidationError returns why spec.idpGroup cannot be used with the rest of
+// spec, or "".
+func idpGroupValidationError(spec v1.GithubTeamSpec) string {
+	if spec.IdPGroup == nil {
+		return ""
+	}
+	switch {
+	case spec.GreenhouseTeam != "" || spec.ExternalMemberProvider != nil:
+		return "idpGroup cannot be combined with greenhouseTeam or externalMemberProvider"
+	case spec.Maintainers != nil:
+		return "idpGroup cannot be combined with maintainers"
+	case spec.IdPGroup.Name == "":
+		return "idpGroup name not provided"
+	case spec.IdPGroup.Type != github.IDP_GROUP_TYPE_EXTERNAL_GROUP && spec.IdPGroup.Type != github.IDP_GROUP_TYPE_TEAM_SYNC:
+		return "idpGroup type must be externalGroup or teamSync"
+	}
+	return ""
+}
+
+// reconcileIdPGroup links the team with the group of spec.idpGroup in Github and
+// records the health of the link in status.idpGroup. A link recorded in status is
+// removed when spec.idpGroup is removed. done is set when the reconcile must return
+// res and err.
+func (r *GithubTeamReconciler) reconcileIdPGroup(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, teamsProvider github.TeamsProvider) (res reconcile.Result, done bool, err error) {
+	l := log.FromContext(ctx)
+	team := githubTeam.Spec.Team
+	desired := githubTeam.Spec.IdPGroup
+	observed := githubTeam.Status.IdPGroup
+	dryRun := githubTeam.Labels[GITHUB_TEAMS_LABEL_DRY_RUN] == GITHUB_TEAMS_LABEL_DRY_RUN_ENABLED_VALUE
+
+	// a link of another type, or of a group that is no longer desired, is removed first
+	if observed != nil && observed.Linked && !dryRun && (desired == nil || desired.Type != observed.Type) {
+		if err := teamsProvider.UnlinkIdPGroups(ctx, observed.Type, team); err != nil {
+			return r.teamGithubError(ctx, req, "error during unlinking the team from the idp group in Github", err)
+		}
+		l.Info("team is unlinked from the idp group in Github", "type", observed.Type, "group", observed.Name)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(githubTeam, nil, corev1.EventTypeNormal, EVENT_REASON_GITHUB_TEAM_IDP_GROUP_UNLINKED, "Unlink",
+				"Github team %s unlinked from %s %s", team, observed.Type, observed.Name)
+		}
+		observed = nil
+	}
+	if desired == nil {
+		if githubTeam.Status.IdPGroup != nil {
+			if err := r.updateIdPGroupStatus(ctx, req, githubTeam, nil, ""); err != nil {
+				return reconcile.Result{}, true, err
+			}
+		}
+		return reconcile.Result{}, false, nil
+	}
+
+	group, found, err := teamsProvider.FindIdPGroup(ctx, desired.Type, desired.Name)
+	if err != nil {
+		return r.teamGithubError(ctx, req, "error during getting the idp group in Github", err)
+	}
+	status := &v1.IdPGroupStatus{Type: desired.Type, Name: desired.Name}
+	if !found {
+		status.Error = "group not found in Github"
+		msg := "idp group " + desired.Name + " not found in Github"
+		if idpGroupStatusEqual(observed, status) && githubTeam.Status.TeamStatus == v1.GithubTeamStateFailed && githubTeam.Status.TeamStatusError == msg {
+			return reconcile.Result{RequeueAfter: idpGroupRecheckInterval}, true, nil
+		}
+		return reconcile.Result{RequeueAfter: idpGroupRecheckInterval}, true, r.updateIdPGroupStatus(ctx, req, githubTeam, status, msg)
+	}
+	status.ID = group.ID
+
+	linked, err := teamsProvider.LinkedIdPGroups(ctx, desired.Type, team)
+	if err != nil {
+		return r.teamGithubError(ctx, req, "error during getting the idp groups of the team in Github", err)
+	}
+	link, isLinked := linkedIdPGroup(linked, group.ID)
+	if !isLinked && !dryRun {
+		if err := teamsProvider.LinkIdPGroup(ctx, desired.Type, team, group); err != nil {
+			return r.teamGithubError(ctx, req, "error during linking the team with the idp group in Github", err)
+		}
+		l.Info("team is linked with the idp group in Github", "type", desired.Type, "group", desired.Name)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(githubTeam, nil, corev1.EventTypeNormal, EVENT_REASON_GITHUB_TEAM_IDP_GROUP_LINKED, "Link",
+				"Github team %s linked with %s %s", team, desired.Type, desired.Name)
+		}
+		link, isLinked = group, true
+	}
+	status.Linked = isLinked
+	if !isLinked {
+		status.Error = "not linked: dry run"
+	}
+	if !link.UpdatedAt.IsZero() {
+		lastSync := metav1.NewTime(link.UpdatedAt)
+		status.LastSync = &lastSync
+	}
+	if !idpGroupStatusEqual(observed, status) {
+		if err := r.updateIdPGroupStatus(ctx, req, githubTeam, status, ""); err != nil {
+			return reconcile.Result{}, true, err
+		}
+	}
+	return reconcile.Result{}, false, nil
+}
+
+// linkedIdPGroup returns the group with id among the groups linked with a team.
+func linkedIdPGroup(linked []github.IdPGroup, id string) (github.IdPGroup, bool) {
+	for _, group := range linked {
+		if group.ID == id {
+			return group, true
+		}
+	}
+	return github.IdPGroup{}, false
+}
+
+// idpGroupStatusEqual reports whether a and b report the same link, so that the
+// status is only written when the link changes.
+func idpGroupStatusEqual(a, b *v1.IdPGroupStatus) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if (a.LastSync == nil) != (b.LastSync == nil) || (a.LastSync != nil && !a.LastSync.Equal(b.LastSync)) {
+		return false
+	}
+	x, y := *a, *b
+	x.LastSync, y.LastSync = nil, nil
+	return reflect.DeepEqual(x, y)
+}
+
+// updateIdPGroupStatus writes status to status.idpGroup of githubTeam. A non-empty
+// failure fails the team with it.
+func (r *GithubTeamReconciler) updateIdPGroupStatus(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, status *v1.IdPGroupStatus, failure string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubTeam{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status.IdPGroup = status
+		if failure != "" {
+			latest.Status.TeamStatus = v1.GithubTeamStateFailed
+			latest.Status.TeamStatusError = failure
+			latest.Status.TeamStatusTimestamp = metav1.Now()
+		}
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+		return err
+	}
+	githubTeam.Status.IdPGroup = status
+	return nil
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func TestIdPGroupValidationError(t *testing.T) {
+	group := &v1.TeamIdPGroup{Type: "externalGroup", Name: "admins"}
+	cases := []struct {
+		name string
+		spec v1.GithubTeamSpec
+		want string
+	}{
+		{"no idp group", v1.GithubTeamSpec{GreenhouseTeam: "admins"}, ""},
+		{"idp group", v1.GithubTeamSpec{IdPGroup: group}, ""},
+		{"with provider", v1.GithubTeamSpec{IdPGroup: group, ExternalMemberProvider: &v1.ExternalMemberProviderConfig{}}, "idpGroup cannot be combined with greenhouseTeam or externalMemberProvider"},
+		{"with maintainers", v1.GithubTeamSpec{IdPGroup: group, Maintainers: &v1.TeamMaintainers{Users: []string{"alice"}}}, "idpGroup cannot be combined with maintainers"},
+		{"without name", v1.GithubTeamSpec{IdPGroup: &v1.TeamIdPGroup{Type: "teamSync"}}, "idpGroup name not provided"},
+		{"unknown type", v1.GithubTeamSpec{IdPGroup: &v1.TeamIdPGroup{Type: "ldap", Name: "admins"}}, "idpGroup type must be externalGroup or teamSync"},
+	}
+	for _, tc := range cases {
+		if got := idpGroupValidationError(tc.spec); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestIdPGroupStatusEqual(t *testing.T) {
+	sync := metav1.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(sync.Add(time.Hour))
+	a := &v1.IdPGroupStatus{Type: "externalGroup", Name: "admins", ID: "2", Linked: true, LastSync: &sync}
+	b := a.DeepCopy()
+	if !idpGroupStatusEqual(a, b) {
+		t.Error("expected copies to be equal")
+	}
+	b.LastSync = &later
+	if idpGroupStatusEqual(a, b) {
+		t.Error("expected a new sync to differ")
+	}
+	b = a.DeepCopy()
+	b.Linked = false
+	if idpGroupStatusEqual(a, b) {
+		t.Error("expected a lost link to differ")
+	}
+	if idpGroupStatusEqual(a, nil) || !idpGroupStatusEqual(nil, nil) {
+		t.Error("unexpected comparison with nil")
+	}
+}
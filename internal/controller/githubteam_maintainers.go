@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	externalprovider "github.com/cloudoperators/repo-guard/internal/external-provider"
+	"github.com/cloudoperators/repo-guard/internal/github"
+
+	greenhousesapv1alpha1 "github.com/cloudoperators/greenhouse/api/v1alpha1"
+)
+
+// teamMaintainerIDs returns the Greenhouse IDs or Github logins of the maintainers of
+// githubTeam: spec.maintainers.users and the members of spec.maintainers.group, which
+// is read from memberProvider or, for Greenhouse teams, is another Greenhouse Team.
+func (r *GithubTeamReconciler) teamMaintainerIDs(ctx context.Context, githubTeam *v1.GithubTeam, memberProvider externalprovider.ExternalProvider) ([]string, error) {
+	maintainers := githubTeam.Spec.Maintainers
+	ids := append([]string{}, maintainers.Users...)
+	if maintainers.Group == "" {
+		return ids, nil
+	}
+
+	if githubTeam.Spec.GreenhouseTeam != "" {
+		greenhouseTeam := greenhousesapv1alpha1.Team{}
+		if err := r.Get(ctx, types.NamespacedName{Name: maintainers.Group, Namespace: githubTeam.Namespace}, &greenhouseTeam); err != nil {
+			return nil, err
+		}
+		for _, m := range greenhouseTeam.Status.Members {
+			ids = append(ids, m.ID)
+		}
+		return ids, nil
+	}
+
+	if memberProvider == nil {
+		return nil, errors.New("maintainers group requires a member provider")
+	}
+	users, err := memberProvider.Users(ctx, maintainers.Group)
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, users...), nil
+}
+
+// applyMaintainerRoles sets the role of members to maintainer for the members found
+// in maintainers and to member for the others. Maintainers that are not in members
+// are added to them.
+func applyMaintainerRoles(members, maintainers []v1.Member) []v1.Member {
+	out := make([]v1.Member, 0, len(members)+len(maintainers))
+	for _, m := range members {
+		m.Role = v1.GithubTeamRoleMember
+		for _, maintainer := range maintainers {
+			if m.SameGithubUser(maintainer) {
+				m.Role = v1.GithubTeamRoleMaintainer
+				break
+			}
+		}
+		out = append(out, m)
+	}
+	for _, maintainer := range maintainers {
+		found := false
+		for _, m := range out {
+			if m.SameGithubUser(maintainer) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			maintainer.Role = v1.GithubTeamRoleMaintainer
+			out = append(out, maintainer)
+		}
+	}
+	return out
+}
+
+// withTeamRoles records the role of members in Github when githubTeam manages
+// maintainers; otherwise members are returned unchanged.
+func withTeamRoles(ctx context.Context, teamsProvider github.TeamsProvider, githubTeam *v1.GithubTeam, members []v1.Member) ([]v1.Member, error) {
+	if githubTeam.Spec.Maintainers == nil {
+		return members, nil
+	}
+	logins, err := teamsProvider.Maintainers(ctx, githubTeam.Spec.Team)
+	if err != nil {
+		return nil, err
+	}
+	maintainers := make(map[string]bool, len(logins))
+	for _, login := range logins {
+		maintainers[strings.ToLower(login)] = true
+	}
+	out := make([]v1.Member, 0, len(members))
+	for _, m := range members {
+		m.Role = v1.GithubTeamRoleMember
+		if maintainers[strings.ToLower(m.GithubUsername)] {
+			m.Role = v1.GithubTeamRoleMaintainer
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func TestApplyMaintainerRoles(t *testing.T) {
+	members := []v1.Member{
+		{GreenhouseID: "I1", GithubUsername: "alice", GithubUID: 1},
+		{GreenhouseID: "I2", GithubUsername: "bob", GithubUID: 2},
+	}
+	maintainers := []v1.Member{
+		// renamed login, matched by UID
+		{GreenhouseID: "I1", GithubUsername: "alice-new", GithubUID: 1},
+		{GreenhouseID: "I3", GithubUsername: "carol", GithubUID: 3},
+	}
+
+	got := applyMaintainerRoles(members, maintainers)
+
+	want := []v1.Member{
+		{GreenhouseID: "I1", GithubUsername: "alice", GithubUID: 1, Role: v1.GithubTeamRoleMaintainer},
+		{GreenhouseID: "I2", GithubUsername: "bob", GithubUID: 2, Role: v1.GithubTeamRoleMember},
+		{GreenhouseID: "I3", GithubUsername: "carol", GithubUID: 3, Role: v1.GithubTeamRoleMaintainer},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("member %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if members[0].Role != "" {
+		t.Errorf("members must not be modified, got %+v", members[0])
+	}
+}
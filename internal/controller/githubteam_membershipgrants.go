@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+// teamMembershipGrants returns the GithubTeamMembershipGrants of githubTeam that are
+// valid at now, sorted by name.
+func (r *GithubTeamReconciler) teamMembershipGrants(ctx context.Context, githubTeam *v1.GithubTeam, now metav1.Time) ([]v1.GithubTeamMembershipGrant, error) {
+	grantList := &v1.GithubTeamMembershipGrantList{}
+	if err := r.List(ctx, grantList, client.InNamespace(githubTeam.Namespace)); err != nil {
+		return nil, err
+	}
+	return activeMembershipGrants(grantList.Items, githubTeam.Name, now), nil
+}
+
+// activeMembershipGrants returns the grants of the GithubTeam named team that are valid
+// at now, sorted by name.
+func activeMembershipGrants(grants []v1.GithubTeamMembershipGrant, team string, now metav1.Time) []v1.GithubTeamMembershipGrant {
+	var active []v1.GithubTeamMembershipGrant
+	for _, grant := range grants {
+		if grant.Spec.GithubTeam != team || grant.Spec.User == "" || !grant.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if grant.Active(now) {
+			active = append(active, grant)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Name < active[j].Name })
+	return active
+}
+
+// membershipGrantUsers returns the users of grants, once each.
+func membershipGrantUsers(grants []v1.GithubTeamMembershipGrant) []string {
+	seen := map[string]bool{}
+	var users []string
+	for _, grant := range grants {
+		if !seen[grant.Spec.User] {
+			seen[grant.Spec.User] = true
+			users = append(users, grant.Spec.User)
+		}
+	}
+	return users
+}
+
+// mergeGrantedMembers adds the members of active grants that are not members of the
+// team already.
+func mergeGrantedMembers(members, granted []v1.Member) []v1.Member {
+	out := append([]v1.Member{}, members...)
+	for _, g := range granted {
+		found := false
+		for _, m := range out {
+			if m.SameGithubUser(g) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// membershipGrantStatuses returns grants as reported in status.membershipGrants.
+func membershipGrantStatuses(grants []v1.GithubTeamMembershipGrant) []v1.MembershipGrantStatus {
+	var statuses []v1.MembershipGrantStatus
+	for _, grant := range grants {
+		statuses = append(statuses, v1.MembershipGrantStatus{Name: grant.Name, User: grant.Spec.User, ExpiresAt: grant.Spec.ExpiresAt})
+	}
+	return statuses
+}
+
+// nextMembershipGrantExpiry returns the time until the first of grants expires, or 0.
+func nextMembershipGrantExpiry(grants []v1.GithubTeamMembershipGrant, now metav1.Time) time.Duration {
+	var next time.Duration
+	for _, grant := range grants {
+		d := grant.Spec.ExpiresAt.Sub(now.Time)
+		if d > 0 && (next == 0 || d < next) {
+			next = d
+		}
+	}
+	return next
+}
+
+// withMembershipGrantRequeue requeues res at the first expiry of grants unless it is
+// requeued earlier already.
+func withMembershipGrantRequeue(res reconcile.Result, grants []v1.GithubTeamMembershipGrant) reconcile.Result {
+	next := nextMembershipGrantExpiry(grants, metav1.Now())
+	if next > 0 && (res.RequeueAfter == 0 || next < res.RequeueAfter) {
+		res.RequeueAfter = next
+	}
+	return res
+}
+
+// updateMembershipGrantsStatus writes statuses to status.membershipGrants of githubTeam
+// when they changed.
+func (r *GithubTeamReconciler) updateMembershipGrantsStatus(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, statuses []v1.MembershipGrantStatus) error {
+	if membershipGrantStatusesEqual(githubTeam.Status.MembershipGrants, statuses) {
+		return nil
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubTeam{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status.MembershipGrants = statuses
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+		return err
+	}
+	githubTeam.Status.MembershipGrants = statuses
+	return nil
+}
+
+// membershipGrantStatusesEqual reports whether a and b list the same grants. The
+// expiry is compared in the second precision of the API.
+func membershipGrantStatusesEqual(a, b []v1.MembershipGrantStatus) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if !x.ExpiresAt.Equal(&y.ExpiresAt) && x.ExpiresAt.Unix() != y.ExpiresAt.Unix() {
+			return false
+		}
+		x.ExpiresAt, y.ExpiresAt = metav1.Time{}, metav1.Time{}
+		if !reflect.DeepEqual(x, y) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+func membershipGrant(name, team, user string, expiresAt time.Time) v1.GithubTeamMembershipGrant {
+	return v1.GithubTeamMembershipGrant{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Spec:       v1.GithubTeamMembershipGrantSpec{GithubTeam: team, User: user, ExpiresAt: metav1.NewTime(expiresAt)},
+	}
+}
+
+func TestActiveMembershipGrants(t *testing.T) {
+	now := metav1.Now()
+	grants := []v1.GithubTeamMembershipGrant{
+		membershipGrant("oncall-bob", "sre", "bob", now.Add(time.Hour)),
+		membershipGrant("incident-alice", "sre", "alice", now.Add(time.Minute)),
+		membershipGrant("expired-carol", "sre", "carol", now.Add(-time.Minute)),
+		membershipGrant("other-dave", "platform", "dave", now.Add(time.Hour)),
+	}
+
+	active := activeMembershipGrants(grants, "sre", now)
+	if len(active) != 2 || active[0].Name != "incident-alice" || active[1].Name != "oncall-bob" {
+		t.Fatalf("unexpected active grants: %+v", active)
+	}
+	if d := nextMembershipGrantExpiry(active, now); d <= 0 || d > time.Minute {
+		t.Errorf("expected the next expiry within a minute, got %v", d)
+	}
+	if d := nextMembershipGrantExpiry(nil, now); d != 0 {
+		t.Errorf("expected no expiry without grants, got %v", d)
+	}
+}
+
+func TestMergeGrantedMembers(t *testing.T) {
+	members := []v1.Member{{GreenhouseID: "I100", GithubUsername: "alice", Role: v1.GithubTeamRoleMaintainer}}
+	granted := []v1.Member{
+		{GreenhouseID: "alice", GithubUsername: "Alice"},
+		{GreenhouseID: "I200", GithubUsername: "bob"},
+	}
+
+	merged := mergeGrantedMembers(members, granted)
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 members, got %+v", merged)
+	}
+	if merged[0].Role != v1.GithubTeamRoleMaintainer {
+		t.Errorf("expected the member of the provider to be kept, got %+v", merged[0])
+	}
+	if merged[1].GithubUsername != "bob" {
+		t.Errorf("expected bob to be added, got %+v", merged[1])
+	}
+}
+
+func TestMembershipGrantStatusesEqual(t *testing.T) {
+	expiresAt := metav1.NewTime(time.Date(2026, 10, 18, 12, 0, 0, 500, time.UTC))
+	a := []v1.MembershipGrantStatus{{Name: "oncall-bob", User: "bob", ExpiresAt: expiresAt}}
+	b := []v1.MembershipGrantStatus{{Name: "oncall-bob", User: "bob", ExpiresAt: metav1.NewTime(expiresAt.Truncate(time.Second))}}
+	if !membershipGrantStatusesEqual(a, b) {
+		t.Error("expected grants with the same expiry in seconds to be equal")
+	}
+	b[0].User = "carol"
+	if membershipGrantStatusesEqual(a, b) {
+		t.Error("expected grants of different users to differ")
+	}
+	if membershipGrantStatusesEqual(a, nil) || !membershipGrantStatusesEqual(nil, nil) {
+		t.Error("unexpected result for empty grants")
+	}
+}
+
+func TestGithubTeamMembershipGrantReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	team := &v1.GithubTeam{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "sre"},
+		Spec:       v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "sre", GreenhouseTeam: "sre"},
+	}
+	active := membershipGrant("oncall-bob", "sre", "bob", time.Now().Add(time.Hour))
+	expired := membershipGrant("incident-alice", "sre", "alice", time.Now().Add(-time.Minute))
+	expired.Status.State = v1.GithubTeamMembershipGrantStateActive
+	missing := membershipGrant("oncall-carol", "missing", "carol", time.Now().Add(time.Hour))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(team, &active, &expired, &missing).
+		WithStatusSubresource(&v1.GithubTeamMembershipGrant{}).Build()
+	r := &GithubTeamMembershipGrantReconciler{Client: c}
+	expiredTotal := ghmetrics.MembershipGrantsExpiredTotal.WithLabelValues("acme", "sre")
+	expiredBefore := testutil.ToFloat64(expiredTotal)
+
+	for _, tc := range []struct {
+		grant   *v1.GithubTeamMembershipGrant
+		state   string
+		err     string
+		requeue bool
+	}{
+		{grant: &active, state: v1.GithubTeamMembershipGrantStateActive, requeue: true},
+		{grant: &expired, state: v1.GithubTeamMembershipGrantStateExpired},
+		{grant: &missing, state: v1.GithubTeamMembershipGrantStateActive, err: "GithubTeam missing not found", requeue: true},
+	} {
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tc.grant)}
+		res, err := r.Reconcile(t.Context(), req)
+		if err != nil {
+			t.Fatalf("Reconcile %s: %v", tc.grant.Name, err)
+		}
+		if (res.RequeueAfter > 0) != tc.requeue {
+			t.Errorf("%s: unexpected requeue %v", tc.grant.Name, res.RequeueAfter)
+		}
+		latest := &v1.GithubTeamMembershipGrant{}
+		if err := c.Get(t.Context(), req.NamespacedName, latest); err != nil {
+			t.Fatal(err)
+		}
+		if latest.Status.State != tc.state || latest.Status.Error != tc.err {
+			t.Errorf("%s: unexpected status %+v", tc.grant.Name, latest.Status)
+		}
+	}
+	if got := testutil.ToFloat64(expiredTotal) - expiredBefore; got != 1 {
+		t.Errorf("expected one expired grant counted for the organization and team, got %v", got)
+	}
+}
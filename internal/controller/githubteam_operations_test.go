@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+// fakeTeamsProvider records the membership changes of a team and rejects the calls for
+// rejected users like the transport does while mutations are paused; the other
+// methods of the interface are not implemented.
+type fakeTeamsProvider struct {
+	github.TeamsProvider
+	rejected map[string]bool
+	retryAt  time.Time
+	calls    []string
+}
+
+func (f *fakeTeamsProvider) rejection(user string) error {
+	if f.rejected[user] {
+		return &github.SecondaryRateLimitError{RetryAt: f.retryAt}
+	}
+	return nil
+}
+
+func (f *fakeTeamsProvider) AddUser(ctx context.Context, team, user, role string) (bool, error) {
+	f.calls = append(f.calls, "add "+user)
+	if err := f.rejection(user); err != nil {
+		return true, fmt.Errorf("adding user to team: %w", err)
+	}
+	return true, nil
+}
+
+func (f *fakeTeamsProvider) SetRole(ctx context.Context, team, user, role string) error {
+	f.calls = append(f.calls, "role "+user)
+	return f.rejection(user)
+}
+
+func (f *fakeTeamsProvider) RemoveUser(ctx context.Context, team, user string) error {
+	f.calls = append(f.calls, "remove "+user)
+	return f.rejection(user)
+}
+
+func TestProcessTeamOperationsRateLimited(t *testing.T) {
+	retryAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	operations := func() []v1.GithubUserOperation {
+		return []v1.GithubUserOperation{
+			{Operation: v1.GithubUserOperationTypeAdd, User: "alice", State: v1.GithubUserOperationStatePending},
+			{Operation: v1.GithubUserOperationTypeRole, User: "bob", Role: v1.GithubTeamRoleMaintainer, State: v1.GithubUserOperationStatePending},
+			{Operation: v1.GithubUserOperationTypeRemove, User: "carol", State: v1.GithubUserOperationStatePending},
+		}
+	}
+	tests := []struct {
+		name      string
+		rejected  string
+		wantCalls int
+		// wantStates are the states of the operations of alice, bob and carol
+		wantStates []v1.GithubUserOperationState
+	}{
+		{
+			name: "add", rejected: "alice", wantCalls: 1,
+			wantStates: []v1.GithubUserOperationState{v1.GithubUserOperationStatePending, v1.GithubUserOperationStatePending, v1.GithubUserOperationStatePending},
+		},
+		{
+			name: "role", rejected: "bob", wantCalls: 2,
+			wantStates: []v1.GithubUserOperationState{v1.GithubUserOperationStateComplete, v1.GithubUserOperationStatePending, v1.GithubUserOperationStatePending},
+		},
+		{
+			name: "remove", rejected: "carol", wantCalls: 3,
+			wantStates: []v1.GithubUserOperationState{v1.GithubUserOperationStateComplete, v1.GithubUserOperationStateComplete, v1.GithubUserOperationStatePending},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeTeamsProvider{rejected: map[string]bool{tt.rejected: true}, retryAt: retryAt}
+			githubTeam := &v1.GithubTeam{Spec: v1.GithubTeamSpec{Team: "sre"}}
+			status := &v1.GithubTeamStatus{Operations: operations()}
+
+			_, failed, err := processTeamOperations(t.Context(), githubTeam, provider, "sre", status)
+
+			var secondary *github.SecondaryRateLimitError
+			if !errors.As(err, &secondary) {
+				t.Fatalf("expected SecondaryRateLimitError, got %v", err)
+			}
+			if reset, ok := parseGitHubRateLimitReset(err.Error()); !ok || !reset.Equal(retryAt) {
+				t.Errorf("reset: got %v, %v, want %v", reset, ok, retryAt)
+			}
+			if failed {
+				t.Error("a rate limited operation must not fail the team")
+			}
+			// the operations after the rejected one are not attempted
+			if len(provider.calls) != tt.wantCalls {
+				t.Errorf("calls: got %v, want %d", provider.calls, tt.wantCalls)
+			}
+			for i, op := range status.Operations {
+				if op.State != tt.wantStates[i] {
+					t.Errorf("operation %s %s: got state %q, want %q", op.Operation, op.User, op.State, tt.wantStates[i])
+				}
+				if op.State == v1.GithubUserOperationStatePending && op.Error != "" {
+					t.Errorf("pending operation %s %s has error %q", op.Operation, op.User, op.Error)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+
+	"github.com/gosimple/slug"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+// EVENT_REASON_GITHUB_TEAM_RENAMED is the reason of the event emitted when the team
+// of a GithubTeam is renamed in Github after spec.team changed.
+const EVENT_REASON_GITHUB_TEAM_RENAMED = "GithubTeamRenamed"
+
+// reconcileTeamRename renames the team from, the team of githubTeam in Github, to
+// spec.team, so that its repository permissions and history are kept. A team that no
+// longer exists under from with the recorded ID is forgotten, and spec.team is handled
+// like a new team. done is set when the reconcile must return res and err.
+func (r *GithubTeamReconciler) reconcileTeamRename(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, teamsProvider github.TeamsProvider, from string) (res reconcile.Result, done bool, err error) {
+	l := log.FromContext(ctx)
+
+	id, found, err := teamsProvider.TeamID(ctx, from)
+	if err != nil {
+		return r.teamGithubError(ctx, req, "error during getting the team to rename in Github", err)
+	}
+	if !found || id != githubTeam.Status.TeamID {
+		l.Info("team to rename is not found in Github", "team", from, "id", githubTeam.Status.TeamID)
+		return reconcile.Result{}, false, r.updateTeamIdentity(ctx, req, githubTeam, 0, "", githubTeam.Status.PreviousSlug)
+	}
+
+	name := githubTeam.Spec.DisplayName
+	if name == "" {
+		name = githubTeam.Spec.Team
+	}
+	newSlug, err := teamsProvider.RenameTeam(ctx, from, name)
+	if err != nil {
+		return r.teamGithubError(ctx, req, "error during renaming the team in Github", err)
+	}
+	l.Info("team is renamed in Github", "from", from, "to", newSlug)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(githubTeam, nil, corev1.EventTypeNormal, EVENT_REASON_GITHUB_TEAM_RENAMED, "Rename",
+			"Github team %d renamed from %s to %s", id, from, newSlug)
+	}
+	if err := r.updateTeamIdentity(ctx, req, githubTeam, id, newSlug, from); err != nil {
+		return reconcile.Result{}, true, err
+	}
+	return reconcile.Result{}, false, nil
+}
+
+// recordTeamIdentity records the ID and the slug of the team of githubTeam in Github,
+// which identify the team when spec.team is changed later.
+func (r *GithubTeamReconciler) recordTeamIdentity(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, teamsProvider github.TeamsProvider) (res reconcile.Result, done bool, err error) {
+	id, found, err := teamsProvider.TeamID(ctx, githubTeam.Spec.Team)
+	if err != nil {
+		return r.teamGithubError(ctx, req, "error during getting the team in Github", err)
+	}
+	teamSlug := slug.Make(githubTeam.Spec.Team)
+	if !found || (githubTeam.Status.TeamID == id && githubTeam.Status.Slug == teamSlug) {
+		return reconcile.Result{}, false, nil
+	}
+	if err := r.updateTeamIdentity(ctx, req, githubTeam, id, teamSlug, githubTeam.Status.PreviousSlug); err != nil {
+		return reconcile.Result{}, true, err
+	}
+	return reconcile.Result{}, false, nil
+}
+
+// updateTeamIdentity writes the team ID and the slugs to the status of githubTeam.
+func (r *GithubTeamReconciler) updateTeamIdentity(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, id int64, teamSlug, previousSlug string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubTeam{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status.TeamID = id
+		latest.Status.Slug = teamSlug
+		latest.Status.PreviousSlug = previousSlug
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+		return err
+	}
+	githubTeam.Status.TeamID = id
+	githubTeam.Status.Slug = teamSlug
+	githubTeam.Status.PreviousSlug = previousSlug
+	return nil
+}
+
+// teamIdentityStatus returns a status with only the identity of the team in Github
+// from status. It survives a status reset, so that a pending rename is not turned into
+// the deletion of the old team and the creation of a new one.
+func teamIdentityStatus(status v1.GithubTeamStatus) v1.GithubTeamStatus {
+	return v1.GithubTeamStatus{TeamID: status.TeamID, Slug: status.Slug, PreviousSlug: status.PreviousSlug}
+}
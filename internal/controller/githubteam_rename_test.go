@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func TestForceReconcileKeepsTeamIdentity(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	team := &v1.GithubTeam{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "com--acme--platform",
+			Labels:    map[string]string{GITHUB_TEAM_LABEL_FORCE_RECONCILE: GITHUB_TEAM_LABEL_FORCE_RECONCILE_VALUE},
+		},
+		Spec: v1.GithubTeamSpec{Github: "com", Organization: "acme", Team: "platform"},
+		Status: v1.GithubTeamStatus{
+			TeamID:          42,
+			Slug:            "infra",
+			PreviousSlug:    "ops",
+			TeamStatus:      v1.GithubTeamStateFailed,
+			TeamStatusError: "stuck",
+			Members:         []v1.Member{{GithubUsername: "alice"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(team).WithStatusSubresource(&v1.GithubTeam{}).Build()
+	r := &GithubTeamReconciler{Client: c}
+
+	if _, err := r.Reconcile(t.Context(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(team)}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	latest := &v1.GithubTeam{}
+	if err := c.Get(t.Context(), client.ObjectKeyFromObject(team), latest); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := latest.Labels[GITHUB_TEAM_LABEL_FORCE_RECONCILE]; ok {
+		t.Error("expected the forceReconcile label to be removed")
+	}
+	if latest.Status.TeamStatus != "" || latest.Status.TeamStatusError != "" || len(latest.Status.Members) != 0 {
+		t.Errorf("expected the status to be reset, got %+v", latest.Status)
+	}
+	if latest.Status.TeamID != 42 || latest.Status.Slug != "infra" || latest.Status.PreviousSlug != "ops" {
+		t.Errorf("expected the identity of the team to be kept, got %+v", latest.Status)
+	}
+	if latest.PendingRename() != "infra" {
+		t.Errorf("expected the rename from infra to stay pending, got %q", latest.PendingRename())
+	}
+}
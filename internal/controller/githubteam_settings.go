@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+// teamSettingsValidationError returns why the settings of spec cannot be applied, or "".
+func teamSettingsValidationError(spec v1.GithubTeamSpec) string {
+	if spec.DisplayName != "" && slug.Make(spec.DisplayName) != slug.Make(spec.Team) {
+		return fmt.Sprintf("displayName %q does not match the slug of team %q", spec.DisplayName, spec.Team)
+	}
+	if spec.Privacy == github.TEAM_PRIVACY_SECRET && spec.ParentTeam != "" {
+		return "secret teams cannot have a parent team"
+	}
+	return ""
+}
+
+// desiredTeamSettings returns the settings set in spec; unset settings are empty.
+func desiredTeamSettings(spec v1.GithubTeamSpec) github.TeamSettings {
+	return github.TeamSettings{
+		Name:                spec.DisplayName,
+		Description:         spec.Description,
+		Privacy:             spec.Privacy,
+		NotificationSetting: spec.NotificationSetting,
+	}
+}
+
+// teamSettingsDrift returns the spec fields of the settings in desired that differ
+// from current. Empty settings in desired are not managed.
+func teamSettingsDrift(current, desired github.TeamSettings) []string {
+	var drift []string
+	if desired.Name != "" && desired.Name != current.Name {
+		drift = append(drift, "displayName")
+	}
+	if desired.Description != "" && desired.Description != current.Description {
+		drift = append(drift, "description")
+	}
+	if desired.Privacy != "" && !strings.EqualFold(desired.Privacy, current.Privacy) {
+		drift = append(drift, "privacy")
+	}
+	if desired.NotificationSetting != "" && !strings.EqualFold(desired.NotificationSetting, current.NotificationSetting) {
+		drift = append(drift, "notificationSetting")
+	}
+	return drift
+}
+
+// reconcileTeamSettings corrects the settings of the team in Github that differ from
+// the spec. With the GITHUB_TEAMS_LABEL_UPDATE_SETTINGS label disabled or in dry-run,
+// the drift is only reported in status.settingsDrift. done is set when the reconcile
+// must return res and err.
+func (r *GithubTeamReconciler) reconcileTeamSettings(ctx context.Context, req ctrl.Request, githubTeam *v1.GithubTeam, teamsProvider github.TeamsProvider) (res reconcile.Result, done bool, err error) {
+	l := log.FromContext(ctx)
+	team := githubTeam.Spec.Team
+
+	current, err := teamsProvider.Settings(ctx, team)
+	if err != nil {
+		return r.teamGithubError(ctx, req, "error during getting the team settings in Github", err)
+	}
+	desired := desiredTeamSettings(githubTeam.Spec)
+	drift := teamSettingsDrift(current, desired)
+
+	if len(drift) > 0 {
+		switch {
+		case githubTeam.Labels[GITHUB_TEAMS_LABEL_DRY_RUN] == GITHUB_TEAMS_LABEL_DRY_RUN_ENABLED_VALUE:
+			l.Info("team settings differ from Github: dry run", "settings", drift)
+		case githubTeam.Labels[GITHUB_TEAMS_LABEL_UPDATE_SETTINGS] != "" && githubTeam.Labels[GITHUB_TEAMS_LABEL_UPDATE_SETTINGS] != GITHUB_TEAMS_LABEL_ADD_REMOVE_USER_ENABLED_VALUE:
+			l.Info("team settings differ from Github: updating team settings is not enabled", "settings", drift)
+		default:
+			if desired.Name == "" {
+				desired.Name = current.Name
+			}
+			if err := teamsProvider.EditSettings(ctx, team, desired); err != nil {
+				return r.teamGithubError(ctx, req, "error during changing the team settings in Github", err)
+			}
+			l.Info("team settings are changed in Github", "settings", drift)
+			drift = nil
+		}
+	}
+
+	if !slices.Equal(githubTeam.Status.SettingsDrift, drift) {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			latest := &v1.GithubTeam{}
+			if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+				return err
+			}
+			latest.Status.SettingsDrift = drift
+			return r.Client.Status().Update(ctx, latest)
+		})
+		if err != nil {
+			l.Error(err, "error during status update")
+			return reconcile.Result{}, true, err
+		}
+		githubTeam.Status.SettingsDrift = drift
+	}
+	return reconcile.Result{}, false, nil
+}
+
+// teamGithubError records err of a Github call in the status of the team: rate limits
+// requeue at their reset, other errors fail the team. Stale ETag cache entries are
+// retried right away.
+func (r *GithubTeamReconciler) teamGithubError(ctx context.Context, req ctrl.Request, msg string, err error) (reconcile.Result, bool, error) {
+	l := log.FromContext(ctx)
+	l.Error(err, msg)
+	if isEtagCacheInconsistency(err) {
+		// Cache was stale; provider already invalidated it. Requeue for a fresh fetch.
+		return reconcile.Result{RequeueAfter: time.Second}, true, nil
+	}
+
+	setStatus := func(state v1.GithubTeamState) error {
+		return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			latest := &v1.GithubTeam{}
+			if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+				return err
+			}
+			latest.Status.TeamStatus = state
+			latest.Status.TeamStatusError = msg + ": " + err.Error()
+			latest.Status.TeamStatusTimestamp = metav1.Now()
+			return r.Client.Status().Update(ctx, latest)
+		})
+	}
+	if t, ok := parseGitHubRateLimitReset(err.Error()); ok {
+		recordTeamRateLimitHit(err.Error(), t)
+		if uerr := setStatus(v1.GithubTeamStateRateLimited); uerr != nil {
+			l.Error(uerr, "error during status update")
+			return reconcile.Result{}, true, uerr
+		}
+		if now := time.Now().UTC(); t.After(now) {
+			return reconcile.Result{RequeueAfter: t.Sub(now)}, true, nil
+		}
+		return reconcile.Result{RequeueAfter: time.Second}, true, nil
+	}
+	if uerr := setStatus(v1.GithubTeamStateFailed); uerr != nil {
+		l.Error(uerr, "error during status update")
+		return reconcile.Result{}, true, uerr
+	}
+	return reconcile.Result{}, true, err
+}
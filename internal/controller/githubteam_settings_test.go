@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+func TestTeamSettingsDrift(t *testing.T) {
+	current := github.TeamSettings{Name: "Platform Squad", Description: "squad", Privacy: "closed", NotificationSetting: "notifications_enabled"}
+	cases := []struct {
+		name    string
+		desired github.TeamSettings
+		want    []string
+	}{
+		{name: "nothing managed", desired: github.TeamSettings{}, want: nil},
+		{name: "no drift", desired: current, want: nil},
+		{name: "privacy case is ignored", desired: github.TeamSettings{Privacy: "CLOSED"}, want: nil},
+		{name: "display name", desired: github.TeamSettings{Name: "platform squad"}, want: []string{"displayName"}},
+		{
+			name:    "all settings",
+			desired: github.TeamSettings{Name: "Platform-Squad", Description: "the squad", Privacy: "secret", NotificationSetting: "notifications_disabled"},
+			want:    []string{"displayName", "description", "privacy", "notificationSetting"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := teamSettingsDrift(current, tc.desired); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTeamSettingsValidationError(t *testing.T) {
+	cases := []struct {
+		name  string
+		spec  v1.GithubTeamSpec
+		valid bool
+	}{
+		{name: "no settings", spec: v1.GithubTeamSpec{Team: "platform-squad"}, valid: true},
+		{name: "display name of the slug", spec: v1.GithubTeamSpec{Team: "platform-squad", DisplayName: "Platform Squad"}, valid: true},
+		{name: "display name of another slug", spec: v1.GithubTeamSpec{Team: "platform-squad", DisplayName: "Platform"}, valid: false},
+		{name: "secret top-level team", spec: v1.GithubTeamSpec{Team: "a", Privacy: "secret"}, valid: true},
+		{name: "secret child team", spec: v1.GithubTeamSpec{Team: "a", Privacy: "secret", ParentTeam: "b"}, valid: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if msg := teamSettingsValidationError(tc.spec); (msg == "") != tc.valid {
+				t.Errorf("got %q, want valid=%v", msg, tc.valid)
+			}
+		})
+	}
+}
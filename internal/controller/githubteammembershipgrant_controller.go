@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// EVENT_REASON_MEMBERSHIP_GRANT_EXPIRED is the reason of the event emitted when a
+// GithubTeamMembershipGrant expires and its user is removed from the team.
+const EVENT_REASON_MEMBERSHIP_GRANT_EXPIRED = "MembershipGrantExpired"
+
+// GithubTeamMembershipGrantReconciler reports the state of GithubTeamMembershipGrants.
+// The members of active grants are merged into their team by the GithubTeam controller.
+type GithubTeamMembershipGrantReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=repo-guard.cloudoperators.dev,resources=githubteammembershipgrants,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=repo-guard.cloudoperators.dev,resources=githubteammembershipgrants/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=repo-guard.cloudoperators.dev,resources=githubteammembershipgrants/finalizers,verbs=update
+
+func (r *GithubTeamMembershipGrantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	l := log.FromContext(ctx)
+	done := ghmetrics.StartReconcileTimer("GithubTeamMembershipGrant")
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
+		} else if res.RequeueAfter > 0 {
+			result = "requeue"
+		}
+		done(result)
+	}()
+
+	grant := &v1.GithubTeamMembershipGrant{}
+	if err = r.Get(ctx, req.NamespacedName, grant); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !grant.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	githubTeam := &v1.GithubTeam{}
+	if err := r.Get(ctx, types.NamespacedName{Name: grant.Spec.GithubTeam, Namespace: grant.Namespace}, githubTeam); err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		githubTeam = nil
+	}
+
+	now := metav1.Now()
+	status := v1.GithubTeamMembershipGrantStatus{State: v1.GithubTeamMembershipGrantStateExpired}
+	if grant.Active(now) {
+		status.State = v1.GithubTeamMembershipGrantStateActive
+		if githubTeam == nil {
+			status.Error = "GithubTeam " + grant.Spec.GithubTeam + " not found"
+		} else {
+			status.Error = membershipGrantTeamError(githubTeam)
+		}
+	}
+
+	if grant.Status != status {
+		expired := grant.Status.State == v1.GithubTeamMembershipGrantStateActive && status.State == v1.GithubTeamMembershipGrantStateExpired
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			latest := &v1.GithubTeamMembershipGrant{}
+			if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+				return err
+			}
+			latest.Status = status
+			return r.Client.Status().Update(ctx, latest)
+		})
+		if err != nil {
+			l.Error(err, "error during status update")
+			return reconcile.Result{}, err
+		}
+		if expired {
+			l.Info("membership grant is expired", "team", grant.Spec.GithubTeam, "user", grant.Spec.User)
+			if githubTeam != nil {
+				ghmetrics.IncMembershipGrantExpired(strings.TrimSpace(githubTeam.Spec.Organization), strings.TrimSpace(githubTeam.Spec.Team))
+			}
+			if r.Recorder != nil {
+				r.Recorder.Eventf(grant, nil, corev1.EventTypeNormal, EVENT_REASON_MEMBERSHIP_GRANT_EXPIRED, "Expire",
+					"Membership of %s in GithubTeam %s expired", grant.Spec.User, grant.Spec.GithubTeam)
+			}
+		}
+	}
+
+	if status.State == v1.GithubTeamMembershipGrantStateActive {
+		return reconcile.Result{RequeueAfter: grant.Spec.ExpiresAt.Sub(now.Time)}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// membershipGrantTeamError returns why the grants of githubTeam cannot be merged into
+// its members, or "". The members of teams without a member provider are not managed.
+func membershipGrantTeamError(githubTeam *v1.GithubTeam) string {
+	if githubTeam.Spec.GreenhouseTeam == "" && githubTeam.Spec.ExternalMemberProvider == nil {
+		return "GithubTeam " + githubTeam.Name + " has no member provider"
+	}
+	return ""
+}
+
+// membershipGrantToGithubTeam enqueues the GithubTeam of a GithubTeamMembershipGrant.
+func membershipGrantToGithubTeam(ctx context.Context, o client.Object) []reconcile.Request {
+	grant, ok := o.(*v1.GithubTeamMembershipGrant)
+	if !ok || grant.Spec.GithubTeam == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: grant.Namespace, Name: grant.Spec.GithubTeam}}}
+}
+
+// githubTeamToMembershipGrants enqueues the GithubTeamMembershipGrants of a GithubTeam.
+func (r *GithubTeamMembershipGrantReconciler) githubTeamToMembershipGrants(ctx context.Context, o client.Object) []reconcile.Request {
+	grantList := &v1.GithubTeamMembershipGrantList{}
+	if err := r.List(ctx, grantList, client.InNamespace(o.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list GithubTeamMembershipGrants")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, grant := range grantList.Items {
+		if grant.Spec.GithubTeam == o.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: grant.Namespace, Name: grant.Name}})
+		}
+	}
+	return requests
+}
+
+func (r *GithubTeamMembershipGrantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.GithubTeamMembershipGrant{}).
+		Watches(&v1.GithubTeam{}, handler.EnqueueRequestsFromMapFunc(r.githubTeamToMembershipGrants)).
+		Complete(r)
+}
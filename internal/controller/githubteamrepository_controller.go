@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gosimple/slug"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// GithubTeamRepositoryReconciler manages the cleanup finalizer of GithubTeamRepositories.
+// The access of the teams to the repositories is granted by the GithubOrganization.
+type GithubTeamRepositoryReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder events.EventRecorder
+}
+
+func (r *GithubTeamRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	l := log.FromContext(ctx)
+	done := ghmetrics.StartReconcileTimer("GithubTeamRepository")
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "error"
+		} else if res.RequeueAfter > 0 {
+			result = "requeue"
+		}
+		done(result)
+	}()
+
+	teamRepository := &v1.GithubTeamRepository{}
+	if err = r.Get(ctx, req.NamespacedName, teamRepository); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if teamRepository.DeletionTimestamp.IsZero() {
+		if err = setCleanupFinalizer(ctx, r.Client, teamRepository, teamRepository.Spec.DeletionPolicy.NeedsCleanup()); err != nil {
+			l.Error(err, "error during updating the finalizer")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+	if !controllerutil.ContainsFinalizer(teamRepository, v1.REPO_GUARD_FINALIZER) {
+		return reconcile.Result{}, nil
+	}
+	if !teamRepository.Spec.DeletionPolicy.NeedsCleanup() {
+		l.Info("github team repository is deleted without cleanup in Github", "deletionPolicy", teamRepository.Spec.DeletionPolicy)
+		return reconcile.Result{}, setCleanupFinalizer(ctx, r.Client, teamRepository, false)
+	}
+
+	reposProvider, githubOrganization, orphaned, err := r.cleanupRepositoryProvider(ctx, teamRepository)
+	if err != nil {
+		l.Error(err, "error during creating the repository provider for the cleanup")
+		return reconcile.Result{}, err
+	}
+	if orphaned != "" {
+		l.Info("github team repository is deleted without cleanup in Github", "reason", orphaned)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(teamRepository, nil, corev1.EventTypeWarning, EVENT_REASON_CLEANUP_ORPHANED, "Delete",
+				"Access of team %s is left in Github: %s", teamRepository.Spec.Team, orphaned)
+		}
+		return reconcile.Result{}, setCleanupFinalizer(ctx, r.Client, teamRepository, false)
+	}
+	if reposProvider == nil {
+		if err := r.setDeleting(ctx, req, teamRepository, "waiting for the github client"); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: deletionRecheckInterval}, nil
+	}
+
+	if err := r.setDeleting(ctx, req, teamRepository, ""); err != nil {
+		return reconcile.Result{}, err
+	}
+	if repo, err := r.removeTeamAccess(ctx, teamRepository, githubOrganization, reposProvider); err != nil {
+		msg := fmt.Sprintf("error during removing the access of the team to %s in Github", repo)
+		l.Error(err, msg)
+		if uerr := r.setDeleting(ctx, req, teamRepository, msg+": "+err.Error()); uerr != nil {
+			return reconcile.Result{}, uerr
+		}
+		if res, ok := deletionRetry(err); ok {
+			return res, nil
+		}
+		return reconcile.Result{}, err
+	}
+	l.Info("access of the team to the repositories is removed in Github", "team", teamRepository.Spec.Team, "repositories", len(teamRepository.Spec.Repository))
+
+	return reconcile.Result{}, setCleanupFinalizer(ctx, r.Client, teamRepository, false)
+}
+
+// cleanupRepositoryProvider returns the repository provider of the organization of
+// teamRepository and the organization. orphaned is the reason why Github cannot be
+// cleaned up anymore; the provider is nil while the Github client is not ready yet.
+func (r *GithubTeamRepositoryReconciler) cleanupRepositoryProvider(ctx context.Context, teamRepository *v1.GithubTeamRepository) (reposProvider github.RepositoryProvider, githubOrganization *v1.GithubOrganization, orphaned string, err error) {
+	githubName := teamRepository.Spec.Github
+	if err := r.Get(ctx, types.NamespacedName{Name: githubName}, &v1.Github{}); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, fmt.Sprintf("github %s not found", githubName), nil
+		}
+		return nil, nil, "", err
+	}
+	githubOrganization = &v1.GithubOrganization{}
+	githubOrganizationName := fmt.Sprintf("%s--%s", strings.ToLower(githubName), strings.ToLower(teamRepository.Spec.Organization))
+	if err := r.Get(ctx, types.NamespacedName{Name: githubOrganizationName, Namespace: teamRepository.Namespace}, githubOrganization); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, fmt.Sprintf("organization %s not found", githubOrganizationName), nil
+		}
+		return nil, nil, "", err
+	}
+
+	githubClient, ok := GithubClients.Get(githubName)
+	if !ok {
+		return nil, githubOrganization, "", nil
+	}
+	installationID := githubOrganization.ResolvedInstallationID()
+	if installationID == 0 && !github.UsesTokenAuth(githubClient) {
+		return nil, githubOrganization, "", nil
+	}
+	reposProvider, err = github.NewRepositoryProvider(githubClient, githubName, teamRepository.Spec.Organization, installationID)
+	return reposProvider, githubOrganization, "", err
+}
+
+// removeTeamAccess removes the access of the team of teamRepository to its
+// repositories. A repository on which the team keeps a permission is set to that
+// permission instead, so that the team does not lose its standing access. repo is the
+// repository that failed.
+func (r *GithubTeamRepositoryReconciler) removeTeamAccess(ctx context.Context, teamRepository *v1.GithubTeamRepository, githubOrganization *v1.GithubOrganization, reposProvider github.RepositoryProvider) (repo string, err error) {
+	permissions, err := r.remainingPermissions(ctx, teamRepository, githubOrganization, reposProvider)
+	if err != nil {
+		return "", err
+	}
+	team := slug.Make(teamRepository.Spec.Team)
+	for _, repo := range teamRepository.Spec.Repository {
+		if permission, ok := permissions[repo]; ok {
+			if err := reposProvider.RepositoryTeamAdd(ctx, repo, team, permission); err != nil {
+				return repo, err
+			}
+			log.FromContext(ctx).Info("access of the team to the repository is set to the remaining permission", "team", team, "repository", repo, "permission", permission)
+			continue
+		}
+		if err := reposProvider.RepositoryTeamRemove(ctx, repo, team); err != nil {
+			return repo, err
+		}
+	}
+	return "", nil
+}
+
+// remainingPermissions returns the permissions the team of teamRepository keeps on its
+// repositories once teamRepository is deleted: the highest permission of the other
+// GithubTeamRepositories of the team and the default repository teams of
+// githubOrganization.
+func (r *GithubTeamRepositoryReconciler) remainingPermissions(ctx context.Context, teamRepository *v1.GithubTeamRepository, githubOrganization *v1.GithubOrganization, reposProvider github.RepositoryProvider) (map[string]v1.GithubTeamPermission, error) {
+	team := slug.Make(teamRepository.Spec.Team)
+	permissions := make(map[string]v1.GithubTeamPermission)
+
+	defaults := make(map[string]v1.GithubTeamPermission)
+	for visibility, teams := range map[string][]v1.GithubTeamWithPermission{
+		"public":   githubOrganization.Spec.DefaultPublicRepositoryTeams,
+		"private":  githubOrganization.Spec.DefaultPrivateRepositoryTeams,
+		"internal": githubOrganization.Spec.DefaultInternalRepositoryTeams,
+	} {
+		for _, defaultTeam := range teams {
+			if slug.Make(defaultTeam.Team) == team {
+				defaults[visibility] = defaultTeam.Permission
+			}
+		}
+	}
+	if len(defaults) > 0 {
+		public, private, internal, err := reposProvider.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		skipList := strings.Split(githubOrganization.Annotations[v1.GITHUB_ORG_ANNOTATION_SKIP_DEFAULT_TEAM_REPOSITORY], ",")
+		for _, repo := range teamRepository.Spec.Repository {
+			if slices.Contains(skipList, repo) {
+				continue
+			}
+			for visibility, repos := range map[string][]string{"public": public, "private": private, "internal": internal} {
+				if permission, ok := defaults[visibility]; ok && slices.Contains(repos, repo) {
+					permissions[repo] = v1.HigherPermission(permissions[repo], permission)
+				}
+			}
+		}
+	}
+
+	list := &v1.GithubTeamRepositoryList{}
+	if err := r.List(ctx, list); err != nil {
+		return nil, err
+	}
+	for _, other := range list.Items {
+		if other.Namespace == teamRepository.Namespace && other.Name == teamRepository.Name {
+			continue
+		}
+		if cleansUpGithub(&other, other.Spec.DeletionPolicy) {
+			continue
+		}
+		if other.Spec.Github != teamRepository.Spec.Github || other.Spec.Organization != teamRepository.Spec.Organization || slug.Make(other.Spec.Team) != team {
+			continue
+		}
+		for _, repo := range other.Spec.Repository {
+			if slices.Contains(teamRepository.Spec.Repository, repo) {
+				permissions[repo] = v1.HigherPermission(permissions[repo], other.Spec.Permission)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// setDeleting reports the deletion of teamRepository in its status, with msg as error.
+func (r *GithubTeamRepositoryReconciler) setDeleting(ctx context.Context, req ctrl.Request, teamRepository *v1.GithubTeamRepository, msg string) error {
+	if teamRepository.Status.State == v1.GithubTeamRepositoryStateDeleting && teamRepository.Status.Error == msg {
+		return nil
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1.GithubTeamRepository{}
+		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return err
+		}
+		latest.Status.State = v1.GithubTeamRepositoryStateDeleting
+		latest.Status.Error = msg
+		return r.Client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error during status update")
+		return err
+	}
+	teamRepository.Status.State = v1.GithubTeamRepositoryStateDeleting
+	teamRepository.Status.Error = msg
+	return nil
+}
+
+func (r *GithubTeamRepositoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.GithubTeamRepository{}).
+		Complete(r)
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+// fakeRepositoryProvider records the team changes of repositories; the other methods
+// of the interface are not implemented.
+type fakeRepositoryProvider struct {
+	github.RepositoryProvider
+	public, private, internal []string
+	added                     map[string]v1.GithubTeamPermission
+	removed                   []string
+}
+
+func (f *fakeRepositoryProvider) List(ctx context.Context) ([]string, []string, []string, error) {
+	return f.public, f.private, f.internal, nil
+}
+
+func (f *fakeRepositoryProvider) RepositoryTeamAdd(ctx context.Context, repo, team string, permission v1.GithubTeamPermission) error {
+	f.added[repo+"/"+team] = permission
+	return nil
+}
+
+func (f *fakeRepositoryProvider) RepositoryTeamRemove(ctx context.Context, repo, team string) error {
+	f.removed = append(f.removed, repo+"/"+team)
+	return nil
+}
+
+func TestRemoveTeamAccessKeepsRemainingPermissions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	githubOrganization := &v1.GithubOrganization{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "com--acme"},
+		Spec: v1.GithubOrganizationSpec{
+			Github:                        "com",
+			Organization:                  "acme",
+			DefaultPrivateRepositoryTeams: []v1.GithubTeamWithPermission{{Team: "SRE", Permission: v1.GithubTeamPermissionPull}},
+			DefaultPublicRepositoryTeams:  []v1.GithubTeamWithPermission{{Team: "SRE", Permission: v1.GithubTeamPermissionPush}},
+		},
+	}
+	teamRepository := func(name string, permission v1.GithubTeamPermission, repositories ...string) *v1.GithubTeamRepository {
+		return &v1.GithubTeamRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: v1.GithubTeamRepositorySpec{
+				Github: "com", Organization: "acme", Team: "SRE",
+				Repository: repositories, Permission: permission,
+			},
+		}
+	}
+	// the temporary grant of an access request
+	grant := teamRepository("grant", v1.GithubTeamPermissionPush, "app", "deploy", "docs", "wiki")
+	grant.Spec.DeletionPolicy = v1.DeletionPolicyDelete
+	// overlapping GithubTeamRepositories with different permissions
+	admin := teamRepository("a-admin", v1.GithubTeamPermissionAdmin, "deploy")
+	triage := teamRepository("b-triage", "triage", "app", "deploy", "docs")
+	// a GithubTeamRepository that is cleaned up itself grants nothing
+	expiring := teamRepository("expiring", v1.GithubTeamPermissionPush, "wiki")
+	expiring.Spec.DeletionPolicy = v1.DeletionPolicyDelete
+	now := metav1.Now()
+	expiring.DeletionTimestamp = &now
+	expiring.Finalizers = []string{v1.REPO_GUARD_FINALIZER}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(githubOrganization, grant, admin, triage, expiring).Build()
+	r := &GithubTeamRepositoryReconciler{Client: c}
+	provider := &fakeRepositoryProvider{
+		private: []string{"app"},
+		public:  []string{"deploy", "docs"},
+		added:   map[string]v1.GithubTeamPermission{},
+	}
+
+	if repo, err := r.removeTeamAccess(t.Context(), grant, githubOrganization, provider); err != nil {
+		t.Fatalf("removeTeamAccess: %s: %v", repo, err)
+	}
+	// the highest remaining permission wins: triage over the private default on app,
+	// admin over triage and the public default on deploy, the public default over
+	// triage on docs
+	want := map[string]v1.GithubTeamPermission{"app/sre": "triage", "deploy/sre": v1.GithubTeamPermissionAdmin, "docs/sre": v1.GithubTeamPermissionPush}
+	if !reflect.DeepEqual(provider.added, want) {
+		t.Errorf("unexpected downgrades %v, want %v", provider.added, want)
+	}
+	if want := []string{"wiki/sre"}; !reflect.DeepEqual(provider.removed, want) {
+		t.Errorf("unexpected removals %v, want %v", provider.removed, want)
+	}
+}
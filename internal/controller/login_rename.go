@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+// EVENT_REASON_GITHUB_LOGIN_RENAMED is the reason of the event emitted when a
+// member's GitHub login changed while its GitHub UID stayed the same.
+const EVENT_REASON_GITHUB_LOGIN_RENAMED = "GithubLoginRenamed"
+
+// loginRename is a GitHub login change observed for the same GitHub UID.
+type loginRename struct {
+	uid      int64
+	oldLogin string
+	newLogin string
+}
+
+// loginRenames returns the members of observed whose GitHub UID is listed in
+// previous under a different login. Members without a UID are never matched.
+func loginRenames(previous, observed []v1.Member) []loginRename {
+	previousByUID := make(map[int64]string, len(previous))
+	for _, m := range previous {
+		if m.GithubUID != 0 {
+			previousByUID[m.GithubUID] = m.GithubUsername
+		}
+	}
+	var renames []loginRename
+	for _, m := range observed {
+		oldLogin, ok := previousByUID[m.GithubUID]
+		if !ok || m.GithubUID == 0 || strings.EqualFold(oldLogin, m.GithubUsername) {
+			continue
+		}
+		renames = append(renames, loginRename{uid: m.GithubUID, oldLogin: oldLogin, newLogin: m.GithubUsername})
+	}
+	return renames
+}
+
+// renameOperationUsers rewrites operations for a renamed login to the new login
+// in place, so that their state carries over instead of being queued again.
+func renameOperationUsers(operations []v1.GithubUserOperation, renames []loginRename) {
+	for _, rename := range renames {
+		for i := range operations {
+			if strings.EqualFold(operations[i].User, rename.oldLogin) {
+				operations[i].User = rename.newLogin
+			}
+		}
+	}
+}
+
+// recordLoginRenames emits one event on obj per rename. It is a no-op without a recorder.
+func recordLoginRenames(recorder events.EventRecorder, obj runtime.Object, renames []loginRename) {
+	if recorder == nil {
+		return
+	}
+	for _, rename := range renames {
+		recorder.Eventf(obj, nil, corev1.EventTypeNormal, EVENT_REASON_GITHUB_LOGIN_RENAMED, "Rename",
+			"GitHub user %d renamed from %s to %s", rename.uid, rename.oldLogin, rename.newLogin)
+	}
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"testing"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+func TestLoginRenames(t *testing.T) {
+	previous := []v1.Member{
+		{GreenhouseID: "jdoe", GithubUsername: "jdoe-old", GithubUID: 42},
+		{GreenhouseID: "asmith", GithubUsername: "asmith", GithubUID: 43},
+		{GreenhouseID: "legacy", GithubUsername: "legacy"},
+	}
+	observed := []v1.Member{
+		{GreenhouseID: "jdoe", GithubUsername: "jdoe-new", GithubUID: 42},
+		{GreenhouseID: "asmith", GithubUsername: "ASmith", GithubUID: 43},
+		{GreenhouseID: "legacy", GithubUsername: "legacy-new"},
+		{GreenhouseID: "newcomer", GithubUsername: "newcomer", GithubUID: 44},
+	}
+
+	renames := loginRenames(previous, observed)
+	if len(renames) != 1 || renames[0] != (loginRename{uid: 42, oldLogin: "jdoe-old", newLogin: "jdoe-new"}) {
+		t.Fatalf("renames = %+v, want only jdoe-old -> jdoe-new", renames)
+	}
+
+	ops := []v1.GithubUserOperation{
+		{Operation: v1.GithubUserOperationTypeAdd, User: "JDoe-Old", State: v1.GithubUserOperationStateComplete},
+		{Operation: v1.GithubUserOperationTypeAdd, User: "asmith", State: v1.GithubUserOperationStatePending},
+	}
+	renameOperationUsers(ops, renames)
+	if ops[0].User != "jdoe-new" || ops[1].User != "asmith" {
+		t.Errorf("ops = %+v, want only the jdoe operation renamed", ops)
+	}
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+	"github.com/cloudoperators/repo-guard/internal/github"
+)
+
+const (
+	// defaultNotFoundRecheckInterval is the first delay before users in the
+	// notfound state are re-resolved against GitHub.
+	defaultNotFoundRecheckInterval = time.Hour
+	// maxNotFoundRecheckInterval caps the exponential backoff between re-checks.
+	maxNotFoundRecheckInterval = 24 * time.Hour
+)
+
+// notFoundRecheckInterval returns the base re-check interval for a team. The
+// GITHUB_TEAM_LABEL_NOTFOUND_RECHECK_INTERVAL label overrides the default; a
+// value of "0" disables the re-check. Invalid values fall back to the default.
+func notFoundRecheckInterval(labels map[string]string) (time.Duration, bool) {
+	s, ok := labels[GITHUB_TEAM_LABEL_NOTFOUND_RECHECK_INTERVAL]
+	if !ok || s == "" {
+		return defaultNotFoundRecheckInterval, true
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return defaultNotFoundRecheckInterval, true
+	}
+	if d == 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// notFoundRecheckBackoff returns the delay before the next re-check after the
+// given number of unsuccessful attempts. The delay doubles per attempt and is
+// capped at maxNotFoundRecheckInterval, or at base if base is larger.
+func notFoundRecheckBackoff(base time.Duration, attempts int) time.Duration {
+	limit := max(base, maxNotFoundRecheckInterval)
+	d := base
+	for i := 0; i < attempts && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// notFoundUsers returns the distinct users with an add operation in the
+// notfound state, in the order they first appear. Users are compared
+// case-insensitively, the first spelling seen is returned.
+func notFoundUsers(ops []v1.GithubUserOperation) []string {
+	seen := make(map[string]bool)
+	var users []string
+	for _, op := range ops {
+		if op.Operation != v1.GithubUserOperationTypeAdd || op.State != v1.GithubUserOperationStateNotFound {
+			continue
+		}
+		lower := strings.ToLower(op.User)
+		if seen[lower] {
+			continue
+		}
+		seen[lower] = true
+		users = append(users, op.User)
+	}
+	return users
+}
+
+// notFoundRecheckNext returns when the notfound users of a team are due for
+// the next re-check. Without a recorded schedule the first re-check happens
+// base after the most recent notfound operation. The zero time is returned
+// when there is nothing to re-check.
+func notFoundRecheckNext(status v1.GithubTeamStatus, base time.Duration) time.Time {
+	if status.NotFoundRecheck != nil && !status.NotFoundRecheck.NextCheck.IsZero() {
+		return status.NotFoundRecheck.NextCheck.Time
+	}
+	var latest time.Time
+	for _, op := range status.Operations {
+		if op.Operation == v1.GithubUserOperationTypeAdd &&
+			op.State == v1.GithubUserOperationStateNotFound &&
+			op.Timestamp.After(latest) {
+			latest = op.Timestamp.Time
+		}
+	}
+	if latest.IsZero() {
+		return latest
+	}
+	return latest.Add(base)
+}
+
+// recheckNotFoundUsers re-resolves the given users through the users provider
+// and returns the lowercased logins that exist on GitHub now. Lookup errors
+// abort the re-check so that the schedule is not advanced on transient failures.
+func recheckNotFoundUsers(usersProvider github.UsersProvider, users []string) (map[string]bool, error) {
+	resolved := make(map[string]bool)
+	for _, user := range users {
+		_, found, err := usersProvider.GithubIDByUsername(user)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			resolved[strings.ToLower(user)] = true
+		}
+	}
+	return resolved, nil
+}
+
+// applyNotFoundRecheck drops notfound add operations of resolved users so that
+// ChangeCalculator queues a fresh add for them, and advances the backoff
+// schedule. The schedule keeps backing off even when users were resolved, so
+// that a user who exists but still cannot be added is not retried at the base
+// interval forever; the caller clears it once no notfound users remain.
+func applyNotFoundRecheck(
+	status *v1.GithubTeamStatus,
+	resolved map[string]bool,
+	base time.Duration,
+	now time.Time,
+) {
+	var ops []v1.GithubUserOperation
+	for _, op := range status.Operations {
+		if op.Operation == v1.GithubUserOperationTypeAdd &&
+			op.State == v1.GithubUserOperationStateNotFound &&
+			resolved[strings.ToLower(op.User)] {
+			continue
+		}
+		ops = append(ops, op)
+	}
+	status.Operations = ops
+
+	attempts := 1
+	if status.NotFoundRecheck != nil {
+		attempts = status.NotFoundRecheck.Attempts + 1
+	}
+	status.NotFoundRecheck = &v1.NotFoundRecheckStatus{
+		Attempts:  attempts,
+		LastCheck: metav1.NewTime(now),
+		NextCheck: metav1.NewTime(now.Add(notFoundRecheckBackoff(base, attempts))),
+	}
+}
@@ -0,0 +1,236 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+// fakeUsersProvider resolves logins from a fixed set; err is returned for every lookup when set.
+// existing maps lowercase logins to IDs, logins maps IDs to logins.
+type fakeUsersProvider struct {
+	existing  map[string]string
+	logins    map[string]string
+	suspended map[string]bool
+	err       error
+	lookups   []string
+}
+
+func (f *fakeUsersProvider) GithubUsernameByID(id string) (string, bool, error) {
+	f.lookups = append(f.lookups, id)
+	if f.err != nil {
+		return "", false, f.err
+	}
+	login, ok := f.logins[id]
+	return login, ok, nil
+}
+
+func (f *fakeUsersProvider) GithubIDByUsername(username string) (string, bool, error) {
+	f.lookups = append(f.lookups, username)
+	if f.err != nil {
+		return "", false, f.err
+	}
+	id, ok := f.existing[strings.ToLower(username)]
+	return id, ok, nil
+}
+
+func (f *fakeUsersProvider) IsMemberOfOrg(_ context.Context, _ string, _ string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeUsersProvider) GithubUserByID(_ context.Context, uid string) (string, bool, bool, error) {
+	f.lookups = append(f.lookups, uid)
+	if f.err != nil {
+		return "", false, false, f.err
+	}
+	login, ok := f.logins[uid]
+	return login, ok, f.suspended[uid], nil
+}
+
+func (f *fakeUsersProvider) HasVerifiedEmailDomainForGithubUID(_ context.Context, _ string, _ string, _ string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeUsersProvider) OrgMembersVerifiedDomainEmails(_ context.Context, _ string) (map[string][]string, int, error) {
+	return nil, 0, nil
+}
+
+func TestNotFoundRecheckInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		wantBase    time.Duration
+		wantEnabled bool
+	}{
+		{"no labels", nil, defaultNotFoundRecheckInterval, true},
+		{"override", map[string]string{GITHUB_TEAM_LABEL_NOTFOUND_RECHECK_INTERVAL: "15m"}, 15 * time.Minute, true},
+		{"disabled", map[string]string{GITHUB_TEAM_LABEL_NOTFOUND_RECHECK_INTERVAL: "0"}, 0, false},
+		{"invalid falls back to default", map[string]string{GITHUB_TEAM_LABEL_NOTFOUND_RECHECK_INTERVAL: "soon"}, defaultNotFoundRecheckInterval, true},
+		{"negative falls back to default", map[string]string{GITHUB_TEAM_LABEL_NOTFOUND_RECHECK_INTERVAL: "-1h"}, defaultNotFoundRecheckInterval, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, enabled := notFoundRecheckInterval(tt.labels)
+			if base != tt.wantBase || enabled != tt.wantEnabled {
+				t.Errorf("got (%v, %v), want (%v, %v)", base, enabled, tt.wantBase, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestNotFoundRecheckBackoff(t *testing.T) {
+	tests := []struct {
+		base     time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{time.Hour, 0, time.Hour},
+		{time.Hour, 1, 2 * time.Hour},
+		{time.Hour, 3, 8 * time.Hour},
+		{time.Hour, 5, 24 * time.Hour},
+		{time.Hour, 1000, 24 * time.Hour},
+		{48 * time.Hour, 3, 48 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := notFoundRecheckBackoff(tt.base, tt.attempts); got != tt.want {
+			t.Errorf("notFoundRecheckBackoff(%v, %d) = %v, want %v", tt.base, tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestNotFoundUsers(t *testing.T) {
+	ts := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ops := []v1.GithubUserOperation{
+		makeUserOp("alice", v1.GithubUserOperationStateNotFound, ts),
+		makeUserOp("Alice", v1.GithubUserOperationStateNotFound, ts),
+		makeUserOp("bob", v1.GithubUserOperationStateComplete, ts),
+		{Operation: v1.GithubUserOperationTypeRemove, User: "carol", State: v1.GithubUserOperationStateNotFound},
+		makeUserOp("dave", v1.GithubUserOperationStateNotFound, ts),
+	}
+	got := notFoundUsers(ops)
+	if len(got) != 2 || got[0] != "alice" || got[1] != "dave" {
+		t.Errorf("notFoundUsers() = %v, want [alice dave]", got)
+	}
+}
+
+func TestNotFoundRecheckNext(t *testing.T) {
+	older := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	newer := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	scheduled := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("nothing to re-check", func(t *testing.T) {
+		status := v1.GithubTeamStatus{Operations: []v1.GithubUserOperation{
+			makeUserOp("alice", v1.GithubUserOperationStateComplete, newer),
+		}}
+		if got := notFoundRecheckNext(status, time.Hour); !got.IsZero() {
+			t.Errorf("expected zero time, got %v", got)
+		}
+	})
+
+	t.Run("first re-check is base after the newest notfound op", func(t *testing.T) {
+		status := v1.GithubTeamStatus{Operations: []v1.GithubUserOperation{
+			makeUserOp("alice", v1.GithubUserOperationStateNotFound, older),
+			makeUserOp("bob", v1.GithubUserOperationStateNotFound, newer),
+		}}
+		if got := notFoundRecheckNext(status, time.Hour); !got.Equal(newer.Add(time.Hour)) {
+			t.Errorf("got %v, want %v", got, newer.Add(time.Hour))
+		}
+	})
+
+	t.Run("recorded schedule wins", func(t *testing.T) {
+		status := v1.GithubTeamStatus{
+			Operations: []v1.GithubUserOperation{
+				makeUserOp("alice", v1.GithubUserOperationStateNotFound, newer),
+			},
+			NotFoundRecheck: &v1.NotFoundRecheckStatus{Attempts: 2, NextCheck: metav1.NewTime(scheduled)},
+		}
+		if got := notFoundRecheckNext(status, time.Hour); !got.Equal(scheduled) {
+			t.Errorf("got %v, want %v", got, scheduled)
+		}
+	})
+}
+
+func TestRecheckNotFoundUsers(t *testing.T) {
+	t.Run("returns lowercased resolved logins", func(t *testing.T) {
+		provider := &fakeUsersProvider{existing: map[string]string{"alice": "1"}}
+		resolved, err := recheckNotFoundUsers(provider, []string{"Alice", "bob"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resolved) != 1 || !resolved["alice"] {
+			t.Errorf("resolved = %v, want only alice", resolved)
+		}
+		if len(provider.lookups) != 2 {
+			t.Errorf("expected 2 lookups, got %v", provider.lookups)
+		}
+	})
+
+	t.Run("lookup error aborts the re-check", func(t *testing.T) {
+		provider := &fakeUsersProvider{err: errors.New("boom")}
+		if _, err := recheckNotFoundUsers(provider, []string{"alice", "bob"}); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if len(provider.lookups) != 1 {
+			t.Errorf("expected the re-check to stop after the first error, got %v", provider.lookups)
+		}
+	})
+}
+
+func TestApplyNotFoundRecheck(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ts := now.Add(-2 * time.Hour)
+
+	t.Run("resolved users are dropped and schedule advances", func(t *testing.T) {
+		status := v1.GithubTeamStatus{Operations: []v1.GithubUserOperation{
+			makeUserOp("alice", v1.GithubUserOperationStateNotFound, ts),
+			makeUserOp("bob", v1.GithubUserOperationStateNotFound, ts),
+			makeUserOp("carol", v1.GithubUserOperationStateComplete, ts),
+		}}
+		applyNotFoundRecheck(&status, map[string]bool{"alice": true}, time.Hour, now)
+
+		if len(status.Operations) != 2 || status.Operations[0].User != "bob" || status.Operations[1].User != "carol" {
+			t.Errorf("unexpected operations: %+v", status.Operations)
+		}
+		if status.NotFoundRecheck == nil {
+			t.Fatal("expected schedule to be recorded")
+		}
+		if status.NotFoundRecheck.Attempts != 1 {
+			t.Errorf("Attempts = %d, want 1", status.NotFoundRecheck.Attempts)
+		}
+		if !status.NotFoundRecheck.LastCheck.Time.Equal(now) {
+			t.Errorf("LastCheck = %v, want %v", status.NotFoundRecheck.LastCheck, now)
+		}
+		if want := now.Add(2 * time.Hour); !status.NotFoundRecheck.NextCheck.Time.Equal(want) {
+			t.Errorf("NextCheck = %v, want %v", status.NotFoundRecheck.NextCheck, want)
+		}
+	})
+
+	t.Run("backoff grows across attempts", func(t *testing.T) {
+		status := v1.GithubTeamStatus{
+			Operations: []v1.GithubUserOperation{
+				makeUserOp("bob", v1.GithubUserOperationStateNotFound, ts),
+			},
+			NotFoundRecheck: &v1.NotFoundRecheckStatus{Attempts: 3},
+		}
+		applyNotFoundRecheck(&status, map[string]bool{}, time.Hour, now)
+
+		if status.NotFoundRecheck.Attempts != 4 {
+			t.Errorf("Attempts = %d, want 4", status.NotFoundRecheck.Attempts)
+		}
+		if want := now.Add(16 * time.Hour); !status.NotFoundRecheck.NextCheck.Time.Equal(want) {
+			t.Errorf("NextCheck = %v, want %v", status.NotFoundRecheck.NextCheck, want)
+		}
+		if len(status.Operations) != 1 {
+			t.Errorf("unresolved notfound op must be kept, got %+v", status.Operations)
+		}
+	})
+}
@@ -242,7 +242,13 @@ func (r *GithubOrganizationReconciler) safeStatusUpdate(
 		if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
 			return err
 		}
+		// status.samlAccountLinks is owned by GithubOrganizationSAMLReconciler and
+		// status.auditLog by GithubOrganizationAuditLogReconciler.
+		samlAccountLinks := latest.Status.SAMLAccountLinks
+		auditLog := latest.Status.AuditLog
 		latest.Status = *status
+		latest.Status.SAMLAccountLinks = samlAccountLinks
+		latest.Status.AuditLog = auditLog
 		return r.Client.Status().Update(ctx, latest)
 	})
 }
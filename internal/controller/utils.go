@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
 )
 
 var (
@@ -56,7 +58,10 @@ func isEtagCacheInconsistency(err error) bool {
 //     → returns a synthetic backoff of now+1h (the API does not provide a reset time for this case).
 //
 //   - GraphQL secondary rate limit: "You have exceeded a secondary rate limit" / "API rate limit exceeded for installation ID"
+//     / "You have triggered an abuse detection mechanism"
 //     → treated as a rate-limit error and returns now+1h as a conservative backoff.
+//     Clients with a rate-limit budget report secondary limits with the advised
+//     retry time instead ("mutations paused until <ts>", Format 1).
 //
 // Returns the retry-after time in UTC and true if the error is a recognisable rate-limit error;
 // otherwise returns zero time and false.
@@ -73,7 +78,7 @@ func parseGitHubRateLimitReset(errStr string) (time.Time, bool) {
 		strings.Contains(lowered, "exceeded the organization invitation rate limit") {
 		return time.Now().UTC().Add(time.Hour), true
 	}
-	if !strings.Contains(lowered, "rate limit") {
+	if !strings.Contains(lowered, "rate limit") && !strings.Contains(lowered, "abuse detection") {
 		return time.Time{}, false
 	}
 	// Format 2: "[rate limit was reset N ago]" — the limit has already cleared.
@@ -106,6 +111,7 @@ func parseGitHubRateLimitReset(errStr string) (time.Time, bool) {
 	if !strings.Contains(lowered, "until ") {
 		// GraphQL secondary rate limit strings (no timestamp): treat as rate-limited with 1h backoff.
 		if strings.Contains(lowered, "secondary rate limit") ||
+			strings.Contains(lowered, "abuse detection") ||
 			strings.Contains(lowered, "api rate limit exceeded for installation") {
 			return time.Now().UTC().Add(time.Hour), true
 		}
@@ -132,3 +138,16 @@ func parseGitHubRateLimitReset(errStr string) (time.Time, bool) {
 	}
 	return time.Time{}, false
 }
+
+// emuShortcode returns the Enterprise Managed Users shortcode for org, or "" if
+// the organization is not part of an EMU enterprise. The organization's
+// configuration takes precedence over the one of its Github.
+func emuShortcode(githubInstance *v1.Github, org *v1.GithubOrganization) string {
+	if org.Spec.EnterpriseManagedUsers != nil {
+		return org.Spec.EnterpriseManagedUsers.Shortcode
+	}
+	if githubInstance.Spec.EnterpriseManagedUsers != nil {
+		return githubInstance.Spec.EnterpriseManagedUsers.Shortcode
+	}
+	return ""
+}
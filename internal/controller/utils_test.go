@@ -7,6 +7,8 @@ import (
 	"errors"
 	"testing"
 	"time"
+
+	v1 "github.com/cloudoperators/repo-guard/api/v1"
 )
 
 func TestIsEtagCacheInconsistency(t *testing.T) {
@@ -180,4 +182,51 @@ func TestParseGitHubRateLimitReset(t *testing.T) {
 			t.Fatalf("expected ~now for zero duration, got %v", got)
 		}
 	})
+
+	t.Run("paused mutations after a secondary rate limit", func(t *testing.T) {
+		// Error of a mutation while the budget transport pauses the installation,
+		// wrapped by net/http.
+		errStr := `PUT "https://api.github.com/orgs/foo/teams/bar/repos/foo/baz": secondary rate limit exceeded: mutations paused until 2026-07-06 19:15:42 +0000 UTC`
+		got, ok := parseGitHubRateLimitReset(errStr)
+		if !ok {
+			t.Fatal("expected ok=true for paused mutations, got false")
+		}
+		expected := time.Date(2026, 7, 6, 19, 15, 42, 0, time.UTC)
+		if !got.Equal(expected) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("abuse detection mechanism", func(t *testing.T) {
+		errStr := "PUT https://ghe.example.com/api/v3/teams/1/repos/foo/bar: 403 You have triggered an abuse detection mechanism. Please wait a few minutes before you try again."
+		if _, ok := parseGitHubRateLimitReset(errStr); !ok {
+			t.Fatal("expected ok=true for abuse detection, got false")
+		}
+	})
+}
+
+func TestEmuShortcode(t *testing.T) {
+	emu := func(shortcode string) *v1.EnterpriseManagedUsers {
+		return &v1.EnterpriseManagedUsers{Shortcode: shortcode}
+	}
+	tests := []struct {
+		name   string
+		github *v1.EnterpriseManagedUsers
+		org    *v1.EnterpriseManagedUsers
+		want   string
+	}{
+		{"not configured", nil, nil, ""},
+		{"inherited from github", emu("acme"), nil, "acme"},
+		{"organization overrides github", emu("acme"), emu("other"), "other"},
+		{"organization only", nil, emu("other"), "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			githubInstance := &v1.Github{Spec: v1.GithubSpec{EnterpriseManagedUsers: tt.github}}
+			org := &v1.GithubOrganization{Spec: v1.GithubOrganizationSpec{EnterpriseManagedUsers: tt.org}}
+			if got := emuShortcode(githubInstance, org); got != tt.want {
+				t.Errorf("emuShortcode = %q, want %q", got, tt.want)
+			}
+		})
+	}
 }
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gogithub "github.com/google/go-github/v90/github"
+	"github.com/palantir/go-githubapp/githubapp"
+)
+
+// DefaultAuditLogActions are the audit log actions that change who has access to an
+// organization, its teams or its repositories.
+var DefaultAuditLogActions = []string{"team.add_member", "team.add_repository", "org.add_member", "repo.add_member"}
+
+// auditLogMaxPages bounds the pages read per action and poll.
+const auditLogMaxPages = 10
+
+// AuditLogEntry is an entry of the audit log of an organization.
+type AuditLogEntry struct {
+	// ID identifies the entry: its _document_id, or its fields if GitHub omits it.
+	ID     string
+	Action string
+	Actor  string
+	// User is the user affected by the action, if any.
+	User string
+	// Team and Repository are "<org>/<name>" if the action is about one.
+	Team       string
+	Repository string
+	CreatedAt  time.Time
+}
+
+type AuditLogProvider interface {
+	// Entries returns the audit log entries with one of actions created at or after
+	// since, oldest first. If the pages read of an action are capped, truncated is the
+	// creation time of the last entry read of it and the entries created after it are
+	// left out, so that a later call from truncated reads them.
+	Entries(ctx context.Context, actions []string, since time.Time) (entries []AuditLogEntry, truncated time.Time, err error)
+}
+
+type DefaultAuditLogProvider struct {
+	service      *gogithub.OrganizationsService
+	organization string
+	githubName   string
+}
+
+func NewAuditLogProvider(cc githubapp.ClientCreator, githubName, organization string, installationID int64) (AuditLogProvider, error) {
+	if organization == "" {
+		return nil, errors.New("organization name should not be empty")
+	}
+	client, err := cc.NewInstallationClient(installationID)
+	if err != nil {
+		return nil, fmt.Errorf("create installation client: %w", err)
+	}
+	return &DefaultAuditLogProvider{service: client.Organizations, organization: organization, githubName: githubName}, nil
+}
+
+func (p *DefaultAuditLogProvider) Entries(ctx context.Context, actions []string, since time.Time) ([]AuditLogEntry, time.Time, error) {
+	var entries []AuditLogEntry
+	var truncated time.Time
+	for _, action := range actions {
+		phrase := fmt.Sprintf("action:%s created:>=%s", action, since.UTC().Format(time.RFC3339))
+		opts := &gogithub.GetAuditLogOptions{
+			Phrase:            &phrase,
+			Order:             gogithub.Ptr("asc"),
+			ListCursorOptions: gogithub.ListCursorOptions{PerPage: 100},
+		}
+		var last time.Time
+		for page := 0; ; page++ {
+			if page == auditLogMaxPages {
+				// the entries after the last one read are left for a later call
+				if last.IsZero() {
+					last = since
+				}
+				if truncated.IsZero() || last.Before(truncated) {
+					truncated = last
+				}
+				break
+			}
+			auditEntries, response, err := p.service.GetAuditLog(ctx, p.organization, opts)
+			if err != nil {
+				return nil, time.Time{}, fmt.Errorf("get audit log of %s for %s: %w", p.organization, action, err)
+			}
+			for _, e := range auditEntries {
+				if entry, ok := auditLogEntryOf(e); ok && !entry.CreatedAt.Before(since) {
+					entries = append(entries, entry)
+					last = entry.CreatedAt
+				}
+			}
+			if response.After == "" {
+				break
+			}
+			opts.After = response.After
+		}
+	}
+	if !truncated.IsZero() {
+		entries = slices.DeleteFunc(entries, func(e AuditLogEntry) bool { return e.CreatedAt.After(truncated) })
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries, truncated, nil
+}
+
+func auditLogEntryOf(e *gogithub.AuditEntry) (AuditLogEntry, bool) {
+	created := e.GetCreatedAt().Time
+	if created.IsZero() {
+		created = e.GetTimestamp().Time
+	}
+	if e.GetAction() == "" || created.IsZero() {
+		return AuditLogEntry{}, false
+	}
+	entry := AuditLogEntry{Action: e.GetAction(), Actor: e.GetActor(), User: e.GetUser(), CreatedAt: created.UTC()}
+	if team, ok := e.AdditionalFields["team"].(string); ok {
+		entry.Team = team
+	}
+	if repo, ok := e.AdditionalFields["repo"].(string); ok {
+		entry.Repository = repo
+	}
+	entry.ID = e.GetDocumentID()
+	if entry.ID == "" {
+		entry.ID = strings.Join([]string{entry.Action, entry.Actor, entry.User, entry.Team, entry.Repository,
+			strconv.FormatInt(entry.CreatedAt.UnixMilli(), 10)}, "|")
+	}
+	return entry, true
+}
+
+// ActorLogin returns the login repo-guard's own changes are attributed to in the audit
+// log: the bot user of the GitHub App ("<app slug>[bot]"), or the owner of the token.
+func ActorLogin(ctx context.Context, cc githubapp.ClientCreator) (string, error) {
+	client, err := cc.NewAppClient()
+	if err != nil {
+		return "", fmt.Errorf("create app client: %w", err)
+	}
+	if UsesTokenAuth(cc) {
+		user, _, err := client.Users.Get(ctx, "")
+		if err != nil {
+			return "", fmt.Errorf("getting the authenticated user: %w", err)
+		}
+		return user.GetLogin(), nil
+	}
+	app, _, err := client.Apps.Get(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("getting the github app: %w", err)
+	}
+	return app.GetSlug() + "[bot]", nil
+}
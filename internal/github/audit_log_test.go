@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v90/github"
+)
+
+func newTestAuditLogProvider(t *testing.T, handler http.HandlerFunc) *DefaultAuditLogProvider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(srv.Client()),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return &DefaultAuditLogProvider{service: client.Organizations, organization: "test-org", githubName: "test"}
+}
+
+func TestAuditLogEntries(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var phrases []string
+	provider := newTestAuditLogProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/orgs/test-org/audit-log" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		phrase := r.URL.Query().Get("phrase")
+		phrases = append(phrases, phrase)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(phrase, "action:team.add_member") && r.URL.Query().Get("after") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/orgs/test-org/audit-log?after=cursor1>; rel="next"`, "http://"+r.Host))
+			// 1714568400000 is 2024-05-01T13:00:00Z
+			fmt.Fprint(w, `[{"_document_id":"doc-1","action":"team.add_member","actor":"alice","user":"bob","team":"test-org/admins","created_at":1714568400000}]`)
+		case strings.HasPrefix(phrase, "action:team.add_member"):
+			// an entry before since is left out
+			fmt.Fprint(w, `[{"_document_id":"doc-2","action":"team.add_member","actor":"alice","user":"carol","team":"test-org/admins","created_at":1714564799000}]`)
+		case strings.HasPrefix(phrase, "action:repo.add_member"):
+			// 1714564800000 is since, 1714566600000 is 2024-05-01T12:30:00Z
+			fmt.Fprint(w, `[{"_document_id":"doc-3","action":"repo.add_member","actor":"dave","user":"erin","repo":"test-org/app","created_at":1714564800000},`+
+				`{"action":"repo.add_member","actor":"dave","user":"frank","repo":"test-org/app","created_at":1714566600000}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+
+	entries, truncated, err := provider.Entries(t.Context(), []string{"team.add_member", "repo.add_member"}, since)
+	if err != nil {
+		t.Fatalf("Entries: unexpected error: %v", err)
+	}
+	if !truncated.IsZero() {
+		t.Errorf("expected all entries to be read, got truncated at %v", truncated)
+	}
+	if len(phrases) != 3 {
+		t.Errorf("expected 3 requests, got %d: %v", len(phrases), phrases)
+	}
+	if want := "action:team.add_member created:>=2024-05-01T12:00:00Z"; phrases[0] != want {
+		t.Errorf("expected phrase %q, got %q", want, phrases[0])
+	}
+	want := []AuditLogEntry{
+		{ID: "doc-3", Action: "repo.add_member", Actor: "dave", User: "erin", Repository: "test-org/app", CreatedAt: since},
+		{ID: "repo.add_member|dave|frank||test-org/app|1714566600000", Action: "repo.add_member", Actor: "dave", User: "frank", Repository: "test-org/app", CreatedAt: since.Add(30 * time.Minute)},
+		{ID: "doc-1", Action: "team.add_member", Actor: "alice", User: "bob", Team: "test-org/admins", CreatedAt: since.Add(time.Hour)},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], entries[i])
+		}
+	}
+}
+
+func TestAuditLogEntries_PageLimit(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pages := 0
+	provider := newTestAuditLogProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.HasPrefix(r.URL.Query().Get("phrase"), "action:team.add_member") {
+			// a single entry after the last one read of team.add_member
+			fmt.Fprintf(w, `[{"_document_id":"repo","action":"repo.add_member","actor":"dave","created_at":%d}]`, since.Add(time.Hour).UnixMilli())
+			return
+		}
+		// every page has a next page with an entry a minute later
+		pages++
+		w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/orgs/test-org/audit-log?after=cursor%d>; rel="next"`, "http://"+r.Host, pages))
+		fmt.Fprintf(w, `[{"_document_id":"team-%d","action":"team.add_member","actor":"alice","created_at":%d}]`, pages, since.Add(time.Duration(pages)*time.Minute).UnixMilli())
+	})
+
+	entries, truncated, err := provider.Entries(t.Context(), []string{"team.add_member", "repo.add_member"}, since)
+	if err != nil {
+		t.Fatalf("Entries: unexpected error: %v", err)
+	}
+	if pages != auditLogMaxPages {
+		t.Errorf("expected %d pages, got %d", auditLogMaxPages, pages)
+	}
+	if want := since.Add(auditLogMaxPages * time.Minute); !truncated.Equal(want) {
+		t.Errorf("expected truncated at %v, got %v", want, truncated)
+	}
+	if len(entries) != auditLogMaxPages || entries[len(entries)-1].ID != fmt.Sprintf("team-%d", auditLogMaxPages) {
+		t.Errorf("expected the entries up to the last one read, got %+v", entries)
+	}
+}
+
+func TestAuditLogEntries_Error(t *testing.T) {
+	provider := newTestAuditLogProvider(t, func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+	if _, _, err := provider.Entries(t.Context(), DefaultAuditLogActions, time.Now()); err == nil {
+		t.Fatal("expected an error for an organization without audit log")
+	}
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"strings"
+)
+
+// EMULogin derives the login GitHub provisions for an Enterprise Managed User
+// from its IdP handle: everything from the first "@" is dropped, characters
+// other than letters, digits and "-" are replaced with "-", and "_<shortcode>"
+// is appended.
+func EMULogin(handle, shortcode string) string {
+	if i := strings.Index(handle, "@"); i >= 0 {
+		handle = handle[:i]
+	}
+	normalized := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, handle)
+	return normalized + "_" + shortcode
+}
+
+// IsEMULogin reports whether login belongs to a managed user of the enterprise
+// with the given shortcode. Logins are compared case-insensitively.
+func IsEMULogin(login, shortcode string) bool {
+	suffix := "_" + strings.ToLower(shortcode)
+	login = strings.ToLower(login)
+	return len(login) > len(suffix) && strings.HasSuffix(login, suffix)
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import "testing"
+
+func TestEMULogin(t *testing.T) {
+	cases := []struct {
+		handle string
+		want   string
+	}{
+		{"jdoe", "jdoe_acme"},
+		{"john.doe@example.com", "john-doe_acme"},
+		{"D012345", "D012345_acme"},
+		{"first_last", "first-last_acme"},
+	}
+	for _, tc := range cases {
+		if got := EMULogin(tc.handle, "acme"); got != tc.want {
+			t.Errorf("EMULogin(%q) = %q, want %q", tc.handle, got, tc.want)
+		}
+	}
+}
+
+func TestIsEMULogin(t *testing.T) {
+	cases := []struct {
+		login string
+		want  bool
+	}{
+		{"jdoe_acme", true},
+		{"JDoe_ACME", true},
+		{"jdoe", false},
+		{"jdoe_other", false},
+		{"_acme", false},
+	}
+	for _, tc := range cases {
+		if got := IsEMULogin(tc.login, "acme"); got != tc.want {
+			t.Errorf("IsEMULogin(%q) = %v, want %v", tc.login, got, tc.want)
+		}
+	}
+}
@@ -3,7 +3,12 @@
 
 package github
 
-import "sync"
+import (
+	"container/list"
+	"sync"
+
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
 
 // etagEntry stores an ETag header value and the last-parsed result for a cached resource.
 type etagEntry struct {
@@ -12,9 +17,37 @@ type etagEntry struct {
 }
 
 // etagCache is a per-organisation cache of ETag entries keyed by an endpoint string.
+// With maxEntries set, the least recently used entries are evicted beyond it.
 type etagCache struct {
-	mu      sync.RWMutex
+	mu      sync.Mutex
 	entries map[string]etagEntry
+
+	githubName   string
+	organization string
+	maxEntries   int
+	// lru orders the keys of entries from the most to the least recently used.
+	lru      *list.List
+	elements map[string]*list.Element
+	// dirty is set on changes not yet persisted.
+	dirty bool
+}
+
+// EtagCacheOptions configures the ETag caches of all organisations.
+type EtagCacheOptions struct {
+	// MaxEntriesPerOrganization bounds the entries of each organisation cache.
+	// 0 means unbounded.
+	MaxEntriesPerOrganization int
+	// Dir is the directory the caches are persisted to with FlushEtagCaches and
+	// loaded from with LoadEtagCaches. Empty disables persistence.
+	Dir string
+}
+
+var etagCacheOptions EtagCacheOptions
+
+// ConfigureEtagCache sets the options of the ETag caches. It must be called before
+// the first provider is created.
+func ConfigureEtagCache(opts EtagCacheOptions) {
+	etagCacheOptions = opts
 }
 
 // orgCaches is the package-level map from org name to its *etagCache.
@@ -25,30 +58,44 @@ var orgCaches sync.Map // key: string, value: *etagCache
 // The key is "<github>|<org>" to avoid collisions when multiple GitHub instances manage
 // organisations with the same name.
 func getOrCreateOrgCache(githubName, org string) *etagCache {
-	key := githubName + "|" + org
-	v, _ := orgCaches.LoadOrStore(key, &etagCache{entries: make(map[string]etagEntry)})
+	v, _ := orgCaches.LoadOrStore(orgCacheKey(githubName, org), newEtagCache(githubName, org))
 	return v.(*etagCache) //nolint:forcetypeassert
 }
 
+func orgCacheKey(githubName, org string) string {
+	return githubName + "|" + org
+}
+
+func newEtagCache(githubName, org string) *etagCache {
+	return &etagCache{
+		entries:      make(map[string]etagEntry),
+		githubName:   githubName,
+		organization: org,
+		maxEntries:   etagCacheOptions.MaxEntriesPerOrganization,
+	}
+}
+
 // getEtag returns the stored ETag for the given cache key, if any.
 func (c *etagCache) getEtag(key string) (string, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	e, ok := c.entries[key]
 	if !ok {
 		return "", false
 	}
+	c.touch(key)
 	return e.etag, true
 }
 
 // getValue returns the stored parsed value for the given cache key, if any.
 func (c *etagCache) getValue(key string) (any, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	e, ok := c.entries[key]
 	if !ok {
 		return nil, false
 	}
+	c.touch(key)
 	return e.value, true
 }
 
@@ -57,6 +104,9 @@ func (c *etagCache) set(key string, etag string, value any) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.entries[key] = etagEntry{etag: etag, value: value}
+	c.dirty = true
+	c.touch(key)
+	c.evict()
 }
 
 // setEtagOnly updates the ETag for the given cache key while preserving any
@@ -69,6 +119,9 @@ func (c *etagCache) setEtagOnly(key string, etag string) {
 	e := c.entries[key]
 	e.etag = etag
 	c.entries[key] = e
+	c.dirty = true
+	c.touch(key)
+	c.evict()
 }
 
 // invalidate removes the entry for the given cache key.
@@ -76,4 +129,41 @@ func (c *etagCache) invalidate(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.entries, key)
+	if el, ok := c.elements[key]; ok {
+		c.lru.Remove(el)
+		delete(c.elements, key)
+	}
+	c.dirty = true
+	c.recordSize()
+}
+
+// touch marks key as the most recently used. c.mu must be held.
+func (c *etagCache) touch(key string) {
+	if c.lru == nil {
+		c.lru = list.New()
+		c.elements = make(map[string]*list.Element)
+	}
+	if el, ok := c.elements[key]; ok {
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.elements[key] = c.lru.PushFront(key)
+}
+
+// evict removes the least recently used entries beyond maxEntries. c.mu must be held.
+func (c *etagCache) evict() {
+	for c.maxEntries > 0 && len(c.entries) > c.maxEntries {
+		el := c.lru.Back()
+		key := el.Value.(string) //nolint:forcetypeassert
+		c.lru.Remove(el)
+		delete(c.elements, key)
+		delete(c.entries, key)
+		ghmetrics.EtagCacheEvictionsTotal.WithLabelValues(c.githubName, c.organization).Inc()
+	}
+	c.recordSize()
+}
+
+// recordSize exports the number of entries. c.mu must be held.
+func (c *etagCache) recordSize() {
+	ghmetrics.EtagCacheEntries.WithLabelValues(c.githubName, c.organization).Set(float64(len(c.entries)))
 }
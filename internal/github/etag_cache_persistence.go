@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gogithub "github.com/google/go-github/v90/github"
+
+	repoguardsapv1 "github.com/cloudoperators/repo-guard/api/v1"
+)
+
+// etagCacheSchemaVersion versions the persisted caches. Bump it whenever the key
+// format or the type of a cached value changes; files of other versions are discarded.
+const etagCacheSchemaVersion = 3
+
+const etagCacheFileSuffix = ".etags"
+
+// etagCacheFile is the persisted form of one organisation cache.
+type etagCacheFile struct {
+	Version      int
+	Github       string
+	Organization string
+	// Entries are ordered from the most to the least recently used.
+	Entries []etagCacheFileEntry
+}
+
+type etagCacheFileEntry struct {
+	Key   string
+	ETag  string
+	Value any
+}
+
+func init() {
+	// types of the values cached by the providers
+	gob.Register([]string{})
+	gob.Register([]GithubMember{})
+	gob.Register([]*gogithub.User{})
+	gob.Register([]repoguardsapv1.GithubTeamWithPermission{})
+	gob.Register(teamDetails{})
+	gob.Register([]IdPGroup{})
+}
+
+func etagCacheFileName(dir, githubName, org string) string {
+	return filepath.Join(dir, url.PathEscape(orgCacheKey(githubName, org))+etagCacheFileSuffix)
+}
+
+// LoadEtagCaches loads the caches persisted in the configured directory, so that
+// the first reconciles after a restart send conditional requests. Files of another
+// schema version or that cannot be decoded are removed.
+func LoadEtagCaches() error {
+	dir := etagCacheOptions.Dir
+	if dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"+etagCacheFileSuffix))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, name := range files {
+		if err := loadEtagCacheFile(name); err != nil {
+			errs = append(errs, fmt.Errorf("discarding etag cache %s: %w", filepath.Base(name), err))
+			_ = os.Remove(name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func loadEtagCacheFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var file etagCacheFile
+	if err := gob.NewDecoder(f).Decode(&file); err != nil {
+		return err
+	}
+	if file.Version != etagCacheSchemaVersion {
+		return fmt.Errorf("schema version %d, want %d", file.Version, etagCacheSchemaVersion)
+	}
+
+	c := newEtagCache(file.Github, file.Organization)
+	c.mu.Lock()
+	for i := len(file.Entries) - 1; i >= 0; i-- {
+		e := file.Entries[i]
+		c.entries[e.Key] = etagEntry{etag: e.ETag, value: e.Value}
+		c.touch(e.Key)
+	}
+	c.evict()
+	c.mu.Unlock()
+	orgCaches.Store(orgCacheKey(file.Github, file.Organization), c)
+	return nil
+}
+
+// FlushEtagCaches writes the caches changed since the last flush to the configured
+// directory. Entries without a parsed value are not persisted since a 304 response
+// cannot be answered from them.
+func FlushEtagCaches() error {
+	dir := etagCacheOptions.Dir
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	var errs []error
+	orgCaches.Range(func(_, v any) bool {
+		c := v.(*etagCache) //nolint:forcetypeassert
+		if err := c.flush(dir); err != nil {
+			errs = append(errs, fmt.Errorf("persisting etag cache of %s/%s: %w", c.githubName, c.organization, err))
+		}
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+func (c *etagCache) flush(dir string) error {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	file := etagCacheFile{Version: etagCacheSchemaVersion, Github: c.githubName, Organization: c.organization}
+	if c.lru != nil {
+		for el := c.lru.Front(); el != nil; el = el.Next() {
+			key := el.Value.(string) //nolint:forcetypeassert
+			if e := c.entries[key]; e.value != nil {
+				file.Entries = append(file.Entries, etagCacheFileEntry{Key: key, ETag: e.etag, Value: e.value})
+			}
+		}
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	if err := writeEtagCacheFile(etagCacheFileName(dir, c.githubName, c.organization), file); err != nil {
+		c.mu.Lock()
+		c.dirty = true
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeEtagCacheFile replaces name atomically, so that a crash never leaves a
+// partially written cache behind.
+func writeEtagCacheFile(name string, file etagCacheFile) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), strings.TrimSuffix(filepath.Base(name), etagCacheFileSuffix)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := gob.NewEncoder(tmp).Encode(file); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
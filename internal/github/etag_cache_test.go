@@ -4,8 +4,13 @@
 package github
 
 import (
+	"os"
+	"reflect"
 	"sync"
 	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v90/github"
 )
 
 func TestEtagCache_SetAndGet(t *testing.T) {
@@ -91,3 +96,81 @@ func TestGetOrCreateOrgCache_DifferentGithubInstances(t *testing.T) {
 		t.Error("expected different *etagCache for same org on different github instances")
 	}
 }
+
+func TestEtagCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := &etagCache{entries: make(map[string]etagEntry), githubName: "gh1", organization: "lru", maxEntries: 2}
+	c.set("a", `"a"`, "a")
+	c.set("b", `"b"`, "b")
+	c.getValue("a") //nolint:errcheck
+	c.set("c", `"c"`, "c")
+
+	if _, ok := c.getEtag("b"); ok {
+		t.Error("expected least recently used entry b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.getEtag(key); !ok {
+			t.Errorf("expected entry %s to be kept", key)
+		}
+	}
+
+	c.invalidate("a")
+	c.setEtagOnly("d", `"d"`)
+	if len(c.entries) != 2 {
+		t.Errorf("got %d entries, want 2", len(c.entries))
+	}
+}
+
+func TestEtagCache_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	old := etagCacheOptions
+	ConfigureEtagCache(EtagCacheOptions{Dir: dir, MaxEntriesPerOrganization: 10})
+	t.Cleanup(func() { ConfigureEtagCache(old) })
+
+	c := getOrCreateOrgCache("gh-persist", "org")
+	members := []GithubMember{{Login: "alice", UID: 1}}
+	c.set("/orgs/org/members", `"m"`, members)
+	c.set("/repos/org/r", `"r"`, true)
+	c.set("/orgs/org/members?role=admin", `"u"`, []*gogithub.User{{Login: gogithub.Ptr("bob")}})
+	groups := []IdPGroup{{ID: "42", Name: "sre", UpdatedAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}}
+	c.set("/orgs/org/external-groups", `"g"`, groups)
+	c.setEtagOnly("/orgs/org/teams", `"t"`)
+	if err := FlushEtagCaches(); err != nil {
+		t.Fatalf("FlushEtagCaches: %v", err)
+	}
+
+	orgCaches.Delete(orgCacheKey("gh-persist", "org"))
+	if err := LoadEtagCaches(); err != nil {
+		t.Fatalf("LoadEtagCaches: %v", err)
+	}
+	loaded := getOrCreateOrgCache("gh-persist", "org")
+	if loaded == c {
+		t.Fatal("expected the cache to be loaded from disk")
+	}
+	if v, _ := loaded.getValue("/orgs/org/members"); !reflect.DeepEqual(v, members) {
+		t.Errorf("members: got %v, want %v", v, members)
+	}
+	if v, _ := loaded.getValue("/repos/org/r"); v != true {
+		t.Errorf("visibility: got %v, want true", v)
+	}
+	if v, _ := loaded.getValue("/orgs/org/members?role=admin"); len(v.([]*gogithub.User)) != 1 { //nolint:forcetypeassert
+		t.Errorf("users: got %v", v)
+	}
+	if v, _ := loaded.getValue("/orgs/org/external-groups"); !reflect.DeepEqual(v, groups) {
+		t.Errorf("idp groups: got %v, want %v", v, groups)
+	}
+	if _, ok := loaded.getEtag("/orgs/org/teams"); ok {
+		t.Error("entries without value must not be persisted")
+	}
+
+	// files of another schema version are discarded
+	name := etagCacheFileName(dir, "gh-persist", "stale")
+	if err := writeEtagCacheFile(name, etagCacheFile{Version: etagCacheSchemaVersion + 1, Github: "gh-persist", Organization: "stale"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadEtagCaches(); err == nil {
+		t.Error("expected error for a file of another schema version")
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Error("expected the stale file to be removed")
+	}
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/palantir/go-githubapp/githubapp"
+	githubv4 "github.com/shurcooL/githubv4"
+
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// ErrSAMLNotEnabled is returned when the organization has no SAML identity provider configured.
+var ErrSAMLNotEnabled = errors.New("SAML single sign-on is not enabled for the organization")
+
+// ExternalIdentity maps a GitHub user to the NameID asserted by the organization's SAML IdP.
+type ExternalIdentity struct {
+	NameID string
+	Login  string
+	UID    int64
+}
+
+type IdentityProvider interface {
+	// SAMLExternalIdentities returns all external identities of the organization that are
+	// linked to a GitHub user. Identities without a linked user or NameID are skipped.
+	SAMLExternalIdentities(ctx context.Context) ([]ExternalIdentity, error)
+}
+
+type DefaultIdentityProvider struct {
+	graphqlClient *githubv4.Client
+	organization  string
+	githubName    string
+}
+
+// samlExternalIdentitiesQuery is the GraphQL query struct for paging through the
+// external identities of an organization's SAML identity provider.
+// SamlIdentityProvider is a pointer because it is null when SAML SSO is not
+// enabled on the organization.
+type samlExternalIdentitiesQuery struct {
+	Organization struct {
+		SamlIdentityProvider *struct {
+			ExternalIdentities struct {
+				PageInfo struct {
+					HasNextPage githubv4.Boolean
+					EndCursor   githubv4.String
+				}
+				Nodes []struct {
+					SamlIdentity *struct {
+						NameId githubv4.String
+					}
+					User *struct {
+						Login      githubv4.String
+						DatabaseId githubv4.Int
+					}
+				}
+			} `graphql:"externalIdentities(first: 100, after: $cursor)"`
+		}
+	} `graphql:"organization(login: $org)"`
+}
+
+func NewIdentityProvider(cc githubapp.ClientCreator, githubName, organization string, installationID int64) (IdentityProvider, error) {
+	if organization == "" {
+		return nil, errors.New("organization name should not be empty")
+	}
+	gqlClient, err := cc.NewInstallationV4Client(installationID)
+	if err != nil {
+		return nil, fmt.Errorf("create installation graphql client: %w", err)
+	}
+	return &DefaultIdentityProvider{
+		graphqlClient: gqlClient,
+		organization:  organization,
+		githubName:    githubName,
+	}, nil
+}
+
+func (p *DefaultIdentityProvider) SAMLExternalIdentities(ctx context.Context) ([]ExternalIdentity, error) {
+	var identities []ExternalIdentity
+	var cursor *githubv4.String
+	for {
+		var query samlExternalIdentitiesQuery
+		vars := map[string]any{
+			"org":    githubv4.String(p.organization),
+			"cursor": cursor,
+		}
+		if err := p.graphqlClient.Query(ctx, &query, vars); err != nil {
+			ghmetrics.GraphQLCallsTotal.WithLabelValues(p.githubName, p.organization, "error").Inc()
+			return nil, fmt.Errorf("list SAML external identities for %s: %w", p.organization, err)
+		}
+		ghmetrics.GraphQLCallsTotal.WithLabelValues(p.githubName, p.organization, "success").Inc()
+
+		idp := query.Organization.SamlIdentityProvider
+		if idp == nil {
+			return nil, ErrSAMLNotEnabled
+		}
+		for _, node := range idp.ExternalIdentities.Nodes {
+			if node.User == nil || node.SamlIdentity == nil || node.SamlIdentity.NameId == "" {
+				continue
+			}
+			identities = append(identities, ExternalIdentity{
+				NameID: string(node.SamlIdentity.NameId),
+				Login:  string(node.User.Login),
+				UID:    int64(node.User.DatabaseId),
+			})
+		}
+
+		if !idp.ExternalIdentities.PageInfo.HasNextPage {
+			break
+		}
+		next := idp.ExternalIdentities.PageInfo.EndCursor
+		cursor = &next
+	}
+	return identities, nil
+}
+
+// UIDString returns the GitHub user ID in the string form used by GithubAccountLink.
+func (e ExternalIdentity) UIDString() string {
+	return strconv.FormatInt(e.UID, 10)
+}
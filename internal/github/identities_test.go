@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	githubv4 "github.com/shurcooL/githubv4"
+)
+
+func newTestIdentityProvider(t *testing.T, handler http.HandlerFunc) *DefaultIdentityProvider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &DefaultIdentityProvider{
+		graphqlClient: githubv4.NewEnterpriseClient(srv.URL+"/", srv.Client()),
+		organization:  "test-org",
+	}
+}
+
+func buildExternalIdentitiesResponse(nodes []map[string]any, hasNextPage bool, endCursor string) string {
+	data := map[string]any{
+		"organization": map[string]any{
+			"samlIdentityProvider": map[string]any{
+				"externalIdentities": map[string]any{
+					"pageInfo": map[string]any{"hasNextPage": hasNextPage, "endCursor": endCursor},
+					"nodes":    nodes,
+				},
+			},
+		},
+	}
+	b, _ := json.Marshal(graphqlResponse{Data: data})
+	return string(b)
+}
+
+func buildExternalIdentityNode(nameID, login string, uid int64) map[string]any {
+	node := map[string]any{"samlIdentity": map[string]any{"nameId": nameID}}
+	if login != "" {
+		node["user"] = map[string]any{"login": login, "databaseId": uid}
+	}
+	return node
+}
+
+func TestSAMLExternalIdentities_Pagination(t *testing.T) {
+	page1 := buildExternalIdentitiesResponse([]map[string]any{
+		buildExternalIdentityNode("alice@example.com", "alice", 1),
+		buildExternalIdentityNode("unlinked@example.com", "", 0),
+	}, true, "cursor1")
+	page2 := buildExternalIdentitiesResponse([]map[string]any{
+		buildExternalIdentityNode("bob@example.com", "bob", 2),
+	}, false, "")
+
+	var calls int32
+	provider := newTestIdentityProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Write([]byte(page1)) //nolint:errcheck
+		} else {
+			w.Write([]byte(page2)) //nolint:errcheck
+		}
+	})
+
+	identities, err := provider.SAMLExternalIdentities(t.Context())
+	if err != nil {
+		t.Fatalf("SAMLExternalIdentities: unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 GraphQL calls, got %d", calls)
+	}
+	if len(identities) != 2 {
+		t.Fatalf("expected 2 identities (unlinked skipped), got %+v", identities)
+	}
+	if identities[0].NameID != "alice@example.com" || identities[0].Login != "alice" || identities[0].UIDString() != "1" {
+		t.Errorf("unexpected first identity: %+v", identities[0])
+	}
+	if identities[1].Login != "bob" || identities[1].UID != 2 {
+		t.Errorf("unexpected second identity: %+v", identities[1])
+	}
+}
+
+func TestSAMLExternalIdentities_NotEnabled(t *testing.T) {
+	provider := newTestIdentityProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"organization":{"samlIdentityProvider":null}}}`)) //nolint:errcheck
+	})
+
+	_, err := provider.SAMLExternalIdentities(t.Context())
+	if !errors.Is(err, ErrSAMLNotEnabled) {
+		t.Fatalf("expected ErrSAMLNotEnabled, got %v", err)
+	}
+}
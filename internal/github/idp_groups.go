@@ -0,0 +1,250 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	gogithub "github.com/google/go-github/v90/github"
+	"github.com/gosimple/slug"
+
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// IDP_GROUP_TYPE_EXTERNAL_GROUP groups are the external groups of an Enterprise Managed
+// Users enterprise, provisioned by SCIM. A team is linked with at most one of them.
+const IDP_GROUP_TYPE_EXTERNAL_GROUP = "externalGroup"
+
+// IDP_GROUP_TYPE_TEAM_SYNC groups are the identity provider groups of Team Synchronization.
+const IDP_GROUP_TYPE_TEAM_SYNC = "teamSync"
+
+// IdPGroup is a group of the identity provider that owns the members of a team.
+type IdPGroup struct {
+	// ID is the numeric group_id of an external group, or the group_id of the
+	// identity provider for team synchronization.
+	ID   string
+	Name string
+	// UpdatedAt is the last sync of an external group; it is not reported for
+	// team synchronization.
+	UpdatedAt time.Time
+}
+
+// FindIdPGroup returns the group of groupType named name. found is false if the
+// organization has no such group.
+func (t DefaultTeamsProvider) FindIdPGroup(ctx context.Context, groupType, name string) (group IdPGroup, found bool, err error) {
+	var groups []IdPGroup
+	switch groupType {
+	case IDP_GROUP_TYPE_EXTERNAL_GROUP:
+		groups, err = t.externalGroups(ctx, name)
+	case IDP_GROUP_TYPE_TEAM_SYNC:
+		groups, err = t.teamSyncGroups(ctx, name)
+	default:
+		return IdPGroup{}, false, fmt.Errorf("unknown idp group type %q", groupType)
+	}
+	if err != nil {
+		return IdPGroup{}, false, err
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			return g, true, nil
+		}
+	}
+	return IdPGroup{}, false, nil
+}
+
+// externalGroups returns the external groups of the organization whose name contains name.
+func (t DefaultTeamsProvider) externalGroups(ctx context.Context, name string) ([]IdPGroup, error) {
+	firstPageKey := fmt.Sprintf("/orgs/%s/external-groups?display_name=%s&per_page=100", t.organization, url.QueryEscape(name))
+
+	opt := &gogithub.ListExternalGroupsOptions{
+		DisplayName: &name,
+		ListOptions: gogithub.ListOptions{PerPage: 100},
+	}
+	groups := make([]IdPGroup, 0)
+	for {
+		list, resp, err := t.service.ListExternalGroups(ctx, t.organization, opt)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotModified {
+				ghmetrics.EtagCacheHitsTotal.WithLabelValues(t.githubName, t.organization, "external-groups").Inc()
+				if cached, ok := t.cache.getValue(firstPageKey); ok {
+					if v, ok := cached.([]IdPGroup); ok {
+						return v, nil
+					}
+				}
+				t.cache.invalidate(firstPageKey)
+				return nil, fmt.Errorf("etag cache inconsistency for %s: 304 received but no valid cached value", firstPageKey)
+			}
+			return nil, err
+		}
+		groups = append(groups, externalGroupsOf(list)...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if etag, ok := t.cache.getEtag(firstPageKey); ok && etag != "" {
+		ghmetrics.EtagCacheMissesTotal.WithLabelValues(t.githubName, t.organization, "external-groups").Inc()
+		t.cache.set(firstPageKey, etag, groups)
+	}
+	return groups, nil
+}
+
+// teamSyncGroups returns the first page of the team synchronization groups of the
+// organization whose name starts with name.
+func (t DefaultTeamsProvider) teamSyncGroups(ctx context.Context, name string) ([]IdPGroup, error) {
+	key := fmt.Sprintf("/orgs/%s/team-sync/groups?per_page=100&q=%s", t.organization, url.QueryEscape(name))
+
+	opt := &gogithub.ListIDPGroupsOptions{
+		Query:             name,
+		ListCursorOptions: gogithub.ListCursorOptions{PerPage: 100},
+	}
+	list, resp, err := t.service.ListIDPGroupsInOrganization(ctx, t.organization, opt)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotModified {
+			ghmetrics.EtagCacheHitsTotal.WithLabelValues(t.githubName, t.organization, "team-sync-groups").Inc()
+			if cached, ok := t.cache.getValue(key); ok {
+				if v, ok := cached.([]IdPGroup); ok {
+					return v, nil
+				}
+			}
+			t.cache.invalidate(key)
+			return nil, fmt.Errorf("etag cache inconsistency for %s: 304 received but no valid cached value", key)
+		}
+		return nil, err
+	}
+	groups := teamSyncGroupsOf(list)
+
+	if etag, ok := t.cache.getEtag(key); ok && etag != "" {
+		ghmetrics.EtagCacheMissesTotal.WithLabelValues(t.githubName, t.organization, "team-sync-groups").Inc()
+		t.cache.set(key, etag, groups)
+	}
+	return groups, nil
+}
+
+// LinkedIdPGroups returns the groups of groupType linked with team.
+func (t DefaultTeamsProvider) LinkedIdPGroups(ctx context.Context, groupType, team string) ([]IdPGroup, error) {
+	var key, scope string
+	switch groupType {
+	case IDP_GROUP_TYPE_EXTERNAL_GROUP:
+		key = fmt.Sprintf("/orgs/%s/teams/%s/external-groups", t.organization, slug.Make(team))
+		scope = "team-external-groups"
+	case IDP_GROUP_TYPE_TEAM_SYNC:
+		key = fmt.Sprintf("/orgs/%s/teams/%s/team-sync/group-mappings", t.organization, slug.Make(team))
+		scope = "team-sync-group-mappings"
+	default:
+		return nil, fmt.Errorf("unknown idp group type %q", groupType)
+	}
+
+	var groups []IdPGroup
+	var resp *gogithub.Response
+	var err error
+	if groupType == IDP_GROUP_TYPE_EXTERNAL_GROUP {
+		var list *gogithub.ExternalGroupList
+		list, resp, err = t.service.ListExternalGroupsForTeamBySlug(ctx, t.organization, slug.Make(team))
+		groups = externalGroupsOf(list)
+	} else {
+		var list *gogithub.IDPGroupList
+		list, resp, err = t.service.ListIDPGroupsForTeamBySlug(ctx, t.organization, slug.Make(team))
+		groups = teamSyncGroupsOf(list)
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotModified {
+			ghmetrics.EtagCacheHitsTotal.WithLabelValues(t.githubName, t.organization, scope).Inc()
+			if cached, ok := t.cache.getValue(key); ok {
+				if v, ok := cached.([]IdPGroup); ok {
+					return v, nil
+				}
+			}
+			t.cache.invalidate(key)
+			return nil, fmt.Errorf("etag cache inconsistency for %s: 304 received but no valid cached value", key)
+		}
+		// teams without a linked group are reported as not found
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return []IdPGroup{}, nil
+		}
+		return nil, err
+	}
+
+	if etag, ok := t.cache.getEtag(key); ok && etag != "" {
+		ghmetrics.EtagCacheMissesTotal.WithLabelValues(t.githubName, t.organization, scope).Inc()
+		t.cache.set(key, etag, groups)
+	}
+	return groups, nil
+}
+
+// LinkIdPGroup links team with group, which replaces the groups linked before. The
+// members of team are then owned by the identity provider.
+func (t DefaultTeamsProvider) LinkIdPGroup(ctx context.Context, groupType, team string, group IdPGroup) error {
+	switch groupType {
+	case IDP_GROUP_TYPE_EXTERNAL_GROUP:
+		id, err := strconv.ParseInt(group.ID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid external group id %q: %w", group.ID, err)
+		}
+		_, _, err = t.service.UpdateConnectedExternalGroup(ctx, t.organization, slug.Make(team), gogithub.UpdateConnectedExternalGroupRequest{GroupID: id})
+		return err
+	case IDP_GROUP_TYPE_TEAM_SYNC:
+		description := ""
+		body := gogithub.IDPGroupList{Groups: []*gogithub.IDPGroup{{GroupID: &group.ID, GroupName: &group.Name, GroupDescription: &description}}}
+		_, _, err := t.service.CreateOrUpdateIDPGroupConnectionsBySlug(ctx, t.organization, slug.Make(team), body)
+		return err
+	}
+	return fmt.Errorf("unknown idp group type %q", groupType)
+}
+
+// UnlinkIdPGroups removes the links of team with groups of groupType. The members of
+// team are kept.
+func (t DefaultTeamsProvider) UnlinkIdPGroups(ctx context.Context, groupType, team string) error {
+	switch groupType {
+	case IDP_GROUP_TYPE_EXTERNAL_GROUP:
+		response, err := t.service.RemoveConnectedExternalGroup(ctx, t.organization, slug.Make(team))
+		if err != nil && response != nil && response.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	case IDP_GROUP_TYPE_TEAM_SYNC:
+		body := gogithub.IDPGroupList{Groups: []*gogithub.IDPGroup{}}
+		_, _, err := t.service.CreateOrUpdateIDPGroupConnectionsBySlug(ctx, t.organization, slug.Make(team), body)
+		return err
+	}
+	return fmt.Errorf("unknown idp group type %q", groupType)
+}
+
+func externalGroupsOf(list *gogithub.ExternalGroupList) []IdPGroup {
+	groups := make([]IdPGroup, 0)
+	if list == nil {
+		return groups
+	}
+	for _, g := range list.Groups {
+		if g == nil {
+			continue
+		}
+		groups = append(groups, IdPGroup{
+			ID:        strconv.FormatInt(g.GetGroupID(), 10),
+			Name:      g.GetGroupName(),
+			UpdatedAt: g.GetUpdatedAt().Time,
+		})
+	}
+	return groups
+}
+
+func teamSyncGroupsOf(list *gogithub.IDPGroupList) []IdPGroup {
+	groups := make([]IdPGroup, 0)
+	if list == nil {
+		return groups
+	}
+	for _, g := range list.Groups {
+		if g == nil {
+			continue
+		}
+		groups = append(groups, IdPGroup{ID: g.GetGroupID(), Name: g.GetGroupName()})
+	}
+	return groups
+}
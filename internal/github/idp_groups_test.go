@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v90/github"
+)
+
+func newTestIdPGroupsProvider(t *testing.T, handler http.HandlerFunc) *DefaultTeamsProvider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(srv.Client()),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return &DefaultTeamsProvider{service: *client.Teams, organization: "test-org", cache: &etagCache{entries: make(map[string]etagEntry)}}
+}
+
+func TestTeamsProvider_FindIdPGroup(t *testing.T) {
+	provider := newTestIdPGroupsProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v3/orgs/test-org/external-groups":
+			if got := r.URL.Query().Get("display_name"); got != "Platform Admins" {
+				t.Errorf("expected display_name filter, got %q", got)
+			}
+			fmt.Fprint(w, `{"groups":[{"group_id":1,"group_name":"Platform Admins Old"},{"group_id":2,"group_name":"Platform Admins","updated_at":"2024-05-01T12:00:00Z"}]}`)
+		case "/api/v3/orgs/test-org/team-sync/groups":
+			if r.URL.Query().Get("q") == "" {
+				t.Error("expected a q filter")
+			}
+			fmt.Fprint(w, `{"groups":[{"group_id":"abc-123","group_name":"sre"}]}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	group, found, err := provider.FindIdPGroup(t.Context(), IDP_GROUP_TYPE_EXTERNAL_GROUP, "Platform Admins")
+	if err != nil || !found {
+		t.Fatalf("FindIdPGroup: found=%v err=%v", found, err)
+	}
+	if group.ID != "2" || !group.UpdatedAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected external group %+v", group)
+	}
+
+	group, found, err = provider.FindIdPGroup(t.Context(), IDP_GROUP_TYPE_TEAM_SYNC, "sre")
+	if err != nil || !found || group.ID != "abc-123" {
+		t.Errorf("FindIdPGroup: unexpected team sync group %+v found=%v err=%v", group, found, err)
+	}
+
+	if _, found, err := provider.FindIdPGroup(t.Context(), IDP_GROUP_TYPE_TEAM_SYNC, "sr"); err != nil || found {
+		t.Errorf("FindIdPGroup: expected no exact match, found=%v err=%v", found, err)
+	}
+	if _, _, err := provider.FindIdPGroup(t.Context(), "ldap", "sre"); err == nil {
+		t.Error("FindIdPGroup: expected an error for an unknown type")
+	}
+}
+
+func TestTeamsProvider_LinkIdPGroup(t *testing.T) {
+	var bodies []string
+	provider := newTestIdPGroupsProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body map[string]any
+		if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+		}
+		raw, _ := json.Marshal(body)
+		bodies = append(bodies, r.Method+" "+r.URL.Path+" "+string(raw))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/orgs/test-org/teams/admins/external-groups":
+			// teams without an external group are not found
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/orgs/test-org/teams/admins/team-sync/group-mappings":
+			fmt.Fprint(w, `{"groups":[{"group_id":"abc-123","group_name":"sre"}]}`)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	})
+
+	linked, err := provider.LinkedIdPGroups(t.Context(), IDP_GROUP_TYPE_EXTERNAL_GROUP, "admins")
+	if err != nil || len(linked) != 0 {
+		t.Errorf("LinkedIdPGroups: expected no external group, got %+v err=%v", linked, err)
+	}
+	linked, err = provider.LinkedIdPGroups(t.Context(), IDP_GROUP_TYPE_TEAM_SYNC, "admins")
+	if err != nil || len(linked) != 1 || linked[0].ID != "abc-123" {
+		t.Errorf("LinkedIdPGroups: unexpected team sync groups %+v err=%v", linked, err)
+	}
+
+	if err := provider.LinkIdPGroup(t.Context(), IDP_GROUP_TYPE_EXTERNAL_GROUP, "admins", IdPGroup{ID: "2", Name: "Platform Admins"}); err != nil {
+		t.Errorf("LinkIdPGroup: %v", err)
+	}
+	if err := provider.LinkIdPGroup(t.Context(), IDP_GROUP_TYPE_TEAM_SYNC, "admins", IdPGroup{ID: "abc-123", Name: "sre"}); err != nil {
+		t.Errorf("LinkIdPGroup: %v", err)
+	}
+	if err := provider.UnlinkIdPGroups(t.Context(), IDP_GROUP_TYPE_EXTERNAL_GROUP, "admins"); err != nil {
+		t.Errorf("UnlinkIdPGroups: %v", err)
+	}
+	if err := provider.UnlinkIdPGroups(t.Context(), IDP_GROUP_TYPE_TEAM_SYNC, "admins"); err != nil {
+		t.Errorf("UnlinkIdPGroups: %v", err)
+	}
+
+	want := []string{
+		`GET /api/v3/orgs/test-org/teams/admins/external-groups null`,
+		`GET /api/v3/orgs/test-org/teams/admins/team-sync/group-mappings null`,
+		`PATCH /api/v3/orgs/test-org/teams/admins/external-groups {"group_id":2}`,
+		`PATCH /api/v3/orgs/test-org/teams/admins/team-sync/group-mappings {"groups":[{"group_description":"","group_id":"abc-123","group_name":"sre"}]}`,
+		`DELETE /api/v3/orgs/test-org/teams/admins/external-groups null`,
+		`PATCH /api/v3/orgs/test-org/teams/admins/team-sync/group-mappings {"groups":[]}`,
+	}
+	if fmt.Sprint(bodies) != fmt.Sprint(want) {
+		t.Errorf("unexpected requests:\n%v\nwant:\n%v", bodies, want)
+	}
+}
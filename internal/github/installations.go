@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	gogithub "github.com/google/go-github/v90/github"
+	"github.com/palantir/go-githubapp/githubapp"
+)
+
+// OrganizationInstallationID looks up the installation of the GitHub App on organization
+// with the app client (GET /orgs/{org}/installation). found is false if the app is not
+// installed on the organization.
+func OrganizationInstallationID(ctx context.Context, cc githubapp.ClientCreator, organization string) (int64, bool, error) {
+	client, err := cc.NewAppClient()
+	if err != nil {
+		return 0, false, fmt.Errorf("create app client: %w", err)
+	}
+	return organizationInstallationID(ctx, client, organization)
+}
+
+func organizationInstallationID(ctx context.Context, client *gogithub.Client, organization string) (int64, bool, error) {
+	installation, response, err := client.Apps.GetOrganizationInstallation(ctx, organization)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("finding installation for organization %s: %w", organization, err)
+	}
+	return installation.GetID(), true, nil
+}
+
+// AppInstallation is an installation of the GitHub App on an organization.
+type AppInstallation struct {
+	ID           int64
+	Organization string
+	// Suspended is true if the installation was suspended; its tokens cannot be used.
+	Suspended bool
+}
+
+// AppInstallations lists the organization installations of the GitHub App with the
+// app client (GET /app/installations). Installations on user accounts are skipped.
+func AppInstallations(ctx context.Context, cc githubapp.ClientCreator) ([]AppInstallation, error) {
+	client, err := cc.NewAppClient()
+	if err != nil {
+		return nil, fmt.Errorf("create app client: %w", err)
+	}
+	return appInstallations(ctx, client)
+}
+
+func appInstallations(ctx context.Context, client *gogithub.Client) ([]AppInstallation, error) {
+	var installations []AppInstallation
+	opts := &gogithub.ListOptions{PerPage: 100}
+	for {
+		page, response, err := client.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing app installations: %w", err)
+		}
+		for _, installation := range page {
+			if installation.GetTargetType() != "Organization" || installation.GetAccount().GetLogin() == "" {
+				continue
+			}
+			installations = append(installations, AppInstallation{
+				ID:           installation.GetID(),
+				Organization: installation.GetAccount().GetLogin(),
+				Suspended:    installation.SuspendedAt != nil,
+			})
+		}
+		if response.NextPage == 0 {
+			break
+		}
+		opts.Page = response.NextPage
+	}
+	return installations, nil
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gogithub "github.com/google/go-github/v90/github"
+)
+
+func TestOrganizationInstallationID(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/api/v3/orgs/installed/installation", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 4242})
+	})
+	mux.HandleFunc("/api/v3/orgs/broken/installation", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(srv.Client()),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("create github client: %v", err)
+	}
+
+	id, found, err := organizationInstallationID(t.Context(), client, "installed")
+	if err != nil || !found || id != 4242 {
+		t.Errorf("installed: got (%d, %v, %v), want (4242, true, nil)", id, found, err)
+	}
+	id, found, err = organizationInstallationID(t.Context(), client, "missing")
+	if err != nil || found || id != 0 {
+		t.Errorf("missing: got (%d, %v, %v), want (0, false, nil)", id, found, err)
+	}
+	if _, _, err := organizationInstallationID(t.Context(), client, "broken"); err == nil {
+		t.Error("broken: expected error, got nil")
+	}
+}
+
+func TestAppInstallations(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/api/v3/app/installations", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"id": 3, "target_type": "Organization", "account": map[string]any{"login": "org-b"}, "suspended_at": "2026-01-02T03:04:05Z"},
+			})
+			return
+		}
+		w.Header().Set("Link", `<`+srv.URL+`/api/v3/app/installations?page=2>; rel="next"`)
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"id": 1, "target_type": "Organization", "account": map[string]any{"login": "org-a"}},
+			{"id": 2, "target_type": "User", "account": map[string]any{"login": "someone"}},
+		})
+	})
+
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(srv.Client()),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("create github client: %v", err)
+	}
+
+	got, err := appInstallations(t.Context(), client)
+	if err != nil {
+		t.Fatalf("appInstallations: %v", err)
+	}
+	want := []AppInstallation{
+		{ID: 1, Organization: "org-a"},
+		{ID: 3, Organization: "org-b", Suspended: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("installation %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
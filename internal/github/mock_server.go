@@ -37,6 +37,11 @@ type MockTeam struct {
 	Name string
 	Slug string
 	Type string // "organization" (default) or "enterprise"
+	// Parent is the slug of the parent team of a nested team.
+	Parent              string
+	Description         string
+	Privacy             string // "closed" (default) or "secret"
+	NotificationSetting string // "notifications_enabled" (default) or "notifications_disabled"
 }
 
 // teamType returns the effective team type, defaulting to "organization".
@@ -121,6 +126,9 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 
 	// teamMembers tracks the members of each team by slug.
 	teamMembers := make(map[string][]MockUser)
+	// teamMaintainers tracks the lower-cased logins of the members of each team
+	// with the maintainer role, by slug.
+	teamMaintainers := make(map[string]map[string]bool)
 
 	// teamRepoPerms tracks team-repo permission assignments: repoName -> []MockTeamWithPermission.
 	// Seeded from cfg.Repos[*].Teams; updated by PUT/DELETE on the team repos endpoint.
@@ -158,6 +166,17 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 		return false
 	}
 
+	// teamSlugByID returns the slug of the team with the given ID, or "" (must be
+	// called with stateMu held).
+	teamSlugByID := func(id int64) string {
+		for _, t := range teams {
+			if t.ID == id {
+				return t.Slug
+			}
+		}
+		return ""
+	}
+
 	// orgAdmins is the mutable set of org admins (login -> MockUser).
 	// Seeded from cfg.Owners; updated when PUT /memberships/{user} sets role=admin.
 	orgAdmins := make(map[string]MockUser)
@@ -225,6 +244,19 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 		})
 	})
 
+	// GET /api/v3/orgs/{org}/installation – installation discovery
+	mux.HandleFunc(fmt.Sprintf("/api/v3/orgs/%s/installation", org), func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"id":      1,
+			"app_id":  1,
+			"account": map[string]any{"login": org, "type": "Organization"},
+		})
+	})
+
 	// POST /api/v3/app/installations/{id}/access_tokens – NewInstallationClient()
 	mux.HandleFunc("/api/v3/app/installations/", func(w http.ResponseWriter, r *http.Request) {
 		if !strings.HasSuffix(r.URL.Path, "/access_tokens") {
@@ -400,12 +432,16 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 			stateMu.Unlock()
 			result := make([]map[string]any, 0, len(snapshot))
 			for _, team := range snapshot {
-				result = append(result, teamToMap(team))
+				result = append(result, teamToMap(team, snapshot))
 			}
 			writeJSON(w, result)
 		case http.MethodPost:
 			var body struct {
-				Name string `json:"name"`
+				Name                string `json:"name"`
+				ParentTeamID        *int64 `json:"parent_team_id"`
+				Description         string `json:"description"`
+				Privacy             string `json:"privacy"`
+				NotificationSetting string `json:"notification_setting"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 				writeJSONError(w, `{"message":"Problems parsing JSON"}`, http.StatusBadRequest)
@@ -423,9 +459,19 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 					return
 				}
 			}
+			var parent string
+			if body.ParentTeamID != nil {
+				parent = teamSlugByID(*body.ParentTeamID)
+				if parent == "" {
+					stateMu.Unlock()
+					writeJSONError(w, `{"message":"Validation Failed","errors":[{"resource":"Team","field":"parent_team_id","code":"invalid"}]}`, http.StatusUnprocessableEntity)
+					return
+				}
+			}
 			id := nextTeamID
 			nextTeamID++
-			teams = append(teams, MockTeam{ID: id, Name: body.Name, Slug: teamSlugNew})
+			teams = append(teams, MockTeam{ID: id, Name: body.Name, Slug: teamSlugNew, Parent: parent,
+				Description: body.Description, Privacy: body.Privacy, NotificationSetting: body.NotificationSetting})
 			stateMu.Unlock()
 			writeJSONCreated(w, map[string]any{"id": id, "name": body.Name, "slug": teamSlugNew})
 		default:
@@ -466,18 +512,32 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 				writeJSONError(w, `{"message":"An enterprise team may not be managed through the organization API"}`, http.StatusUnprocessableEntity)
 				return
 			}
+			// Like GitHub, deleting a team deletes its child teams as well.
+			deleted := map[string]bool{teamSlug: true}
+			for changed := true; changed; {
+				changed = false
+				for _, t := range teams {
+					if !deleted[t.Slug] && deleted[t.Parent] {
+						deleted[t.Slug] = true
+						changed = true
+					}
+				}
+			}
 			var newTeams []MockTeam
 			for _, t := range teams {
-				if t.Slug != teamSlug {
+				if !deleted[t.Slug] {
 					newTeams = append(newTeams, t)
 				}
 			}
 			teams = newTeams
-			delete(teamMembers, teamSlug)
+			for deletedSlug := range deleted {
+				delete(teamMembers, deletedSlug)
+				delete(teamMaintainers, deletedSlug)
+			}
 			for repoName, perms := range teamRepoPerms {
 				var newPerms []MockTeamWithPermission
 				for _, tp := range perms {
-					if tp.Slug != teamSlug {
+					if !deleted[tp.Slug] {
 						newPerms = append(newPerms, tp)
 					}
 				}
@@ -486,6 +546,104 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 			stateMu.Unlock()
 			w.WriteHeader(http.StatusNoContent)
 
+		// PATCH /api/v3/orgs/{org}/teams/{slug}
+		// The slug is kept on renames so that tests can keep addressing the team.
+		case subPath == "" && r.Method == http.MethodPatch:
+			var body map[string]json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeJSONError(w, `{"message":"Problems parsing JSON"}`, http.StatusBadRequest)
+				return
+			}
+			stringField := func(key string) (string, bool) {
+				var v string
+				raw, ok := body[key]
+				if !ok || json.Unmarshal(raw, &v) != nil {
+					return "", false
+				}
+				return v, true
+			}
+			stateMu.Lock()
+			parent, setParent := "", false
+			if raw, ok := body["parent_team_id"]; ok {
+				setParent = true
+				var parentID *int64
+				_ = json.Unmarshal(raw, &parentID)
+				if parentID != nil {
+					parent = teamSlugByID(*parentID)
+					if parent == "" || parent == teamSlug {
+						stateMu.Unlock()
+						writeJSONError(w, `{"message":"Validation Failed","errors":[{"resource":"Team","field":"parent_team_id","code":"invalid"}]}`, http.StatusUnprocessableEntity)
+						return
+					}
+				}
+			}
+			for i := range teams {
+				if teams[i].Slug == teamSlug {
+					if setParent {
+						teams[i].Parent = parent
+					}
+					if v, ok := stringField("name"); ok {
+						// renaming a team changes its slug
+						if newSlug := slug.Make(v); newSlug != teamSlug {
+							if teamExists(newSlug) {
+								stateMu.Unlock()
+								writeJSONError(w, `{"message":"Validation Failed","errors":[{"resource":"Team","field":"name","code":"already_exists"}]}`, http.StatusUnprocessableEntity)
+								return
+							}
+							teams[i].Slug = newSlug
+							for j := range teams {
+								if teams[j].Parent == teamSlug {
+									teams[j].Parent = newSlug
+								}
+							}
+							teamMembers[newSlug], teamMaintainers[newSlug] = teamMembers[teamSlug], teamMaintainers[teamSlug]
+							delete(teamMembers, teamSlug)
+							delete(teamMaintainers, teamSlug)
+							for repo, perms := range teamRepoPerms {
+								for j := range perms {
+									if perms[j].Slug == teamSlug {
+										teamRepoPerms[repo][j].Slug = newSlug
+									}
+								}
+							}
+						}
+						teams[i].Name = v
+					}
+					if v, ok := stringField("description"); ok {
+						teams[i].Description = v
+					}
+					if v, ok := stringField("privacy"); ok {
+						teams[i].Privacy = v
+					}
+					if v, ok := stringField("notification_setting"); ok {
+						teams[i].NotificationSetting = v
+					}
+					result := teamToMap(teams[i], teams)
+					stateMu.Unlock()
+					writeJSON(w, result)
+					return
+				}
+			}
+			stateMu.Unlock()
+			writeJSONError(w, `{"message":"Not Found"}`, http.StatusNotFound)
+
+		// GET /api/v3/orgs/{org}/teams/{slug}/teams
+		case subPath == "teams" && r.Method == http.MethodGet:
+			stateMu.Lock()
+			exists := teamExists(teamSlug)
+			result := make([]map[string]any, 0)
+			for _, t := range teams {
+				if t.Parent == teamSlug {
+					result = append(result, teamToMap(t, teams))
+				}
+			}
+			stateMu.Unlock()
+			if !exists {
+				writeJSONError(w, `{"message":"Not Found"}`, http.StatusNotFound)
+				return
+			}
+			writeJSON(w, result)
+
 		// GET /api/v3/orgs/{org}/teams/{slug}
 		case subPath == "" && r.Method == http.MethodGet:
 			stateMu.Lock()
@@ -494,7 +652,7 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 			stateMu.Unlock()
 			for _, team := range snapshot {
 				if team.Slug == teamSlug {
-					writeJSON(w, teamToMap(team))
+					writeJSON(w, teamToMap(team, snapshot))
 					return
 				}
 			}
@@ -504,8 +662,14 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 		case subPath == "members" && r.Method == http.MethodGet:
 			stateMu.Lock()
 			exists := teamExists(teamSlug)
-			members := make([]MockUser, len(teamMembers[teamSlug]))
-			copy(members, teamMembers[teamSlug])
+			role := r.URL.Query().Get("role")
+			var members []MockUser
+			for _, u := range teamMembers[teamSlug] {
+				maintainer := teamMaintainers[teamSlug][strings.ToLower(u.Login)]
+				if role == "" || role == "all" || (role == "maintainer") == maintainer {
+					members = append(members, u)
+				}
+			}
 			stateMu.Unlock()
 			if !exists {
 				writeJSONError(w, `{"message":"Not Found"}`, http.StatusNotFound)
@@ -531,13 +695,24 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 						break
 					}
 				}
+				role := "member"
+				if teamMaintainers[teamSlug][strings.ToLower(username)] {
+					role = "maintainer"
+				}
 				stateMu.Unlock()
 				if found {
-					writeJSON(w, map[string]any{"state": "active", "role": "member"})
+					writeJSON(w, map[string]any{"state": "active", "role": role})
 				} else {
 					writeJSONError(w, `{"message":"Not Found"}`, http.StatusNotFound)
 				}
 			case http.MethodPut:
+				var body struct {
+					Role string `json:"role"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				if body.Role == "" {
+					body.Role = "member"
+				}
 				u, _ := lookupUser(username)
 				stateMu.Lock()
 				if !teamExists(teamSlug) {
@@ -556,8 +731,12 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 				if !found {
 					teamMembers[teamSlug] = append(teamMembers[teamSlug], u)
 				}
+				if teamMaintainers[teamSlug] == nil {
+					teamMaintainers[teamSlug] = make(map[string]bool)
+				}
+				teamMaintainers[teamSlug][strings.ToLower(username)] = body.Role == "maintainer"
 				stateMu.Unlock()
-				writeJSON(w, map[string]any{"state": "active", "role": "member"})
+				writeJSON(w, map[string]any{"state": "active", "role": body.Role})
 			case http.MethodDelete:
 				stateMu.Lock()
 				var newList []MockUser
@@ -567,6 +746,7 @@ func registerMockHandlers(mux *http.ServeMux, cfg MockConfig) {
 					}
 				}
 				teamMembers[teamSlug] = newList
+				delete(teamMaintainers[teamSlug], strings.ToLower(username))
 				stateMu.Unlock()
 				w.WriteHeader(http.StatusNoContent)
 			default:
@@ -1059,13 +1239,31 @@ func userToMap(u MockUser) map[string]any {
 }
 
 // teamToMap converts a MockTeam into the JSON map shape go-github expects.
-func teamToMap(team MockTeam) map[string]any {
-	return map[string]any{
-		"id":   team.ID,
-		"name": team.Name,
-		"slug": team.Slug,
-		"type": team.teamType(),
+// teamToMap renders team; teams are used to resolve its parent.
+func teamToMap(team MockTeam, teams []MockTeam) map[string]any {
+	privacy := team.Privacy
+	if privacy == "" {
+		privacy = "closed"
+	}
+	notificationSetting := team.NotificationSetting
+	if notificationSetting == "" {
+		notificationSetting = "notifications_enabled"
+	}
+	result := map[string]any{
+		"id":                   team.ID,
+		"name":                 team.Name,
+		"slug":                 team.Slug,
+		"type":                 team.teamType(),
+		"description":          team.Description,
+		"privacy":              privacy,
+		"notification_setting": notificationSetting,
+	}
+	for _, parent := range teams {
+		if team.Parent != "" && parent.Slug == team.Parent {
+			result["parent"] = map[string]any{"id": parent.ID, "name": parent.Name, "slug": parent.Slug}
+		}
 	}
+	return result
 }
 
 func (r MockRepo) repoVisibility() string {
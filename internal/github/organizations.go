@@ -33,7 +33,8 @@ type DefaultOrganizationProvider struct {
 	cache               *etagCache
 }
 
-// installationID can be found at Organizations - Settings - Installed Github Apps and check the URL
+// installationID can be found at Organizations - Settings - Installed Github Apps and check the URL,
+// or is discovered with OrganizationInstallationID
 func NewOrganizationProvider(cc githubapp.ClientCreator, githubName, organization string, installationID int64) (OrganizationProvider, error) {
 
 	client, err := cc.NewInstallationClient(installationID)
@@ -0,0 +1,318 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gogithub "github.com/google/go-github/v90/github"
+	"github.com/palantir/go-githubapp/githubapp"
+	githubv4 "github.com/shurcooL/githubv4"
+
+	ghmetrics "github.com/cloudoperators/repo-guard/internal/metrics"
+)
+
+// Rate-limit resources as reported in the X-RateLimit-Resource header.
+const (
+	RateLimitResourceCore    = "core"
+	RateLimitResourceGraphQL = "graphql"
+)
+
+// lowPriorityReserve is the share of a rate limit kept for high-priority work such
+// as team membership changes: low-priority work is deferred below it.
+const lowPriorityReserve = 0.2
+
+// defaultSecondaryRateLimitPause is the pause after a secondary rate limit without
+// Retry-After header; GitHub asks to wait at least a minute then.
+const defaultSecondaryRateLimitPause = time.Minute
+
+// rateLimitState is the rate limit of one resource as last reported by GitHub.
+type rateLimitState struct {
+	remaining int
+	limit     int
+	reset     time.Time
+}
+
+// rateLimitBudget tracks the rate limits of one installation per resource. GraphQL
+// responses report the remaining points after the cost of the query in the same
+// headers as REST responses.
+type rateLimitBudget struct {
+	githubName     string
+	installationID int64
+
+	mu        sync.Mutex
+	resources map[string]rateLimitState
+	// mutationsPausedUntil is set when GitHub reported a secondary rate limit.
+	mutationsPausedUntil time.Time
+}
+
+// SecondaryRateLimitError is returned for requests that hit a secondary rate limit
+// and for mutations of an installation while they are paused because of one. The
+// message contains the retry time in the format of GitHub's primary rate limit
+// errors, so that callers keep the affected operations pending until then.
+type SecondaryRateLimitError struct {
+	RetryAt time.Time
+}
+
+func (e *SecondaryRateLimitError) Error() string {
+	return fmt.Sprintf("secondary rate limit exceeded: mutations paused until %s", e.RetryAt.UTC().Format("2006-01-02 15:04:05 -0700 MST"))
+}
+
+type rateLimitBudgetKey struct {
+	githubName     string
+	installationID int64
+}
+
+var (
+	rateLimitBudgetsMu sync.Mutex
+	rateLimitBudgets   = make(map[rateLimitBudgetKey]*rateLimitBudget)
+)
+
+// rateLimitBudgetFor returns the shared budget of an installation, which outlives
+// the client creators of a Github.
+func rateLimitBudgetFor(githubName string, installationID int64) *rateLimitBudget {
+	rateLimitBudgetsMu.Lock()
+	defer rateLimitBudgetsMu.Unlock()
+	key := rateLimitBudgetKey{githubName: githubName, installationID: installationID}
+	budget, ok := rateLimitBudgets[key]
+	if !ok {
+		budget = &rateLimitBudget{githubName: githubName, installationID: installationID, resources: make(map[string]rateLimitState)}
+		rateLimitBudgets[key] = budget
+	}
+	return budget
+}
+
+// observe records the X-RateLimit-* headers of a response. Responses without them are ignored.
+func (b *rateLimitBudget) observe(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = RateLimitResourceCore
+	}
+
+	b.mu.Lock()
+	b.resources[resource] = rateLimitState{remaining: remaining, limit: limit, reset: time.Unix(reset, 0)}
+	b.mu.Unlock()
+	ghmetrics.RateLimitRemaining.WithLabelValues(b.githubName, strconv.FormatInt(b.installationID, 10), resource).Set(float64(remaining))
+}
+
+// deferLowPriority returns the reset time of resource if its remaining budget is
+// below the low-priority reserve. Without an observation, or after the reset, low
+// priority work may run.
+func (b *rateLimitBudget) deferLowPriority(resource string, now time.Time) (time.Time, bool) {
+	b.mu.Lock()
+	state, ok := b.resources[resource]
+	b.mu.Unlock()
+	if !ok || !now.Before(state.reset) {
+		return time.Time{}, false
+	}
+	if float64(state.remaining) >= float64(state.limit)*lowPriorityReserve {
+		return time.Time{}, false
+	}
+	return state.reset, true
+}
+
+// pauseMutations pauses the mutations of the installation until retryAt, unless
+// they are already paused for longer, and returns the end of the pause.
+func (b *rateLimitBudget) pauseMutations(retryAt time.Time) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if retryAt.After(b.mutationsPausedUntil) {
+		b.mutationsPausedUntil = retryAt
+	}
+	return b.mutationsPausedUntil
+}
+
+// mutationsPaused returns the end of the mutation pause if it is still running at now.
+func (b *rateLimitBudget) mutationsPaused(now time.Time) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !now.Before(b.mutationsPausedUntil) {
+		return time.Time{}, false
+	}
+	return b.mutationsPausedUntil, true
+}
+
+// rateLimitTransport records the rate limit of every response in budget. Once
+// GitHub reports a secondary rate limit, mutations of the installation fail with a
+// SecondaryRateLimitError without being sent until the advised time.
+type rateLimitTransport struct {
+	wrapped http.RoundTripper
+	budget  *rateLimitBudget
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isMutation(req) {
+		if retryAt, paused := t.budget.mutationsPaused(time.Now()); paused {
+			if req.Body != nil {
+				_ = req.Body.Close()
+			}
+			return nil, &SecondaryRateLimitError{RetryAt: retryAt}
+		}
+	}
+	resp, err := t.wrapped.RoundTrip(req)
+	if resp == nil {
+		return resp, err
+	}
+	t.budget.observe(resp.Header)
+	if retryAt, ok := secondaryRateLimit(resp, time.Now()); ok {
+		_ = resp.Body.Close()
+		return nil, &SecondaryRateLimitError{RetryAt: t.budget.pauseMutations(retryAt)}
+	}
+	return resp, err
+}
+
+// isMutation reports whether req changes state at GitHub. GraphQL requests are
+// always POSTed, so their query is inspected.
+func isMutation(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return false
+	}
+	if !strings.HasSuffix(req.URL.Path, "/graphql") {
+		return true
+	}
+	if req.GetBody == nil {
+		return true
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return true
+	}
+	defer func() { _ = body.Close() }()
+	query, err := io.ReadAll(io.LimitReader(body, 4096))
+	if err != nil {
+		return true
+	}
+	return bytes.Contains(query, []byte(`"query":"mutation`))
+}
+
+// secondaryRateLimit reports whether resp is a secondary rate limit response, i.e.
+// a 403 or 429 with a Retry-After header or a message about secondary rate limits
+// or abuse detection, and returns the time GitHub advised to retry at. Primary rate
+// limits come without Retry-After header and are left to the callers.
+func secondaryRateLimit(resp *http.Response, now time.Time) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return now.Add(time.Duration(seconds) * time.Second), true
+		}
+		return now.Add(defaultSecondaryRateLimitPause), true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return time.Time{}, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil {
+		return time.Time{}, false
+	}
+	message := strings.ToLower(string(body))
+	if strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse detection") {
+		return now.Add(defaultSecondaryRateLimitPause), true
+	}
+	return time.Time{}, false
+}
+
+// budgetClientCreator records the rate limits of its installation clients in the
+// shared budget of the installation and pauses their mutations on secondary rate
+// limits.
+type budgetClientCreator struct {
+	githubapp.ClientCreator
+	githubName string
+	v4URL      string
+}
+
+// NewRateLimitBudgetClientCreator returns a ClientCreator whose installation clients
+// record the rate limits reported by GitHub, so that low-priority work can be
+// deferred with DeferLowPriority. v4URL is the GraphQL endpoint of the Github.
+func NewRateLimitBudgetClientCreator(base githubapp.ClientCreator, githubName, v4URL string) githubapp.ClientCreator {
+	if v4URL == "" {
+		v4URL = "https://api.github.com/graphql"
+	}
+	return &budgetClientCreator{ClientCreator: base, githubName: githubName, v4URL: v4URL}
+}
+
+// budget returns the budget of installationID. All clients of a token share one budget.
+func (c *budgetClientCreator) budget(installationID int64) *rateLimitBudget {
+	if UsesTokenAuth(c.ClientCreator) {
+		installationID = 0
+	}
+	return rateLimitBudgetFor(c.githubName, installationID)
+}
+
+func (c *budgetClientCreator) httpClient(installationID int64) (*gogithub.Client, *http.Client, error) {
+	client, err := c.ClientCreator.NewInstallationClient(installationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseHTTP := client.Client()
+	baseTransport := baseHTTP.Transport
+	if baseTransport == nil {
+		baseTransport = http.DefaultTransport
+	}
+	return client, &http.Client{
+		Transport:     &rateLimitTransport{wrapped: baseTransport, budget: c.budget(installationID)},
+		CheckRedirect: baseHTTP.CheckRedirect,
+		Jar:           baseHTTP.Jar,
+		Timeout:       baseHTTP.Timeout,
+	}, nil
+}
+
+func (c *budgetClientCreator) NewInstallationClient(installationID int64) (*gogithub.Client, error) {
+	client, httpClient, err := c.httpClient(installationID)
+	if err != nil {
+		return nil, err
+	}
+	budgetClient, err := client.Clone(gogithub.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("clone github client with rate limit transport: %w", err)
+	}
+	return budgetClient, nil
+}
+
+// NewInstallationV4Client builds the GraphQL client on the HTTP client of the REST
+// installation client, which carries the same authentication.
+func (c *budgetClientCreator) NewInstallationV4Client(installationID int64) (*githubv4.Client, error) {
+	_, httpClient, err := c.httpClient(installationID)
+	if err != nil {
+		return nil, err
+	}
+	return githubv4.NewEnterpriseClient(c.v4URL, httpClient), nil
+}
+
+// DeferLowPriority reports until when low-priority work of an installation, such as
+// email checks and collaborator scans, should wait because the remaining budget of
+// resource is below the reserve kept for high-priority work. It never defers for
+// client creators that do not record budgets.
+func DeferLowPriority(cc githubapp.ClientCreator, installationID int64, resource string, now time.Time) (time.Time, bool) {
+	c, ok := cc.(*budgetClientCreator)
+	if !ok {
+		return time.Time{}, false
+	}
+	return c.budget(installationID).deferLowPriority(resource, now)
+}
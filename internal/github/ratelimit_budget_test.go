@@ -0,0 +1,245 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitBudget_DeferLowPriority(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	reset := now.Add(10 * time.Minute)
+
+	tests := []struct {
+		name      string
+		remaining int
+		limit     int
+		reset     time.Time
+		wantDefer bool
+	}{
+		{name: "plenty left", remaining: 4000, limit: 5000, reset: reset},
+		{name: "at the reserve", remaining: 1000, limit: 5000, reset: reset},
+		{name: "below the reserve", remaining: 999, limit: 5000, reset: reset, wantDefer: true},
+		{name: "exhausted", remaining: 0, limit: 5000, reset: reset, wantDefer: true},
+		{name: "reset passed", remaining: 0, limit: 5000, reset: now.Add(-time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &rateLimitBudget{githubName: "test", resources: make(map[string]rateLimitState)}
+			header := http.Header{}
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(tt.remaining))
+			header.Set("X-RateLimit-Limit", strconv.Itoa(tt.limit))
+			header.Set("X-RateLimit-Reset", strconv.FormatInt(tt.reset.Unix(), 10))
+			header.Set("X-RateLimit-Resource", RateLimitResourceGraphQL)
+			budget.observe(header)
+
+			resetAt, deferred := budget.deferLowPriority(RateLimitResourceGraphQL, now)
+			if deferred != tt.wantDefer {
+				t.Fatalf("deferred: got %v, want %v", deferred, tt.wantDefer)
+			}
+			if deferred && !resetAt.Equal(tt.reset) {
+				t.Errorf("reset: got %v, want %v", resetAt, tt.reset)
+			}
+			if _, deferred := budget.deferLowPriority(RateLimitResourceCore, now); deferred {
+				t.Error("core resource deferred without an observation")
+			}
+		})
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/no-headers" {
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "12")
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+	}))
+	t.Cleanup(srv.Close)
+
+	budget := rateLimitBudgetFor("transport-test", 7)
+	client := &http.Client{Transport: &rateLimitTransport{wrapped: http.DefaultTransport, budget: budget}}
+	for _, path := range []string{"/", "/no-headers"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	state, ok := budget.resources[RateLimitResourceCore]
+	if !ok {
+		t.Fatal("no rate limit recorded for the core resource")
+	}
+	if state.remaining != 12 || state.limit != 5000 || state.reset.Unix() != reset {
+		t.Errorf("unexpected state %+v", state)
+	}
+	if rateLimitBudgetFor("transport-test", 7) != budget {
+		t.Error("budget of an installation is not shared")
+	}
+}
+
+func TestDeferLowPriority(t *testing.T) {
+	now := time.Now()
+	low := http.Header{}
+	low.Set("X-RateLimit-Remaining", "1")
+	low.Set("X-RateLimit-Limit", "5000")
+	low.Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+
+	appCC := NewRateLimitBudgetClientCreator(&recordingClientCreator{}, "defer-app", "")
+	rateLimitBudgetFor("defer-app", 1).observe(low)
+	if _, deferred := DeferLowPriority(appCC, 1, RateLimitResourceCore, now); !deferred {
+		t.Error("installation 1: expected low-priority work to be deferred")
+	}
+	if _, deferred := DeferLowPriority(appCC, 2, RateLimitResourceCore, now); deferred {
+		t.Error("installation 2: budgets of other installations must not be shared")
+	}
+
+	// all installation IDs share the budget of a token
+	tokenCC := NewRateLimitBudgetClientCreator(NewTokenClientCreator(&recordingClientCreator{}, "token"), "defer-token", "")
+	rateLimitBudgetFor("defer-token", 0).observe(low)
+	if _, deferred := DeferLowPriority(tokenCC, 42, RateLimitResourceCore, now); !deferred {
+		t.Error("token: expected low-priority work to be deferred")
+	}
+	if !UsesTokenAuth(tokenCC) {
+		t.Error("UsesTokenAuth: got false for a wrapped token client creator")
+	}
+
+	if _, deferred := DeferLowPriority(&recordingClientCreator{}, 1, RateLimitResourceCore, now); deferred {
+		t.Error("client creators without budget must never defer")
+	}
+}
+
+func TestRateLimitTransport_SecondaryRateLimit(t *testing.T) {
+	var mutations int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte("{}"))
+		case r.URL.Path == "/graphql":
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), `"query":"mutation`) {
+				mutations++
+			}
+			_, _ = w.Write([]byte("{}"))
+		case r.URL.Path == "/abuse":
+			mutations++
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have triggered an abuse detection mechanism."}`))
+		default:
+			mutations++
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	budget := rateLimitBudgetFor("secondary-test", 3)
+	client := &http.Client{Transport: &rateLimitTransport{wrapped: http.DefaultTransport, budget: budget}}
+	do := func(method, path, body string) error {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		return nil
+	}
+	retryAt := func(err error) time.Time {
+		t.Helper()
+		var secondary *SecondaryRateLimitError
+		if !errors.As(err, &secondary) {
+			t.Fatalf("expected SecondaryRateLimitError, got %v", err)
+		}
+		return secondary.RetryAt
+	}
+
+	start := time.Now()
+	first := retryAt(do(http.MethodPut, "/teams/1/repos/org/repo", ""))
+	if first.Before(start.Add(29*time.Second)) || first.After(time.Now().Add(31*time.Second)) {
+		t.Errorf("retry at %v is not 30s after the response", first)
+	}
+
+	// further mutations are not sent until the pause ends
+	if got := retryAt(do(http.MethodDelete, "/teams/1/memberships/user", "")); !got.Equal(first) {
+		t.Errorf("paused mutation: got retry at %v, want %v", got, first)
+	}
+	if got := retryAt(do(http.MethodPost, "/graphql", `{"query":"mutation{addTeam}"}`)); !got.Equal(first) {
+		t.Errorf("paused GraphQL mutation: got retry at %v, want %v", got, first)
+	}
+	if mutations != 1 {
+		t.Errorf("got %d mutations sent, want 1", mutations)
+	}
+
+	// reads and GraphQL queries keep running
+	if err := do(http.MethodGet, "/orgs/org/teams", ""); err != nil {
+		t.Errorf("read during pause: %v", err)
+	}
+	if err := do(http.MethodPost, "/graphql", `{"query":"query{viewer{login}}"}`); err != nil {
+		t.Errorf("GraphQL query during pause: %v", err)
+	}
+
+	// the pause is per installation; a message without Retry-After pauses a minute
+	other := rateLimitBudgetFor("secondary-test", 4)
+	client.Transport = &rateLimitTransport{wrapped: http.DefaultTransport, budget: other}
+	if got := retryAt(do(http.MethodPut, "/abuse", "")); got.Before(start.Add(defaultSecondaryRateLimitPause)) {
+		t.Errorf("abuse detection: retry at %v is less than a minute away", got)
+	}
+
+	// mutations are sent again once the pause ended
+	budget.mutationsPausedUntil = time.Now().Add(-time.Second)
+	client.Transport = &rateLimitTransport{wrapped: http.DefaultTransport, budget: budget}
+	if err := do(http.MethodPost, "/graphql", `{"query":"mutation{addTeam}"}`); err != nil {
+		t.Errorf("mutation after the pause: %v", err)
+	}
+}
+
+func TestSecondaryRateLimit(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name   string
+		status int
+		header map[string]string
+		body   string
+		wantOK bool
+		wantAt time.Time
+	}{
+		{name: "retry after", status: http.StatusForbidden, header: map[string]string{"Retry-After": "120"}, wantOK: true, wantAt: now.Add(2 * time.Minute)},
+		{name: "too many requests", status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "5"}, wantOK: true, wantAt: now.Add(5 * time.Second)},
+		{name: "secondary message", status: http.StatusForbidden, body: `{"message":"You have exceeded a secondary rate limit"}`, wantOK: true, wantAt: now.Add(time.Minute)},
+		{name: "primary rate limit", status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0"}, body: `{"message":"API rate limit exceeded"}`},
+		{name: "permission denied", status: http.StatusForbidden, body: `{"message":"Resource not accessible by integration"}`},
+		{name: "ok", status: http.StatusOK, header: map[string]string{"Retry-After": "5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tt.body))}
+			for k, v := range tt.header {
+				resp.Header.Set(k, v)
+			}
+			at, ok := secondaryRateLimit(resp, now)
+			if ok != tt.wantOK || !at.Equal(tt.wantAt) {
+				t.Errorf("got (%v, %v), want (%v, %v)", at, ok, tt.wantAt, tt.wantOK)
+			}
+			// the body stays readable for go-github
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.body {
+				t.Errorf("body: got %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
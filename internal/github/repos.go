@@ -38,7 +38,8 @@ type DefaultRepositoryProvider struct {
 	cache             *etagCache
 }
 
-// installationID can be found at Organizations - Settings - Installed Github Apps and check the URL
+// installationID can be found at Organizations - Settings - Installed Github Apps and check the URL,
+// or is discovered with OrganizationInstallationID
 func NewRepositoryProvider(cc githubapp.ClientCreator, githubName, organization string, installationID int64) (RepositoryProvider, error) {
 
 	client, err := cc.NewInstallationClient(installationID)
@@ -291,6 +292,9 @@ func (t *DefaultRepositoryProvider) RepositoryTeamRemove(ctx context.Context, re
 
 	response, err := t.teamsService.RemoveTeamRepoBySlug(ctx, t.organization, team, t.organization, repo)
 	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil
+		}
 		return err
 	}
 	if response.StatusCode != 204 {
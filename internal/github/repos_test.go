@@ -149,3 +149,30 @@ func assertStringSlice(t *testing.T, label string, got, want []string) {
 		}
 	}
 }
+
+func TestRepositoryProvider_RepositoryTeamRemove(t *testing.T) {
+	provider, mux := newTestRepositoryProvider(t)
+	mux.HandleFunc("/api/v3/orgs/test-org/teams/admins/repos/test-org/app", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("expected DELETE, got %s", r.Method)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v3/orgs/test-org/teams/admins/repos/test-org/gone", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v3/orgs/test-org/teams/admins/repos/test-org/broken", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"message":"Server Error"}`, http.StatusInternalServerError)
+	})
+
+	if err := provider.RepositoryTeamRemove(t.Context(), "app", "admins"); err != nil {
+		t.Errorf("RepositoryTeamRemove: unexpected error: %v", err)
+	}
+	// access that is already gone is not an error
+	if err := provider.RepositoryTeamRemove(t.Context(), "gone", "admins"); err != nil {
+		t.Errorf("RepositoryTeamRemove: unexpected error for a missing repository: %v", err)
+	}
+	if err := provider.RepositoryTeamRemove(t.Context(), "broken", "admins"); err == nil {
+		t.Error("RepositoryTeamRemove: expected an error for a server error")
+	}
+}
@@ -20,10 +20,61 @@ type TeamsProvider interface {
 	List(ctx context.Context) ([]string, error)
 	Members(ctx context.Context, team string) ([]string, error)
 	MembersExtended(ctx context.Context, team string) ([]GithubMember, error)
-	AddTeam(ctx context.Context, team string) error
+	// AddTeam creates team below parentTeam with settings; an empty parentTeam creates a
+	// top-level team.
+	AddTeam(ctx context.Context, team, parentTeam string, settings TeamSettings) error
 	RemoveTeam(ctx context.Context, team string) error
-	AddUser(ctx context.Context, team, user string) (bool, error)
+	// ParentTeam returns the name of the parent of team, or "" for a top-level team.
+	ParentTeam(ctx context.Context, team string) (string, error)
+	// SetParentTeam moves team below parentTeam; an empty parentTeam makes it a top-level team.
+	SetParentTeam(ctx context.Context, team, parentTeam string) error
+	// TeamID returns the ID of team; found is false if the team does not exist.
+	TeamID(ctx context.Context, team string) (id int64, found bool, err error)
+	// RenameTeam changes the name of team to name and returns the new slug of the team.
+	RenameTeam(ctx context.Context, team, name string) (string, error)
+	// ChildTeams returns the names of the direct child teams of team.
+	ChildTeams(ctx context.Context, team string) ([]string, error)
+	// Settings returns the settings of team.
+	Settings(ctx context.Context, team string) (TeamSettings, error)
+	// EditSettings changes the settings of team; empty fields are left unchanged.
+	EditSettings(ctx context.Context, team string, settings TeamSettings) error
+	// AddUser adds user to team with role; an empty role adds a member.
+	AddUser(ctx context.Context, team, user, role string) (bool, error)
 	RemoveUser(ctx context.Context, team, user string) error
+	// Maintainers returns the logins of the members of team with the maintainer role.
+	Maintainers(ctx context.Context, team string) ([]string, error)
+	// SetRole changes the role of user, a member of team, to role.
+	SetRole(ctx context.Context, team, user, role string) error
+	// FindIdPGroup returns the identity provider group of groupType named name; found
+	// is false if the organization has no such group.
+	FindIdPGroup(ctx context.Context, groupType, name string) (group IdPGroup, found bool, err error)
+	// LinkedIdPGroups returns the identity provider groups of groupType linked with team.
+	LinkedIdPGroups(ctx context.Context, groupType, team string) ([]IdPGroup, error)
+	// LinkIdPGroup links team with group, which then owns the members of team.
+	LinkIdPGroup(ctx context.Context, groupType, team string, group IdPGroup) error
+	// UnlinkIdPGroups removes the links of team with identity provider groups of groupType.
+	UnlinkIdPGroups(ctx context.Context, groupType, team string) error
+}
+
+// TEAM_ROLE_MAINTAINER members can manage the members and settings of their team.
+const TEAM_ROLE_MAINTAINER = "maintainer"
+const TEAM_ROLE_MEMBER = "member"
+
+// TEAM_PRIVACY_CLOSED teams are visible to all members of the organization; only
+// closed teams can be nested.
+const TEAM_PRIVACY_CLOSED = "closed"
+const TEAM_PRIVACY_SECRET = "secret"
+
+// DEFAULT_TEAM_DESCRIPTION is the description of teams created without one.
+const DEFAULT_TEAM_DESCRIPTION = "membership to this team is managed by github-guard"
+
+// TeamSettings are the settings of a team besides its members and parent.
+type TeamSettings struct {
+	// Name is the display name. The slug of the team is derived from it.
+	Name                string
+	Description         string
+	Privacy             string
+	NotificationSetting string
 }
 
 type GithubMember struct {
@@ -36,10 +87,14 @@ type DefaultTeamsProvider struct {
 	organization string
 	githubName   string
 	cache        *etagCache
+	// emuShortcode is set for organizations of an Enterprise Managed Users enterprise.
+	emuShortcode string
 }
 
-// installationID can be found at Organizations - Settings - Installed Github Apps and check the URL
-func NewTeamsProvider(cc githubapp.ClientCreator, githubName, organization string, installationID int64) (TeamsProvider, error) {
+// installationID can be found at Organizations - Settings - Installed Github Apps and check the URL,
+// or is discovered with OrganizationInstallationID
+// emuShortcode is the enterprise shortcode for EMU organizations and empty otherwise.
+func NewTeamsProvider(cc githubapp.ClientCreator, githubName, organization string, installationID int64, emuShortcode string) (TeamsProvider, error) {
 
 	client, err := cc.NewInstallationClient(installationID)
 	if err != nil {
@@ -71,7 +126,7 @@ func NewTeamsProvider(cc githubapp.ClientCreator, githubName, organization strin
 		return nil, fmt.Errorf("clone github client with etag transport: %w", err)
 	}
 
-	return &DefaultTeamsProvider{service: *etagClient.Teams, organization: organization, githubName: githubName, cache: cache}, nil
+	return &DefaultTeamsProvider{service: *etagClient.Teams, organization: organization, githubName: githubName, cache: cache, emuShortcode: emuShortcode}, nil
 }
 
 func (t *DefaultTeamsProvider) List(ctx context.Context) ([]string, error) {
@@ -228,12 +283,27 @@ func (t DefaultTeamsProvider) Members(ctx context.Context, team string) ([]strin
 	return userList, nil
 }
 
-func (t DefaultTeamsProvider) AddTeam(ctx context.Context, team string) error {
+func (t DefaultTeamsProvider) AddTeam(ctx context.Context, team, parentTeam string, settings TeamSettings) error {
 
-	privacyLevel := "closed"
-	description := "membership to this team is managed by github-guard"
+	if settings.Name == "" {
+		settings.Name = team
+	}
+	if settings.Description == "" {
+		settings.Description = DEFAULT_TEAM_DESCRIPTION
+	}
+	if settings.Privacy == "" {
+		settings.Privacy = TEAM_PRIVACY_CLOSED
+	}
+	newTeam := newTeamOf(settings)
+	if parentTeam != "" {
+		parentID, err := t.teamID(ctx, parentTeam)
+		if err != nil {
+			return err
+		}
+		newTeam.ParentTeamID = &parentID
+	}
 
-	_, response, err := t.service.CreateTeam(ctx, t.organization, gogithub.NewTeam{Name: team, Privacy: &privacyLevel, Description: &description})
+	_, response, err := t.service.CreateTeam(ctx, t.organization, newTeam)
 	if err != nil {
 		// Treat 422 "Name must be unique for this org" as success (team already exists)
 		if response != nil && response.StatusCode == 422 {
@@ -265,9 +335,228 @@ func (t DefaultTeamsProvider) RemoveTeam(ctx context.Context, team string) error
 	return nil
 }
 
-func (t DefaultTeamsProvider) AddUser(ctx context.Context, team, user string) (bool, error) {
+// newTeamOf returns the request body setting the non-empty fields of settings.
+func newTeamOf(settings TeamSettings) gogithub.NewTeam {
+	newTeam := gogithub.NewTeam{Name: settings.Name}
+	if settings.Description != "" {
+		newTeam.Description = &settings.Description
+	}
+	if settings.Privacy != "" {
+		newTeam.Privacy = &settings.Privacy
+	}
+	if settings.NotificationSetting != "" {
+		newTeam.NotificationSetting = &settings.NotificationSetting
+	}
+	return newTeam
+}
+
+// teamDetails is the cached part of a team of the organization.
+type teamDetails struct {
+	ID       int64
+	Parent   string
+	Settings TeamSettings
+}
 
-	_, response, err := t.service.AddTeamMembershipBySlug(ctx, t.organization, slug.Make(team), user, nil)
+// details returns the ID and the parent of team. found is false if the team does not exist.
+func (t DefaultTeamsProvider) details(ctx context.Context, team string) (details teamDetails, found bool, err error) {
+	key := fmt.Sprintf("/orgs/%s/teams/%s", t.organization, slug.Make(team))
+
+	githubTeam, response, err := t.service.GetTeamBySlug(ctx, t.organization, slug.Make(team))
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotModified {
+			ghmetrics.EtagCacheHitsTotal.WithLabelValues(t.githubName, t.organization, "team").Inc()
+			if cached, ok := t.cache.getValue(key); ok {
+				if v, ok := cached.(teamDetails); ok {
+					return v, true, nil
+				}
+			}
+			t.cache.invalidate(key)
+			return teamDetails{}, false, fmt.Errorf("etag cache inconsistency for %s: 304 received but no valid cached value", key)
+		}
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return teamDetails{}, false, nil
+		}
+		return teamDetails{}, false, err
+	}
+	details = teamDetails{
+		ID:     githubTeam.GetID(),
+		Parent: githubTeam.GetParent().GetName(),
+		Settings: TeamSettings{
+			Name:                githubTeam.GetName(),
+			Description:         githubTeam.GetDescription(),
+			Privacy:             githubTeam.GetPrivacy(),
+			NotificationSetting: githubTeam.GetNotificationSetting(),
+		},
+	}
+
+	if etag, ok := t.cache.getEtag(key); ok && etag != "" {
+		ghmetrics.EtagCacheMissesTotal.WithLabelValues(t.githubName, t.organization, "team").Inc()
+		t.cache.set(key, etag, details)
+	}
+	return details, true, nil
+}
+
+// teamID returns the ID of team, which is needed to reference it as a parent.
+func (t DefaultTeamsProvider) teamID(ctx context.Context, team string) (int64, error) {
+	details, found, err := t.details(ctx, team)
+	if err != nil {
+		return 0, fmt.Errorf("getting team %q: %w", team, err)
+	}
+	if !found {
+		return 0, fmt.Errorf("parent team %q not found in github", team)
+	}
+	return details.ID, nil
+}
+
+func (t DefaultTeamsProvider) ParentTeam(ctx context.Context, team string) (string, error) {
+	details, found, err := t.details(ctx, team)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("team %q not found in github", team)
+	}
+	return details.Parent, nil
+}
+
+func (t DefaultTeamsProvider) SetParentTeam(ctx context.Context, team, parentTeam string) error {
+
+	// the name is required; the current name keeps the display name
+	current, err := t.Settings(ctx, team)
+	if err != nil {
+		return err
+	}
+	body := gogithub.NewTeam{Name: current.Name}
+	removeParent := parentTeam == ""
+	if !removeParent {
+		parentID, err := t.teamID(ctx, parentTeam)
+		if err != nil {
+			return err
+		}
+		body.ParentTeamID = &parentID
+	}
+
+	_, response, err := t.service.EditTeamBySlug(ctx, t.organization, slug.Make(team), body, removeParent)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != 200 && response.StatusCode != 201 {
+		return fmt.Errorf("changing parent team response code: %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (t DefaultTeamsProvider) TeamID(ctx context.Context, team string) (int64, bool, error) {
+	details, found, err := t.details(ctx, team)
+	return details.ID, found, err
+}
+
+func (t DefaultTeamsProvider) RenameTeam(ctx context.Context, team, name string) (string, error) {
+
+	githubTeam, response, err := t.service.EditTeamBySlug(ctx, t.organization, slug.Make(team), gogithub.NewTeam{Name: name}, false)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusUnprocessableEntity {
+			return "", fmt.Errorf("team %q cannot be renamed to %q: %w", team, name, err)
+		}
+		return "", err
+	}
+	if response.StatusCode != 200 && response.StatusCode != 201 {
+		return "", fmt.Errorf("renaming team response code: %d", response.StatusCode)
+	}
+	return githubTeam.GetSlug(), nil
+}
+
+func (t DefaultTeamsProvider) Settings(ctx context.Context, team string) (TeamSettings, error) {
+	details, found, err := t.details(ctx, team)
+	if err != nil {
+		return TeamSettings{}, err
+	}
+	if !found {
+		return TeamSettings{}, fmt.Errorf("team %q not found in github", team)
+	}
+	return details.Settings, nil
+}
+
+func (t DefaultTeamsProvider) EditSettings(ctx context.Context, team string, settings TeamSettings) error {
+
+	// the name is required; an unchanged name keeps the slug
+	if settings.Name == "" {
+		current, err := t.Settings(ctx, team)
+		if err != nil {
+			return err
+		}
+		settings.Name = current.Name
+	}
+
+	_, response, err := t.service.EditTeamBySlug(ctx, t.organization, slug.Make(team), newTeamOf(settings), false)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != 200 && response.StatusCode != 201 {
+		return fmt.Errorf("changing team settings response code: %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (t DefaultTeamsProvider) ChildTeams(ctx context.Context, team string) ([]string, error) {
+
+	firstPageKey := fmt.Sprintf("/orgs/%s/teams/%s/teams?per_page=100", t.organization, slug.Make(team))
+
+	opt := &gogithub.ListOptions{
+		PerPage: 100,
+	}
+
+	children := make([]string, 0)
+	for {
+		teams, response, err := t.service.ListChildTeamsByParentSlug(ctx, t.organization, slug.Make(team), opt)
+		if err != nil {
+			if response != nil && response.StatusCode == http.StatusNotModified {
+				ghmetrics.EtagCacheHitsTotal.WithLabelValues(t.githubName, t.organization, "team-children").Inc()
+				if cached, ok := t.cache.getValue(firstPageKey); ok {
+					if v, ok := cached.([]string); ok {
+						return v, nil
+					}
+				}
+				t.cache.invalidate(firstPageKey)
+				return nil, fmt.Errorf("etag cache inconsistency for %s: 304 received but no valid cached value", firstPageKey)
+			}
+			if response != nil && response.StatusCode == http.StatusNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for _, child := range teams {
+			if name := child.GetName(); name != "" {
+				children = append(children, name)
+			}
+		}
+		if response.NextPage == 0 {
+			break
+		}
+		opt.Page = response.NextPage
+	}
+
+	if etag, ok := t.cache.getEtag(firstPageKey); ok && etag != "" {
+		ghmetrics.EtagCacheMissesTotal.WithLabelValues(t.githubName, t.organization, "team-children").Inc()
+		t.cache.set(firstPageKey, etag, children)
+	}
+
+	return children, nil
+}
+
+func (t DefaultTeamsProvider) AddUser(ctx context.Context, team, user, role string) (bool, error) {
+
+	// Managed users cannot be invited; anything that is not a managed user login
+	// of the enterprise can never become a member.
+	if t.emuShortcode != "" && !IsEMULogin(user, t.emuShortcode) {
+		return false, fmt.Errorf("user is not a managed user of enterprise %q", t.emuShortcode)
+	}
+
+	var opts *gogithub.TeamAddTeamMembershipOptions
+	if role != "" {
+		opts = &gogithub.TeamAddTeamMembershipOptions{Role: role}
+	}
+	membership, response, err := t.service.AddTeamMembershipBySlug(ctx, t.organization, slug.Make(team), user, opts)
 	if err != nil {
 		if response != nil {
 			if response.StatusCode == 404 {
@@ -280,7 +569,17 @@ func (t DefaultTeamsProvider) AddUser(ctx context.Context, team, user string) (b
 				return true, fmt.Errorf("adding user to team response code: %d", response.StatusCode)
 			}
 		}
-		return false, err
+		// Without a response the request never reached Github, e.g. because the
+		// transport holds back mutations during a secondary rate limit. Nothing is
+		// known about the user, so the operation can be retried.
+		return true, fmt.Errorf("adding user to team: %w", err)
+	}
+
+	// Outside EMU a pending membership is an invitation the user still has to
+	// accept. Managed users are added directly, so a pending membership means the
+	// user has not been provisioned through SCIM yet.
+	if t.emuShortcode != "" && membership.GetState() == "pending" {
+		return false, errors.New("user is not provisioned through SCIM")
 	}
 
 	return true, nil
@@ -302,3 +601,63 @@ func (t DefaultTeamsProvider) RemoveUser(ctx context.Context, team, user string)
 
 	return nil
 }
+
+func (t DefaultTeamsProvider) Maintainers(ctx context.Context, team string) ([]string, error) {
+
+	teamSlug := slug.Make(team)
+	firstPageKey := fmt.Sprintf("/orgs/%s/teams/%s/members?per_page=100&role=%s", t.organization, teamSlug, TEAM_ROLE_MAINTAINER)
+
+	opt := &gogithub.TeamListTeamMembersOptions{
+		Role:        TEAM_ROLE_MAINTAINER,
+		ListOptions: gogithub.ListOptions{PerPage: 100},
+	}
+
+	maintainers := make([]string, 0)
+	for {
+		users, resp, err := t.service.ListTeamMembersBySlug(ctx, t.organization, teamSlug, opt)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotModified {
+				ghmetrics.EtagCacheHitsTotal.WithLabelValues(t.githubName, t.organization, "team-maintainers").Inc()
+				if cached, ok := t.cache.getValue(firstPageKey); ok {
+					if v, ok := cached.([]string); ok {
+						return v, nil
+					}
+				}
+				t.cache.invalidate(firstPageKey)
+				return nil, fmt.Errorf("etag cache inconsistency for %s: 304 received but no valid cached value", firstPageKey)
+			}
+			return nil, err
+		}
+		for _, user := range users {
+			if login := user.GetLogin(); login != "" {
+				maintainers = append(maintainers, login)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if etag, ok := t.cache.getEtag(firstPageKey); ok && etag != "" {
+		ghmetrics.EtagCacheMissesTotal.WithLabelValues(t.githubName, t.organization, "team-maintainers").Inc()
+		t.cache.set(firstPageKey, etag, maintainers)
+	}
+
+	return maintainers, nil
+}
+
+func (t DefaultTeamsProvider) SetRole(ctx context.Context, team, user, role string) error {
+
+	// Adding an existing member again only changes their role.
+	_, response, err := t.service.AddTeamMembershipBySlug(ctx, t.organization, slug.Make(team), user, &gogithub.TeamAddTeamMembershipOptions{Role: role})
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != 200 {
+		return fmt.Errorf("changing the role of user in team response code: %d", response.StatusCode)
+	}
+
+	return nil
+}
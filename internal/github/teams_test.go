@@ -5,10 +5,13 @@ package github
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	gogithub "github.com/google/go-github/v90/github"
 )
@@ -71,7 +74,7 @@ func TestTeamsProvider_AddUser(t *testing.T) {
 					}
 				})
 
-			found, err := provider.AddUser(t.Context(), "my-team", "alice")
+			found, err := provider.AddUser(t.Context(), "my-team", "alice", "")
 
 			if found != tc.wantFound {
 				t.Errorf("found: got %v, want %v", found, tc.wantFound)
@@ -91,6 +94,96 @@ func TestTeamsProvider_AddUser(t *testing.T) {
 	}
 }
 
+func TestTeamsProvider_AddUser_EMU(t *testing.T) {
+	cases := []struct {
+		name            string
+		user            string
+		state           string
+		wantFound       bool
+		wantErrContains string
+		wantCalled      bool
+	}{
+		{name: "managed user is added", user: "alice_acme", state: "active", wantFound: true, wantCalled: true},
+		{name: "non-managed login is rejected without an API call", user: "alice", wantErrContains: "not a managed user"},
+		{name: "pending membership is not an invitation", user: "alice_acme", state: "pending", wantErrContains: "SCIM", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, mux := newTestTeamsProvider(t)
+			provider.emuShortcode = "acme"
+
+			called := false
+			mux.HandleFunc("/api/v3/orgs/test-org/teams/my-team/memberships/"+tc.user,
+				func(w http.ResponseWriter, r *http.Request) {
+					called = true
+					w.Header().Set("Content-Type", "application/json")
+					_ = json.NewEncoder(w).Encode(map[string]any{"state": tc.state, "role": "member"})
+				})
+
+			found, err := provider.AddUser(t.Context(), "my-team", tc.user, "")
+
+			if found != tc.wantFound {
+				t.Errorf("found: got %v, want %v", found, tc.wantFound)
+			}
+			if called != tc.wantCalled {
+				t.Errorf("API called: got %v, want %v", called, tc.wantCalled)
+			}
+			if tc.wantErrContains == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tc.wantErrContains != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErrContains)) {
+				t.Errorf("error %v does not contain %q", err, tc.wantErrContains)
+			}
+		})
+	}
+}
+
+func TestTeamsProvider_AddUser_SecondaryRateLimit(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"state": "active", "role": "member"})
+	}))
+	t.Cleanup(srv.Close)
+
+	// mutations of the installation are paused, so the transport rejects the call
+	// without a response
+	budget := rateLimitBudgetFor("teams-secondary-test", 1)
+	retryAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	budget.mutationsPausedUntil = retryAt
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(&http.Client{Transport: &rateLimitTransport{wrapped: srv.Client().Transport, budget: budget}}),
+		gogithub.WithAuthToken("test-token"),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("create github client: %v", err)
+	}
+	provider := &DefaultTeamsProvider{
+		service:      *client.Teams,
+		organization: "test-org",
+		cache:        &etagCache{entries: make(map[string]etagEntry)},
+	}
+
+	found, err := provider.AddUser(t.Context(), "my-team", "alice", "")
+
+	if !found {
+		t.Error("a rejected call must not report the user as not found")
+	}
+	var secondary *SecondaryRateLimitError
+	if !errors.As(err, &secondary) {
+		t.Fatalf("expected SecondaryRateLimitError, got %v", err)
+	}
+	if !secondary.RetryAt.Equal(retryAt) {
+		t.Errorf("retry at: got %v, want %v", secondary.RetryAt, retryAt)
+	}
+	if called {
+		t.Error("paused mutation was sent to Github")
+	}
+}
+
 // newTestTeamsProvider creates a DefaultTeamsProvider backed by a fake HTTP server.
 func newTestTeamsProvider(t *testing.T) (*DefaultTeamsProvider, *http.ServeMux) {
 	t.Helper()
@@ -188,3 +281,216 @@ func TestTeamsProvider_List_EnterpriseFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestTeamsProvider_NestedTeams(t *testing.T) {
+	srv, _ := NewMockGitHubServer(t, MockConfig{
+		Org:   "test-org",
+		Teams: []MockTeam{{ID: 1, Name: "department", Slug: "department"}},
+	})
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(srv.Client()),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("create github client: %v", err)
+	}
+	provider := &DefaultTeamsProvider{
+		service:      *client.Teams,
+		organization: "test-org",
+		cache:        &etagCache{entries: make(map[string]etagEntry)},
+	}
+	ctx := t.Context()
+
+	if err := provider.AddTeam(ctx, "squad", "department", TeamSettings{}); err != nil {
+		t.Fatalf("AddTeam with parent: %v", err)
+	}
+	if parent, err := provider.ParentTeam(ctx, "squad"); err != nil || parent != "department" {
+		t.Errorf("ParentTeam: got (%q, %v), want (department, nil)", parent, err)
+	}
+	if children, err := provider.ChildTeams(ctx, "department"); err != nil || len(children) != 1 || children[0] != "squad" {
+		t.Errorf("ChildTeams: got (%v, %v), want ([squad], nil)", children, err)
+	}
+
+	if err := provider.AddTeam(ctx, "other", "missing", TeamSettings{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("AddTeam with missing parent: got %v, want a not found error", err)
+	}
+
+	if err := provider.SetParentTeam(ctx, "squad", ""); err != nil {
+		t.Fatalf("SetParentTeam to top level: %v", err)
+	}
+	if parent, err := provider.ParentTeam(ctx, "squad"); err != nil || parent != "" {
+		t.Errorf("ParentTeam after removing the parent: got (%q, %v), want (\"\", nil)", parent, err)
+	}
+	if err := provider.SetParentTeam(ctx, "department", "squad"); err != nil {
+		t.Fatalf("SetParentTeam: %v", err)
+	}
+	if children, err := provider.ChildTeams(ctx, "squad"); err != nil || len(children) != 1 || children[0] != "department" {
+		t.Errorf("ChildTeams after re-parenting: got (%v, %v), want ([department], nil)", children, err)
+	}
+
+	// deleting a parent deletes its children
+	if err := provider.RemoveTeam(ctx, "squad"); err != nil {
+		t.Fatalf("RemoveTeam: %v", err)
+	}
+	if teams, err := provider.List(ctx); err != nil || len(teams) != 0 {
+		t.Errorf("List after removing the parent: got (%v, %v), want no teams", teams, err)
+	}
+}
+
+func TestTeamsProvider_Settings(t *testing.T) {
+	srv, _ := NewMockGitHubServer(t, MockConfig{
+		Org:   "test-org",
+		Teams: []MockTeam{{ID: 1, Name: "department", Slug: "department"}},
+	})
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(srv.Client()),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("create github client: %v", err)
+	}
+	provider := &DefaultTeamsProvider{
+		service:      *client.Teams,
+		organization: "test-org",
+		cache:        &etagCache{entries: make(map[string]etagEntry)},
+	}
+	ctx := t.Context()
+
+	if err := provider.AddTeam(ctx, "platform-squad", "department", TeamSettings{Name: "Platform Squad"}); err != nil {
+		t.Fatalf("AddTeam: %v", err)
+	}
+	got, err := provider.Settings(ctx, "platform-squad")
+	if err != nil {
+		t.Fatalf("Settings: %v", err)
+	}
+	want := TeamSettings{Name: "Platform Squad", Description: DEFAULT_TEAM_DESCRIPTION, Privacy: TEAM_PRIVACY_CLOSED, NotificationSetting: "notifications_enabled"}
+	if got != want {
+		t.Errorf("Settings of a new team: got %+v, want %+v", got, want)
+	}
+
+	if err := provider.EditSettings(ctx, "platform-squad", TeamSettings{Description: "the platform squad", NotificationSetting: "notifications_disabled"}); err != nil {
+		t.Fatalf("EditSettings: %v", err)
+	}
+	got, err = provider.Settings(ctx, "platform-squad")
+	if err != nil {
+		t.Fatalf("Settings: %v", err)
+	}
+	want = TeamSettings{Name: "Platform Squad", Description: "the platform squad", Privacy: TEAM_PRIVACY_CLOSED, NotificationSetting: "notifications_disabled"}
+	if got != want {
+		t.Errorf("Settings after editing: got %+v, want %+v", got, want)
+	}
+	// editing settings keeps the parent
+	if parent, err := provider.ParentTeam(ctx, "platform-squad"); err != nil || parent != "department" {
+		t.Errorf("ParentTeam after editing settings: got (%q, %v), want (department, nil)", parent, err)
+	}
+}
+
+func TestTeamsProvider_Maintainers(t *testing.T) {
+	srv, _ := NewMockGitHubServer(t, MockConfig{
+		Org:     "test-org",
+		Members: []MockUser{{Login: "alice", ID: 1}, {Login: "bob", ID: 2}},
+		Teams:   []MockTeam{{ID: 1, Name: "squad", Slug: "squad"}},
+	})
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(srv.Client()),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("create github client: %v", err)
+	}
+	provider := &DefaultTeamsProvider{
+		service:      *client.Teams,
+		organization: "test-org",
+		cache:        &etagCache{entries: make(map[string]etagEntry)},
+	}
+	ctx := t.Context()
+
+	if _, err := provider.AddUser(ctx, "squad", "alice", TEAM_ROLE_MAINTAINER); err != nil {
+		t.Fatalf("AddUser alice: %v", err)
+	}
+	if _, err := provider.AddUser(ctx, "squad", "bob", ""); err != nil {
+		t.Fatalf("AddUser bob: %v", err)
+	}
+	maintainers, err := provider.Maintainers(ctx, "squad")
+	if err != nil {
+		t.Fatalf("Maintainers: %v", err)
+	}
+	if fmt.Sprint(maintainers) != "[alice]" {
+		t.Errorf("Maintainers = %v, want [alice]", maintainers)
+	}
+
+	if err := provider.SetRole(ctx, "squad", "alice", TEAM_ROLE_MEMBER); err != nil {
+		t.Fatalf("SetRole alice: %v", err)
+	}
+	if err := provider.SetRole(ctx, "squad", "bob", TEAM_ROLE_MAINTAINER); err != nil {
+		t.Fatalf("SetRole bob: %v", err)
+	}
+	maintainers, err = provider.Maintainers(ctx, "squad")
+	if err != nil {
+		t.Fatalf("Maintainers: %v", err)
+	}
+	if fmt.Sprint(maintainers) != "[bob]" {
+		t.Errorf("Maintainers after changing roles = %v, want [bob]", maintainers)
+	}
+	members, err := provider.Members(ctx, "squad")
+	if err != nil {
+		t.Fatalf("Members: %v", err)
+	}
+	if len(members) != 2 {
+		t.Errorf("Members = %v, want alice and bob", members)
+	}
+}
+
+func TestTeamsProvider_RenameTeam(t *testing.T) {
+	srv, _ := NewMockGitHubServer(t, MockConfig{
+		Org:     "test-org",
+		Members: []MockUser{{Login: "alice", ID: 1}},
+		Teams: []MockTeam{
+			{ID: 1, Name: "old-squad", Slug: "old-squad"},
+			{ID: 2, Name: "child", Slug: "child", Parent: "old-squad"},
+			{ID: 3, Name: "taken", Slug: "taken"},
+		},
+	})
+	client, err := gogithub.NewClient(
+		gogithub.WithHTTPClient(srv.Client()),
+		gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("create github client: %v", err)
+	}
+	provider := &DefaultTeamsProvider{
+		service:      *client.Teams,
+		organization: "test-org",
+		cache:        &etagCache{entries: make(map[string]etagEntry)},
+	}
+	ctx := t.Context()
+	if _, err := provider.AddUser(ctx, "old-squad", "alice", ""); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+
+	newSlug, err := provider.RenameTeam(ctx, "old-squad", "New Squad")
+	if err != nil {
+		t.Fatalf("RenameTeam: %v", err)
+	}
+	if newSlug != "new-squad" {
+		t.Errorf("RenameTeam slug = %q, want new-squad", newSlug)
+	}
+	id, found, err := provider.TeamID(ctx, "new-squad")
+	if err != nil || !found || id != 1 {
+		t.Errorf("TeamID(new-squad) = (%d, %v, %v), want (1, true, nil)", id, found, err)
+	}
+	if _, found, _ := provider.TeamID(ctx, "old-squad"); found {
+		t.Error("old-squad must not exist after the rename")
+	}
+	members, err := provider.Members(ctx, "new-squad")
+	if err != nil || fmt.Sprint(members) != "[alice]" {
+		t.Errorf("Members(new-squad) = (%v, %v), want [alice]", members, err)
+	}
+	if parent, err := provider.ParentTeam(ctx, "child"); err != nil || parent != "New Squad" {
+		t.Errorf("ParentTeam(child) = (%q, %v), want New Squad", parent, err)
+	}
+
+	if _, err := provider.RenameTeam(ctx, "new-squad", "taken"); err == nil {
+		t.Error("expected an error when renaming to the name of another team")
+	}
+}
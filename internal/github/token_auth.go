@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	gogithub "github.com/google/go-github/v90/github"
+	"github.com/palantir/go-githubapp/githubapp"
+	githubv4 "github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+)
+
+// RequiredTokenScopes are the OAuth scopes a classic personal access token needs
+// to manage organizations, teams and repository permissions.
+var RequiredTokenScopes = []string{"admin:org", "repo"}
+
+// tokenClientCreator authenticates every client with the same token, so that app
+// and installation clients can be used with personal access tokens. The
+// installation ID passed to the installation clients is ignored.
+type tokenClientCreator struct {
+	base  githubapp.ClientCreator
+	token string
+}
+
+// NewTokenClientCreator returns a ClientCreator whose app and installation clients
+// are token clients of base. Providers created with it work unchanged.
+func NewTokenClientCreator(base githubapp.ClientCreator, token string) githubapp.ClientCreator {
+	return &tokenClientCreator{base: base, token: token}
+}
+
+// UsesTokenAuth reports whether cc authenticates with a token instead of a GitHub
+// App; installation IDs are not needed then.
+func UsesTokenAuth(cc githubapp.ClientCreator) bool {
+	switch c := cc.(type) {
+	case *tokenClientCreator:
+		return true
+	case *budgetClientCreator:
+		return UsesTokenAuth(c.ClientCreator)
+	}
+	return false
+}
+
+func (c *tokenClientCreator) NewAppClient() (*gogithub.Client, error) {
+	return c.base.NewTokenClient(c.token)
+}
+
+func (c *tokenClientCreator) NewAppV4Client() (*githubv4.Client, error) {
+	return c.base.NewTokenV4Client(c.token)
+}
+
+func (c *tokenClientCreator) NewInstallationClient(int64) (*gogithub.Client, error) {
+	return c.base.NewTokenClient(c.token)
+}
+
+func (c *tokenClientCreator) NewInstallationV4Client(int64) (*githubv4.Client, error) {
+	return c.base.NewTokenV4Client(c.token)
+}
+
+func (c *tokenClientCreator) NewTokenSourceClient(ts oauth2.TokenSource) (*gogithub.Client, error) {
+	return c.base.NewTokenSourceClient(ts)
+}
+
+func (c *tokenClientCreator) NewTokenSourceV4Client(ts oauth2.TokenSource) (*githubv4.Client, error) {
+	return c.base.NewTokenSourceV4Client(ts)
+}
+
+func (c *tokenClientCreator) NewTokenClient(token string) (*gogithub.Client, error) {
+	return c.base.NewTokenClient(token)
+}
+
+func (c *tokenClientCreator) NewTokenV4Client(token string) (*githubv4.Client, error) {
+	return c.base.NewTokenV4Client(token)
+}
+
+// TokenInfo describes the owner and the scopes of a token.
+type TokenInfo struct {
+	Login string
+	// Scopes granted to a classic personal access token.
+	Scopes []string
+	// MissingScopes are the RequiredTokenScopes not granted to a classic token.
+	MissingScopes []string
+	// FineGrained is true if GitHub did not report OAuth scopes, which is the case
+	// for fine-grained tokens. Their permissions cannot be validated up front.
+	FineGrained bool
+}
+
+// ValidateToken fetches the authenticated user (GET /user) with the app client of cc
+// and compares the OAuth scopes reported in the X-OAuth-Scopes header with
+// RequiredTokenScopes.
+func ValidateToken(ctx context.Context, cc githubapp.ClientCreator) (TokenInfo, error) {
+	client, err := cc.NewAppClient()
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("create token client: %w", err)
+	}
+	return validateToken(ctx, client)
+}
+
+func validateToken(ctx context.Context, client *gogithub.Client) (TokenInfo, error) {
+	user, response, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("getting the authenticated user: %w", err)
+	}
+	info := TokenInfo{Login: user.GetLogin()}
+	header, ok := response.Header["X-Oauth-Scopes"]
+	if !ok {
+		info.FineGrained = true
+		return info, nil
+	}
+	for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			info.Scopes = append(info.Scopes, scope)
+		}
+	}
+	for _, scope := range RequiredTokenScopes {
+		if !slices.Contains(info.Scopes, scope) {
+			info.MissingScopes = append(info.MissingScopes, scope)
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	gogithub "github.com/google/go-github/v90/github"
+	githubv4 "github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+)
+
+// recordingClientCreator records the tokens its token clients are created with.
+type recordingClientCreator struct {
+	tokens []string
+}
+
+func (c *recordingClientCreator) NewAppClient() (*gogithub.Client, error) {
+	panic("app client must not be used")
+}
+
+func (c *recordingClientCreator) NewAppV4Client() (*githubv4.Client, error) {
+	panic("app client must not be used")
+}
+
+func (c *recordingClientCreator) NewInstallationClient(int64) (*gogithub.Client, error) {
+	panic("installation client must not be used")
+}
+
+func (c *recordingClientCreator) NewInstallationV4Client(int64) (*githubv4.Client, error) {
+	panic("installation client must not be used")
+}
+
+func (c *recordingClientCreator) NewTokenSourceClient(oauth2.TokenSource) (*gogithub.Client, error) {
+	return gogithub.NewClient()
+}
+
+func (c *recordingClientCreator) NewTokenSourceV4Client(oauth2.TokenSource) (*githubv4.Client, error) {
+	return githubv4.NewClient(nil), nil
+}
+
+func (c *recordingClientCreator) NewTokenClient(token string) (*gogithub.Client, error) {
+	c.tokens = append(c.tokens, token)
+	return gogithub.NewClient()
+}
+
+func (c *recordingClientCreator) NewTokenV4Client(token string) (*githubv4.Client, error) {
+	c.tokens = append(c.tokens, token)
+	return githubv4.NewClient(nil), nil
+}
+
+func TestNewTokenClientCreator(t *testing.T) {
+	base := &recordingClientCreator{}
+	cc := NewTokenClientCreator(base, "secret-token")
+
+	if _, err := cc.NewAppClient(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cc.NewAppV4Client(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cc.NewInstallationClient(42); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cc.NewInstallationV4Client(0); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"secret-token", "secret-token", "secret-token", "secret-token"}
+	if !slices.Equal(base.tokens, want) {
+		t.Errorf("got tokens %v, want %v", base.tokens, want)
+	}
+	if !UsesTokenAuth(cc) {
+		t.Error("UsesTokenAuth: got false for a token client creator")
+	}
+	if UsesTokenAuth(base) {
+		t.Error("UsesTokenAuth: got true for another client creator")
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	tests := []struct {
+		name            string
+		scopes          []string // nil means no X-OAuth-Scopes header
+		wantScopes      []string
+		wantMissing     []string
+		wantFineGrained bool
+	}{
+		{
+			name:       "all required scopes",
+			scopes:     []string{"admin:org, repo, read:user"},
+			wantScopes: []string{"admin:org", "repo", "read:user"},
+		},
+		{
+			name:        "missing scopes",
+			scopes:      []string{"read:org"},
+			wantScopes:  []string{"read:org"},
+			wantMissing: []string{"admin:org", "repo"},
+		},
+		{
+			name:        "no scopes",
+			scopes:      []string{""},
+			wantMissing: []string{"admin:org", "repo"},
+		},
+		{
+			name:            "fine-grained token",
+			wantFineGrained: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+			mux.HandleFunc("/api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				for _, s := range tt.scopes {
+					w.Header().Add("X-OAuth-Scopes", s)
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"login": "robot", "id": 7})
+			})
+			client, err := gogithub.NewClient(
+				gogithub.WithHTTPClient(srv.Client()),
+				gogithub.WithEnterpriseURLs(srv.URL+"/api/v3/", srv.URL+"/"),
+			)
+			if err != nil {
+				t.Fatalf("create github client: %v", err)
+			}
+
+			info, err := validateToken(t.Context(), client)
+			if err != nil {
+				t.Fatalf("validateToken: %v", err)
+			}
+			if info.Login != "robot" {
+				t.Errorf("login: got %q, want robot", info.Login)
+			}
+			if !slices.Equal(info.Scopes, tt.wantScopes) {
+				t.Errorf("scopes: got %v, want %v", info.Scopes, tt.wantScopes)
+			}
+			if !slices.Equal(info.MissingScopes, tt.wantMissing) {
+				t.Errorf("missing scopes: got %v, want %v", info.MissingScopes, tt.wantMissing)
+			}
+			if info.FineGrained != tt.wantFineGrained {
+				t.Errorf("fine-grained: got %v, want %v", info.FineGrained, tt.wantFineGrained)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportConfig configures the HTTP transport of all clients of a Github.
+type TransportConfig struct {
+	// ProxyURL is the proxy for HTTP and HTTPS requests. Empty means no proxy.
+	ProxyURL string
+	// NoProxy lists hosts, domains and CIDRs reached directly, in the format of
+	// the NO_PROXY environment variable.
+	NoProxy []string
+	// CABundle holds PEM certificates trusted in addition to the system roots.
+	CABundle []byte
+}
+
+// NewTransport returns a clone of http.DefaultTransport with the proxy and CA bundle
+// of cfg; without ProxyURL the proxy environment variables apply. It is passed to
+// go-githubapp as the base transport, so that REST, GraphQL and installation token
+// requests all use it.
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("proxy URL %q must have a scheme and a host", cfg.ProxyURL)
+		}
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  cfg.ProxyURL,
+			HTTPSProxy: cfg.ProxyURL,
+			NoProxy:    strings.Join(cfg.NoProxy, ","),
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if len(cfg.CABundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(cfg.CABundle) {
+			return nil, errors.New("CA bundle contains no PEM certificate")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return transport, nil
+}
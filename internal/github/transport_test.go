@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package github
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewTransport_Proxy(t *testing.T) {
+	transport, err := NewTransport(TransportConfig{
+		ProxyURL: "http://proxy.example.com:3128",
+		NoProxy:  []string{".internal.example.com", "10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+
+	tests := []struct {
+		url       string
+		wantProxy string
+	}{
+		{url: "https://api.github.com/user", wantProxy: "http://proxy.example.com:3128"},
+		{url: "https://ghe.internal.example.com/api/v3/user", wantProxy: ""},
+		{url: "https://10.1.2.3/api/graphql", wantProxy: ""},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proxy, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.url, err)
+		}
+		got := ""
+		if proxy != nil {
+			got = proxy.String()
+		}
+		if got != tt.wantProxy {
+			t.Errorf("%s: got proxy %q, want %q", tt.url, got, tt.wantProxy)
+		}
+	}
+
+	if _, err := NewTransport(TransportConfig{ProxyURL: "proxy.example.com"}); err == nil {
+		t.Error("expected error for proxy URL without scheme")
+	}
+}
+
+func TestNewTransport_CABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	// Without the bundle the self-signed certificate of the server is rejected.
+	plain, err := NewTransport(TransportConfig{})
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	if _, err := (&http.Client{Transport: plain}).Get(srv.URL); err == nil {
+		t.Fatal("expected TLS error without CA bundle")
+	}
+
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	transport, err := NewTransport(TransportConfig{CABundle: bundle})
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with CA bundle: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+
+	if _, err := NewTransport(TransportConfig{CABundle: []byte("not a certificate")}); err == nil {
+		t.Error("expected error for CA bundle without certificates")
+	}
+}
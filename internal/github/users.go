@@ -24,11 +24,20 @@ type UsersProvider interface {
 	GithubIDByUsername(username string) (string, bool, error)
 	// IsMemberOfOrg checks whether the GitHub user with the given UID is a member of the organization.
 	IsMemberOfOrg(ctx context.Context, org string, uid string) (bool, error)
+	// GithubUserByID resolves the GitHub user with the given UID with a single, uncached
+	// lookup and returns (login, found, suspended, error). Suspension is only visible to
+	// site administrators on GitHub Enterprise Server; elsewhere the user is reported as
+	// not suspended.
+	GithubUserByID(ctx context.Context, uid string) (string, bool, bool, error)
 	// HasVerifiedEmailDomainForGithubUID checks whether the GitHub user with the given UID
 	// has an email address visible to the given organization that matches the provided domain.
 	// This uses the organization members endpoint which exposes members' verified emails to
 	// organization owners, per GitHub changelog 2019-03-25.
 	HasVerifiedEmailDomainForGithubUID(ctx context.Context, org string, uid string, domain string) (bool, error)
+	// OrgMembersVerifiedDomainEmails pages through all members of the organization once and
+	// returns their organizationVerifiedDomainEmails keyed by GitHub user ID, together with
+	// the number of GraphQL calls made. Members without such emails map to an empty slice.
+	OrgMembersVerifiedDomainEmails(ctx context.Context, org string) (map[string][]string, int, error)
 }
 
 type DefaultUsersProvider struct {
@@ -183,6 +192,21 @@ func (u *DefaultUsersProvider) IsMemberOfOrg(ctx context.Context, org string, ui
 	return isMember, nil
 }
 
+func (u *DefaultUsersProvider) GithubUserByID(ctx context.Context, uid string) (string, bool, bool, error) {
+	userID, err := strconv.ParseInt(uid, 10, 64)
+	if err != nil {
+		return "", false, false, fmt.Errorf("invalid GitHub user ID: %q (expected numeric ID): %w", uid, err)
+	}
+	user, resp, err := u.rawService.GetByID(ctx, userID)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return "", false, false, nil
+		}
+		return "", false, false, err
+	}
+	return user.GetLogin(), true, user.SuspendedAt != nil, nil
+}
+
 // HasVerifiedEmailDomainForGithubUID implements UsersProvider.HasVerifiedEmailDomainForGithubUID.
 // It uses the GitHub GraphQL API to query User.organizationVerifiedDomainEmails and
 // checks whether any email has the requested domain. This requires appropriate
@@ -230,9 +254,75 @@ func (u *DefaultUsersProvider) HasVerifiedEmailDomainForGithubUID(ctx context.Co
 		return false, err
 	}
 
-	return slices.ContainsFunc(q.User.Emails, func(e githubv4.String) bool {
-		email := string(e)
+	emails := make([]string, 0, len(q.User.Emails))
+	for _, e := range q.User.Emails {
+		emails = append(emails, string(e))
+	}
+	return HasEmailInDomain(emails, domain), nil
+}
+
+func (u *DefaultUsersProvider) OrgMembersVerifiedDomainEmails(ctx context.Context, org string) (map[string][]string, int, error) {
+	if u.http == nil {
+		return nil, 0, errors.New("missing underlying http client for GraphQL")
+	}
+	return orgMembersVerifiedDomainEmails(ctx, githubv4.NewClient(u.http.Client()), u.githubName, org)
+}
+
+// orgMembersVerifiedDomainEmailsQuery pages through the members of an organization
+// together with the emails they have verified under the organization's domains.
+type orgMembersVerifiedDomainEmailsQuery struct {
+	Organization struct {
+		MembersWithRole struct {
+			PageInfo struct {
+				HasNextPage githubv4.Boolean
+				EndCursor   githubv4.String
+			}
+			Nodes []struct {
+				DatabaseId githubv4.Int
+				Emails     []githubv4.String `graphql:"organizationVerifiedDomainEmails(login: $org)"`
+			}
+		} `graphql:"membersWithRole(first: 100, after: $cursor)"`
+	} `graphql:"organization(login: $org)"`
+}
+
+func orgMembersVerifiedDomainEmails(ctx context.Context, v4 *githubv4.Client, githubName, org string) (map[string][]string, int, error) {
+	members := make(map[string][]string)
+	calls := 0
+	var cursor *githubv4.String
+	for {
+		var query orgMembersVerifiedDomainEmailsQuery
+		vars := map[string]any{
+			"org":    githubv4.String(org),
+			"cursor": cursor,
+		}
+		calls++
+		if err := v4.Query(ctx, &query, vars); err != nil {
+			ghmetrics.GraphQLCallsTotal.WithLabelValues(githubName, org, "error").Inc()
+			return nil, calls, fmt.Errorf("list verified domain emails of %s members: %w", org, err)
+		}
+		ghmetrics.GraphQLCallsTotal.WithLabelValues(githubName, org, "success").Inc()
+
+		for _, node := range query.Organization.MembersWithRole.Nodes {
+			emails := make([]string, 0, len(node.Emails))
+			for _, e := range node.Emails {
+				emails = append(emails, string(e))
+			}
+			members[strconv.FormatInt(int64(node.DatabaseId), 10)] = emails
+		}
+
+		if !query.Organization.MembersWithRole.PageInfo.HasNextPage {
+			break
+		}
+		next := query.Organization.MembersWithRole.PageInfo.EndCursor
+		cursor = &next
+	}
+	return members, calls, nil
+}
+
+// HasEmailInDomain reports whether any of the emails belongs to domain (case-insensitive).
+func HasEmailInDomain(emails []string, domain string) bool {
+	return slices.ContainsFunc(emails, func(email string) bool {
 		at := strings.LastIndexByte(email, '@')
 		return at > 0 && at < len(email)-1 && strings.EqualFold(email[at+1:], domain)
-	}), nil
+	})
 }
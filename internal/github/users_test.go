@@ -4,10 +4,16 @@
 package github
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+
+	githubv4 "github.com/shurcooL/githubv4"
 )
 
 func TestDefaultUsersProvider_ParseIntErrorWrapping(t *testing.T) {
@@ -42,6 +48,16 @@ func TestDefaultUsersProvider_ParseIntErrorWrapping(t *testing.T) {
 		}
 	})
 
+	t.Run("GithubUserByID", func(t *testing.T) {
+		_, _, _, err := u.GithubUserByID(t.Context(), uid)
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("expected strconv.ErrSyntax, got %v", err)
+		}
+	})
+
 	t.Run("HasVerifiedEmailDomainForGithubUID", func(t *testing.T) {
 		_, err := u.HasVerifiedEmailDomainForGithubUID(t.Context(), "org", uid, "domain.com")
 		if err == nil {
@@ -56,3 +72,57 @@ func TestDefaultUsersProvider_ParseIntErrorWrapping(t *testing.T) {
 		}
 	})
 }
+
+func buildOrgMembersEmailsResponse(nodes []map[string]any, hasNextPage bool, endCursor string) string {
+	data := map[string]any{
+		"organization": map[string]any{
+			"membersWithRole": map[string]any{
+				"pageInfo": map[string]any{"hasNextPage": hasNextPage, "endCursor": endCursor},
+				"nodes":    nodes,
+			},
+		},
+	}
+	b, _ := json.Marshal(graphqlResponse{Data: data})
+	return string(b)
+}
+
+func TestOrgMembersVerifiedDomainEmails_Pagination(t *testing.T) {
+	page1 := buildOrgMembersEmailsResponse([]map[string]any{
+		{"databaseId": 1, "organizationVerifiedDomainEmails": []string{"alice@example.com"}},
+		{"databaseId": 2, "organizationVerifiedDomainEmails": []string{}},
+	}, true, "cursor1")
+	page2 := buildOrgMembersEmailsResponse([]map[string]any{
+		{"databaseId": 3, "organizationVerifiedDomainEmails": []string{"carol@Example.COM", "carol@other.org"}},
+	}, false, "")
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Write([]byte(page1)) //nolint:errcheck
+		} else {
+			w.Write([]byte(page2)) //nolint:errcheck
+		}
+	}))
+	defer srv.Close()
+
+	members, n, err := orgMembersVerifiedDomainEmails(t.Context(), githubv4.NewEnterpriseClient(srv.URL+"/", srv.Client()), "com", "test-org")
+	if err != nil {
+		t.Fatalf("orgMembersVerifiedDomainEmails: unexpected error: %v", err)
+	}
+	if n != 2 || calls != 2 {
+		t.Errorf("expected 2 GraphQL calls, got reported=%d served=%d", n, calls)
+	}
+	if len(members) != 3 {
+		t.Fatalf("expected 3 members, got %v", members)
+	}
+	if !HasEmailInDomain(members["1"], "example.com") {
+		t.Errorf("member 1 should have an example.com email: %v", members["1"])
+	}
+	if emails, ok := members["2"]; !ok || HasEmailInDomain(emails, "example.com") {
+		t.Errorf("member 2 should be present without emails: %v, %v", emails, ok)
+	}
+	if !HasEmailInDomain(members["3"], "example.com") || HasEmailInDomain(members["3"], "example.net") {
+		t.Errorf("unexpected domain match for member 3: %v", members["3"])
+	}
+}
@@ -129,6 +129,58 @@ var (
 		[]string{"organization", "team"},
 	)
 
+	// Time-bound memberships of GithubTeamMembershipGrants
+	MembershipGrantsActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "repo_guard",
+			Subsystem: "githubteam",
+			Name:      "membership_grants_active",
+			Help:      "Number of active GithubTeamMembershipGrants merged into the members of this GitHub team.",
+		},
+		[]string{"organization", "team"},
+	)
+
+	MembershipGrantsExpiredTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "githubteam",
+			Name:      "membership_grants_expired_total",
+			Help:      "Total number of GithubTeamMembershipGrants of this GitHub team that expired.",
+		},
+		[]string{"organization", "team"},
+	)
+
+	AccessRequestDecisionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "githubaccessrequest",
+			Name:      "decisions_total",
+			Help:      "Total number of GithubAccessRequests by namespace and decision: approved, denied, expired or revoked.",
+		},
+		[]string{"namespace", "decision"},
+	)
+
+	// Users stuck in the notfound state and their periodic re-checks
+	NotFoundUsers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "repo_guard",
+			Subsystem: "githubteam",
+			Name:      "notfound_users",
+			Help:      "Number of distinct users whose add operation is in the notfound state for this GitHub team.",
+		},
+		[]string{"organization", "team"},
+	)
+
+	NotFoundRechecksTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "githubteam",
+			Name:      "notfound_rechecks_total",
+			Help:      "Total number of notfound users re-resolved against GitHub, by result (resolved, notfound or error).",
+		},
+		[]string{"organization", "team", "result"},
+	)
+
 	// Sync failure counters
 	OrgSyncFailuresTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -161,6 +213,16 @@ var (
 		[]string{"controller", "type"},
 	)
 
+	RateLimitRemaining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "repo_guard",
+			Subsystem: "github",
+			Name:      "ratelimit_remaining",
+			Help:      "Remaining GitHub API rate limit as last reported by GitHub, by github instance, installation and rate-limit resource (core, graphql, ...).",
+		},
+		[]string{"github", "installation", "resource"},
+	)
+
 	RateLimitBackoffSeconds = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "repo_guard",
@@ -225,6 +287,84 @@ var (
 		},
 		[]string{"github", "organization", "endpoint"},
 	)
+
+	EtagCacheEvictionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "github",
+			Name:      "etag_cache_evictions_total",
+			Help:      "Total number of ETag cache entries evicted because the cache of an organization reached its size bound.",
+		},
+		[]string{"github", "organization"},
+	)
+
+	EtagCacheEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "repo_guard",
+			Subsystem: "github",
+			Name:      "etag_cache_entries",
+			Help:      "Number of entries in the ETag cache of an organization.",
+		},
+		[]string{"github", "organization"},
+	)
+
+	WebhookEventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "github",
+			Name:      "webhook_events_total",
+			Help:      "Total number of GitHub webhook deliveries received, by github instance, event type and result (accepted, ignored, rejected, dropped, error).",
+		},
+		[]string{"github", "event", "result"},
+	)
+
+	OutOfBandChangesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "github",
+			Name:      "out_of_band_changes_total",
+			Help:      "Total number of audit log entries of access changes made by other actors than repo-guard, by github instance, organization and action.",
+		},
+		[]string{"github", "organization", "action"},
+	)
+
+	// Verified-domain email checks, per-link vs. per-organization batch
+	EmailVerificationChecksTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "github",
+			Name:      "email_verification_checks_total",
+			Help:      "Total number of GithubAccountLink verified-domain email checks, by github instance, organization and mode (per_link or batch).",
+		},
+		[]string{"github", "organization", "mode"},
+	)
+
+	EmailVerificationAPICallsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "github",
+			Name:      "email_verification_api_calls_total",
+			Help:      "Total number of GitHub API calls made for verified-domain email checks, by github instance, organization and mode (per_link or batch).",
+		},
+		[]string{"github", "organization", "mode"},
+	)
+
+	EmailVerificationAPICallsSavedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "repo_guard",
+			Subsystem: "github",
+			Name:      "email_verification_api_calls_saved_total",
+			Help:      "Estimated number of GitHub API calls the per-link path would have made for checks served by batch mode, minus the calls batch mode made.",
+		},
+		[]string{"github", "organization"},
+	)
+)
+
+// Calls made by the per-link verified-domain email check: user lookup and membership check,
+// plus a second user lookup and the GraphQL query for members.
+const (
+	EmailVerificationPerLinkCallsNonMember = 2
+	EmailVerificationPerLinkCallsMember    = 4
 )
 
 func init() {
@@ -240,15 +380,28 @@ func init() {
 		ManagedTeamsTotal,
 		ManagedReposTotal,
 		ManagedMembersTotal,
+		MembershipGrantsActive,
+		MembershipGrantsExpiredTotal,
+		AccessRequestDecisionsTotal,
+		NotFoundUsers,
+		NotFoundRechecksTotal,
 		OrgSyncFailuresTotal,
 		TeamSyncFailuresTotal,
 		RateLimitHitsTotal,
 		RateLimitBackoffSeconds,
+		RateLimitRemaining,
 		PendingOperationsTotal,
 		GraphQLCallsTotal,
 		EtagCacheHitsTotal,
 		EtagCacheMissesTotal,
+		EtagCacheEvictionsTotal,
+		EtagCacheEntries,
+		WebhookEventsTotal,
+		OutOfBandChangesTotal,
 		OrgStatusPayloadBytes,
+		EmailVerificationChecksTotal,
+		EmailVerificationAPICallsTotal,
+		EmailVerificationAPICallsSavedTotal,
 	)
 }
 
@@ -458,7 +611,7 @@ func SetGithubTeamMetrics(team *v1.GithubTeam) {
 	}
 
 	// zero ops buckets
-	ops := []string{"add", "remove"}
+	ops := []string{"add", "remove", "role"}
 	states := []string{"pending", "complete", "failed", "skipped", "notfound"}
 	for _, op := range ops {
 		for _, st := range states {
@@ -484,10 +637,50 @@ func SetGithubTeamMetrics(team *v1.GithubTeam) {
 
 	// managed members total
 	ManagedMembersTotal.WithLabelValues(org, tname).Set(float64(len(team.Status.Members)))
+
+	// active time-bound memberships
+	MembershipGrantsActive.WithLabelValues(org, tname).Set(float64(len(team.Status.MembershipGrants)))
+
+	// distinct users stuck in notfound
+	notFound := map[string]struct{}{}
+	for _, o := range team.Status.Operations {
+		if o.Operation == v1.GithubUserOperationTypeAdd && o.State == v1.GithubUserOperationStateNotFound {
+			notFound[strings.ToLower(o.User)] = struct{}{}
+		}
+	}
+	NotFoundUsers.WithLabelValues(org, tname).Set(float64(len(notFound)))
+}
+
+// ObserveNotFoundRecheck records the outcome of re-resolving users in the notfound state.
+// result is "resolved", "notfound" or "error"; users is the number of users with that outcome.
+func ObserveNotFoundRecheck(organization, team, result string, users int) {
+	if users <= 0 {
+		return
+	}
+	NotFoundRechecksTotal.WithLabelValues(organization, team, result).Add(float64(users))
 }
 
 // IncOrgSyncFailures increments the sync failure counter for the given org and scope.
 // Call inside the controller defer after status is finalized.
+// ObserveEmailVerificationPerLink records one per-link verified-domain email check and the
+// GitHub API calls it made.
+func ObserveEmailVerificationPerLink(github, organization string, calls int) {
+	EmailVerificationChecksTotal.WithLabelValues(github, organization, "per_link").Inc()
+	EmailVerificationAPICallsTotal.WithLabelValues(github, organization, "per_link").Add(float64(calls))
+}
+
+// ObserveEmailVerificationBatch records a batch pass that served checks, of which members
+// were organization members, with calls GitHub API calls, and the calls saved compared to
+// the per-link path.
+func ObserveEmailVerificationBatch(github, organization string, checks, members, calls int) {
+	EmailVerificationChecksTotal.WithLabelValues(github, organization, "batch").Add(float64(checks))
+	EmailVerificationAPICallsTotal.WithLabelValues(github, organization, "batch").Add(float64(calls))
+	perLink := members*EmailVerificationPerLinkCallsMember + (checks-members)*EmailVerificationPerLinkCallsNonMember
+	if saved := perLink - calls; saved > 0 {
+		EmailVerificationAPICallsSavedTotal.WithLabelValues(github, organization).Add(float64(saved))
+	}
+}
+
 func IncOrgSyncFailures(github, organization, scope string) {
 	OrgSyncFailuresTotal.WithLabelValues(github, organization, scope).Inc()
 }
@@ -497,6 +690,11 @@ func IncTeamSyncFailure(organization, team string) {
 	TeamSyncFailuresTotal.WithLabelValues(organization, team).Inc()
 }
 
+// IncMembershipGrantExpired increments the counter of expired membership grants for the given team.
+func IncMembershipGrantExpired(organization, team string) {
+	MembershipGrantsExpiredTotal.WithLabelValues(organization, team).Inc()
+}
+
 // ObserveRateLimitHit records a rate-limit event and the backoff window.
 // limitType is "api" or "invitation". backoff is the duration until reset (0 if already reset).
 func ObserveRateLimitHit(controller, limitType string, backoff time.Duration) {
@@ -212,6 +212,58 @@ func TestSetGithubTeamMetrics_ManagedMembers(t *testing.T) {
 		"ManagedMembersTotal should equal len(Members)")
 }
 
+func TestSetGithubTeamMetrics_MembershipGrants(t *testing.T) {
+	team := &v1.GithubTeam{
+		Spec: v1.GithubTeamSpec{
+			Organization: "sapcc",
+			Team:         "grants-team",
+		},
+		Status: v1.GithubTeamStatus{
+			MembershipGrants: []v1.MembershipGrantStatus{
+				{Name: "oncall-alice", User: "alice"},
+				{Name: "incident-bob", User: "bob"},
+			},
+		},
+	}
+
+	SetGithubTeamMetrics(team)
+
+	assert.Equal(t, float64(2),
+		testutil.ToFloat64(MembershipGrantsActive.WithLabelValues("sapcc", "grants-team")),
+		"MembershipGrantsActive should equal len(MembershipGrants)")
+}
+
+func TestSetGithubTeamMetrics_NotFoundUsers(t *testing.T) {
+	team := &v1.GithubTeam{
+		Spec: v1.GithubTeamSpec{
+			Organization: "sapcc",
+			Team:         "notfound-team",
+		},
+		Status: v1.GithubTeamStatus{
+			Operations: []v1.GithubUserOperation{
+				{Operation: v1.GithubUserOperationTypeAdd, User: "ghost", State: v1.GithubUserOperationStateNotFound},
+				{Operation: v1.GithubUserOperationTypeAdd, User: "Ghost", State: v1.GithubUserOperationStateNotFound},
+				{Operation: v1.GithubUserOperationTypeAdd, User: "phantom", State: v1.GithubUserOperationStateNotFound},
+				{Operation: v1.GithubUserOperationTypeAdd, User: "alice", State: v1.GithubUserOperationStateComplete},
+			},
+		},
+	}
+
+	SetGithubTeamMetrics(team)
+
+	assert.Equal(t, float64(2),
+		testutil.ToFloat64(NotFoundUsers.WithLabelValues("sapcc", "notfound-team")),
+		"NotFoundUsers should count distinct users case-insensitively")
+}
+
+func TestObserveNotFoundRecheck(t *testing.T) {
+	before := testutil.ToFloat64(NotFoundRechecksTotal.WithLabelValues("sapcc", "recheck-team", "resolved"))
+	ObserveNotFoundRecheck("sapcc", "recheck-team", "resolved", 3)
+	ObserveNotFoundRecheck("sapcc", "recheck-team", "resolved", 0)
+	assert.Equal(t, before+3,
+		testutil.ToFloat64(NotFoundRechecksTotal.WithLabelValues("sapcc", "recheck-team", "resolved")))
+}
+
 func TestObserveRateLimitHit(t *testing.T) {
 	// Use unique controller names to avoid interference from other tests
 	ctrl := "TestRateLimitController"
@@ -277,3 +329,19 @@ func TestObserveRateLimitHit_ZeroBackoff(t *testing.T) {
 		}
 	}
 }
+
+func TestObserveEmailVerification(t *testing.T) {
+	ObserveEmailVerificationPerLink("com", "ev-org", EmailVerificationPerLinkCallsMember)
+	assert.Equal(t, 1.0, testutil.ToFloat64(EmailVerificationChecksTotal.WithLabelValues("com", "ev-org", "per_link")))
+	assert.Equal(t, 4.0, testutil.ToFloat64(EmailVerificationAPICallsTotal.WithLabelValues("com", "ev-org", "per_link")))
+
+	// 10 checks, 6 members: per-link would have made 6*4 + 4*2 = 32 calls; batch made 2.
+	ObserveEmailVerificationBatch("com", "ev-org", 10, 6, 2)
+	assert.Equal(t, 10.0, testutil.ToFloat64(EmailVerificationChecksTotal.WithLabelValues("com", "ev-org", "batch")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(EmailVerificationAPICallsTotal.WithLabelValues("com", "ev-org", "batch")))
+	assert.Equal(t, 30.0, testutil.ToFloat64(EmailVerificationAPICallsSavedTotal.WithLabelValues("com", "ev-org")))
+
+	// A batch that costs more than the per-link path never decreases the saved counter.
+	ObserveEmailVerificationBatch("com", "ev-org", 1, 0, 5)
+	assert.Equal(t, 30.0, testutil.ToFloat64(EmailVerificationAPICallsSavedTotal.WithLabelValues("com", "ev-org")))
+}